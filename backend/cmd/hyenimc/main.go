@@ -1,17 +1,25 @@
 package main
 
 import (
-	"crypto/rand"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"io"
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	grpclib "google.golang.org/grpc"
 
 	"hyenimc/backend/internal/account"
 	"hyenimc/backend/internal/db"
 	"hyenimc/backend/internal/grpc"
+	httpapi "hyenimc/backend/internal/http"
+	"hyenimc/backend/internal/httpclient"
+	"hyenimc/backend/internal/keystore"
+	"hyenimc/backend/internal/msauth"
 	"hyenimc/backend/internal/profile"
 	"hyenimc/backend/internal/services"
 	"hyenimc/backend/internal/settings"
@@ -48,47 +56,173 @@ func main() {
 	// Initialize settings service
 	settingsRepo := settings.NewRepository(db.Get())
 	settingsService := settings.NewService(settingsRepo)
+	watchNetworkSettings(settingsService)
 
 	// Initialize profile service (SQLite based)
 	profileRepo := profile.NewRepository(db.Get())
 	profileService := services.NewProfileService(profileRepo, dataDir)
+	profileService.SetTrashRetention(settingsService.TrashRetention)
+	profileService.StartTrashPurger(context.Background(), services.TrashPurgeInterval)
 
 	// Initialize account service
-	encryptionKey := getOrCreateEncryptionKey(dataDir)
-	deviceID := generateDeviceID(dataDir)
 	accountRepo := account.NewRepository(db.Get())
-	accountService := services.NewAccountService(accountRepo, encryptionKey, deviceID)
+	keyID, err := accountRepo.ActiveKeyID()
+	if err != nil {
+		log.Fatalf("failed to read active encryption key: %v", err)
+	}
+	storedAccounts, err := accountRepo.ListEncrypted()
+	if err != nil {
+		log.Fatalf("failed to read stored accounts: %v", err)
+	}
+	keyInUse := false
+	for _, acc := range storedAccounts {
+		if acc.KeyID == keyID {
+			keyInUse = true
+			break
+		}
+	}
+	encryptionKey, keyStore, err := getOrCreateEncryptionKey(dataDir, keyID, keyInUse)
+	if err != nil {
+		// Everything but stored tokens works without the key, so start anyway
+		log.Printf("[Main] Account tokens unavailable until the keyring is unlocked and the launcher restarted: %v", err)
+	}
+	deviceID := generateDeviceID(dataDir)
+	msAuth := msauth.NewClient(os.Getenv("AZURE_CLIENT_ID"), msauth.DefaultEndpoints())
+	accountService := services.NewAccountService(accountRepo, encryptionKey, keyID, keyStore, deviceID, msAuth, filepath.Join(dataDir, "skins"))
+	accountService.StartTokenRefresher(context.Background(), services.TokenRefreshInterval, services.TokenRefreshWindow)
+
+	// Every gRPC call must carry this launch's secret
+	auth, httpToken, err := launchAuth()
+	if err != nil {
+		log.Fatalf("failed to set up gRPC auth: %v", err)
+	}
+
+	// Start the opt-in REST/JSON gateway on loopback; it serves the gRPC services once they are registered below
+	gateway := startHTTPGateway(dataDir, profileService, httpToken)
 
-	// Start gRPC server (prints chosen address to stdout internally)
+	// Start gRPC server (writes the chosen address to .grpc-port internally)
 	addr := os.Getenv("HYENIMC_ADDR")
-	if err := grpc.StartGRPCServer(addr, db.Get(), dataDir, profileService, settingsService, accountService); err != nil {
+	if err := grpc.StartGRPCServer(addr, auth, db.Get(), dataDir, profileService, settingsService, accountService, gateway); err != nil {
 		log.Fatalf("failed to start gRPC server: %v", err)
 	}
 }
 
-// getOrCreateEncryptionKey gets or creates the encryption key
-func getOrCreateEncryptionKey(dataDir string) []byte {
-	keyPath := filepath.Join(dataDir, ".key")
-	
-	// Try to read existing key
-	key, err := os.ReadFile(keyPath)
-	if err == nil && len(key) == 32 {
-		return key
+// launchAuth returns the per-launch gRPC secret and REST gateway token. With
+// HYENIMC_AUTH=stdin the launcher writes them as the first lines on stdin (the gateway
+// token only matters when HYENIMC_HTTP_ADDR is set), so neither touches disk; otherwise
+// fresh ones are generated and published in .grpc-port and .http-token for development tools.
+func launchAuth() (auth grpc.Auth, httpToken string, err error) {
+	if os.Getenv("HYENIMC_AUTH") == "stdin" {
+		n := 1
+		if os.Getenv("HYENIMC_HTTP_ADDR") != "" {
+			n = 2
+		}
+		tokens, err := grpc.ReadAuthTokens(os.Stdin, n, 10*time.Second)
+		if err != nil {
+			return grpc.Auth{}, "", err
+		}
+		if n == 2 {
+			httpToken = tokens[1]
+		}
+		return grpc.Auth{Token: tokens[0]}, httpToken, nil
 	}
-	
-	// Generate new key
-	key = make([]byte, 32)
-	if _, err := io.ReadFull(rand.Reader, key); err != nil {
-		log.Fatalf("failed to generate encryption key: %v", err)
+	token, err := grpc.NewAuthToken()
+	return grpc.Auth{Token: token, Publish: true}, "", err
+}
+
+// startHTTPGateway starts the REST gateway when HYENIMC_HTTP_ADDR is set
+// (127.0.0.1:0 picks a free port); it is off by default.
+// HYENIMC_HTTP_ORIGINS adds comma-separated browser origins to the allowed list.
+// token is the gateway token handed over on stdin; when empty the server makes one
+// and writes it to .http-token.
+// It returns nil when the gateway is not running.
+func startHTTPGateway(dataDir string, profileService *services.ProfileService, token string) grpclib.ServiceRegistrar {
+	addr := os.Getenv("HYENIMC_HTTP_ADDR")
+	if addr == "" {
+		return nil
 	}
-	
-	// Save with restrictive permissions
-	if err := os.WriteFile(keyPath, key, 0600); err != nil {
-		log.Fatalf("failed to save encryption key: %v", err)
+	origins := append([]string{}, httpapi.DefaultAllowedOrigins...)
+	if extra := os.Getenv("HYENIMC_HTTP_ORIGINS"); extra != "" {
+		origins = append(origins, strings.Split(extra, ",")...)
 	}
-	
-	log.Println("[Main] Generated new encryption key")
-	return key
+
+	gateway := httpapi.NewGateway()
+	server, err := httpapi.NewServer(profileService, gateway, origins, token)
+	if err == nil {
+		_, err = server.Start(addr, dataDir)
+	}
+	if err != nil {
+		// gRPC stays the primary API; the launcher works without the gateway
+		log.Printf("[Main] REST gateway disabled: %v", err)
+		return nil
+	}
+	return gateway
+}
+
+// watchNetworkSettings applies the proxy/CA/timeout settings to every outbound
+// HTTP client now and whenever a network.* setting changes
+func watchNetworkSettings(svc *settings.Service) {
+	apply := func() {
+		cfg, err := svc.NetworkConfig()
+		if err == nil {
+			err = httpclient.Configure(cfg)
+		}
+		if err != nil {
+			log.Printf("[Main] Failed to apply network settings, keeping previous configuration: %v", err)
+			return
+		}
+		log.Printf("[Main] Network settings applied (proxy mode: %s)", cfg.ProxyMode)
+	}
+	apply()
+
+	changes, _ := svc.Subscribe(4)
+	go func() {
+		for change := range changes {
+			for _, key := range change.Keys {
+				if settings.IsNetworkKey(key) {
+					apply()
+					break
+				}
+			}
+		}
+	}()
+}
+
+// getOrCreateEncryptionKey gets or creates the encryption key version keyID
+// and returns it with the provider holding it.
+// The key lives in the OS keyring when available (HYENIMC_KEYRING=file disables it);
+// a legacy <dataDir>/.key file is migrated into the keyring automatically.
+// No key is ever generated while stored tokens reference keyID: a new key could not
+// decrypt them, so an unreadable keyring is returned as an error instead.
+func getOrCreateEncryptionKey(dataDir, keyID string, keyInUse bool) ([]byte, keystore.Provider, error) {
+	var system keystore.Provider
+	if os.Getenv("HYENIMC_KEYRING") != "file" {
+		system = keystore.NewSystemProvider()
+	}
+
+	fileStore := keystore.NewFileProvider(dataDir)
+	name := keystore.AccountKeyNameFor(keyID)
+	if keyInUse {
+		key, provider, err := keystore.LoadKey(system, fileStore, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("[Main] Encryption key loaded from %s", provider.Name())
+		return key, provider, nil
+	}
+
+	key, provider, err := keystore.LoadOrCreateKey(system, fileStore, name)
+	if errors.Is(err, keystore.ErrKeyUnreadable) {
+		// Nothing is encrypted yet, so no key can be lost: keep one in the file
+		log.Printf("[Main] %v; storing the key in a file instead", err)
+		key, provider, err = keystore.LoadOrCreateKey(nil, fileStore, name)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("[Main] Encryption key loaded from %s", provider.Name())
+	return key, provider, nil
 }
 
 // generateDeviceID generates a unique device identifier
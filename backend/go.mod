@@ -4,6 +4,9 @@ go 1.23.0
 
 require (
 	github.com/google/uuid v1.6.0
+	golang.org/x/crypto v0.21.0
+	golang.org/x/net v0.22.0
+	golang.org/x/sys v0.34.0
 	google.golang.org/grpc v1.64.0
 	google.golang.org/protobuf v1.34.1
 	modernc.org/sqlite v1.39.0
@@ -15,8 +18,6 @@ require (
 	github.com/ncruces/go-strftime v0.1.9 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
-	golang.org/x/net v0.22.0 // indirect
-	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 	modernc.org/libc v1.66.3 // indirect
@@ -22,9 +22,9 @@ func NewRepository(db *sql.DB) *Repository {
 func (r *Repository) Save(account *domain.Account) error {
 	query := `
 		INSERT INTO accounts (
-			id, name, uuid, type, encrypted_data, iv, auth_tag, 
-			skin_url, last_used, device_id, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, name, uuid, type, encrypted_data, iv, auth_tag, key_id,
+			skin_url, auth_server, last_used, device_id, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			uuid = excluded.uuid,
@@ -32,7 +32,9 @@ func (r *Repository) Save(account *domain.Account) error {
 			encrypted_data = excluded.encrypted_data,
 			iv = excluded.iv,
 			auth_tag = excluded.auth_tag,
+			key_id = excluded.key_id,
 			skin_url = excluded.skin_url,
+			auth_server = excluded.auth_server,
 			last_used = excluded.last_used,
 			device_id = excluded.device_id,
 			updated_at = excluded.updated_at
@@ -46,7 +48,9 @@ func (r *Repository) Save(account *domain.Account) error {
 		account.EncryptedData,
 		account.IV,
 		account.AuthTag,
+		account.KeyID,
 		account.SkinURL,
+		account.AuthServer,
 		account.LastUsed,
 		account.DeviceID,
 		account.CreatedAt.Unix(),
@@ -59,8 +63,8 @@ func (r *Repository) Save(account *domain.Account) error {
 // Get retrieves an account by ID
 func (r *Repository) Get(id string) (*domain.Account, error) {
 	query := `
-		SELECT id, name, uuid, type, encrypted_data, iv, auth_tag, 
-		       skin_url, last_used, device_id, created_at, updated_at
+		SELECT id, name, uuid, type, encrypted_data, iv, auth_tag, key_id,
+		       skin_url, auth_server, last_used, device_id, created_at, updated_at
 		FROM accounts
 		WHERE id = ?
 	`
@@ -76,7 +80,9 @@ func (r *Repository) Get(id string) (*domain.Account, error) {
 		&account.EncryptedData,
 		&account.IV,
 		&account.AuthTag,
+		&account.KeyID,
 		&account.SkinURL,
+		&account.AuthServer,
 		&account.LastUsed,
 		&account.DeviceID,
 		&createdAt,
@@ -99,8 +105,8 @@ func (r *Repository) Get(id string) (*domain.Account, error) {
 // List retrieves all accounts for a device, sorted by last used
 func (r *Repository) List(deviceID string) ([]*domain.Account, error) {
 	query := `
-		SELECT id, name, uuid, type, encrypted_data, iv, auth_tag, 
-		       skin_url, last_used, device_id, created_at, updated_at
+		SELECT id, name, uuid, type, encrypted_data, iv, auth_tag, key_id,
+		       skin_url, auth_server, last_used, device_id, created_at, updated_at
 		FROM accounts
 		WHERE device_id = ?
 		ORDER BY last_used DESC
@@ -126,7 +132,9 @@ func (r *Repository) List(deviceID string) ([]*domain.Account, error) {
 			&account.EncryptedData,
 			&account.IV,
 			&account.AuthTag,
+			&account.KeyID,
 			&account.SkinURL,
+			&account.AuthServer,
 			&account.LastUsed,
 			&account.DeviceID,
 			&createdAt,
@@ -158,9 +166,101 @@ func (r *Repository) UpdateLastUsed(id string, lastUsed int64) error {
 	return err
 }
 
+// UpdateSkinURL updates the active skin URL
+func (r *Repository) UpdateSkinURL(id, skinURL string) error {
+	query := `
+		UPDATE accounts
+		SET skin_url = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(query, skinURL, time.Now().Unix(), id)
+	return err
+}
+
 // Delete removes an account
 func (r *Repository) Delete(id string) error {
 	query := `DELETE FROM accounts WHERE id = ?`
 	_, err := r.db.Exec(query, id)
 	return err
 }
+
+// ListEncrypted retrieves every account row holding encrypted tokens, on any device
+func (r *Repository) ListEncrypted() ([]*domain.Account, error) {
+	query := `
+		SELECT id, encrypted_data, iv, auth_tag, key_id
+		FROM accounts
+		WHERE encrypted_data IS NOT NULL AND encrypted_data != ''
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*domain.Account
+	for rows.Next() {
+		var account domain.Account
+		if err := rows.Scan(&account.ID, &account.EncryptedData, &account.IV, &account.AuthTag, &account.KeyID); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, &account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// ActiveKeyID returns the id of the key new tokens are encrypted with
+func (r *Repository) ActiveKeyID() (string, error) {
+	var id string
+	err := r.db.QueryRow(`SELECT id FROM encryption_keys WHERE active = 1`).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// RotateKey stores re-encrypted tokens for accounts and makes keyID the active key,
+// all in one transaction. It fails without changes if any row still references
+// oldKeyID afterwards, since that key is about to be retired.
+func (r *Repository) RotateKey(oldKeyID, keyID string, accounts []*domain.Account) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for _, account := range accounts {
+		_, err := tx.Exec(`
+			UPDATE accounts
+			SET encrypted_data = ?, iv = ?, auth_tag = ?, key_id = ?, updated_at = ?
+			WHERE id = ?
+		`, account.EncryptedData, account.IV, account.AuthTag, keyID, now, account.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update account %s: %w", account.ID, err)
+		}
+	}
+
+	var remaining int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM accounts
+		WHERE key_id = ? AND encrypted_data IS NOT NULL AND encrypted_data != ''
+	`, oldKeyID).Scan(&remaining)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return fmt.Errorf("%d accounts still use key %q", remaining, oldKeyID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM encryption_keys`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO encryption_keys (id, active, created_at) VALUES (?, 1, ?)`, keyID, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
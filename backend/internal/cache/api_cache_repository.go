@@ -3,12 +3,24 @@ package cache
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
+	"sync"
 	"time"
 )
 
+// APICacheLimits bound the API cache; zero MaxTTL or MaxBytes means no limit
+type APICacheLimits struct {
+	Disabled bool
+	MaxTTL   time.Duration // caps the TTL of new entries
+	MaxBytes int64         // total size of cached responses
+}
+
 // APICacheRepository handles API response caching
 type APICacheRepository struct {
 	db *sql.DB
+
+	mu     sync.RWMutex
+	limits APICacheLimits
 }
 
 // NewAPICacheRepository creates a new API cache repository
@@ -16,8 +28,51 @@ func NewAPICacheRepository(db *sql.DB) *APICacheRepository {
 	return &APICacheRepository{db: db}
 }
 
+// SetLimits replaces the cache limits and trims the cache to them
+func (r *APICacheRepository) SetLimits(limits APICacheLimits) error {
+	r.mu.Lock()
+	r.limits = limits
+	r.mu.Unlock()
+	if limits.Disabled {
+		_, err := r.db.Exec("DELETE FROM api_cache")
+		return err
+	}
+	if limits.MaxTTL > 0 {
+		maxExpiry := time.Now().Add(limits.MaxTTL).Unix()
+		if _, err := r.db.Exec("UPDATE api_cache SET expires_at = ? WHERE expires_at > ?", maxExpiry, maxExpiry); err != nil {
+			return err
+		}
+	}
+	return r.trim(limits.MaxBytes)
+}
+
+func (r *APICacheRepository) currentLimits() APICacheLimits {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.limits
+}
+
+// trim drops the oldest entries until the cached responses fit in maxBytes
+func (r *APICacheRepository) trim(maxBytes int64) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+	_, err := r.db.Exec(`
+		DELETE FROM api_cache WHERE cache_key IN (
+			SELECT cache_key FROM (
+				SELECT cache_key, SUM(LENGTH(response_data)) OVER (ORDER BY cached_at DESC, cache_key) AS total
+				FROM api_cache
+			) WHERE total > ?
+		)
+	`, maxBytes)
+	return err
+}
+
 // Get retrieves cached API response
 func (r *APICacheRepository) Get(cacheKey string) ([]byte, bool, error) {
+	if r.currentLimits().Disabled {
+		return nil, false, nil
+	}
 	var responseData string
 	var expiresAt int64
 
@@ -46,6 +101,14 @@ func (r *APICacheRepository) Get(cacheKey string) ([]byte, bool, error) {
 
 // Set stores API response in cache
 func (r *APICacheRepository) Set(cacheKey, cacheType string, data interface{}, ttl time.Duration) error {
+	limits := r.currentLimits()
+	if limits.Disabled {
+		return nil
+	}
+	if limits.MaxTTL > 0 && ttl > limits.MaxTTL {
+		ttl = limits.MaxTTL
+	}
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return err
@@ -58,8 +121,14 @@ func (r *APICacheRepository) Set(cacheKey, cacheType string, data interface{}, t
 		INSERT OR REPLACE INTO api_cache (cache_key, cache_type, response_data, cached_at, expires_at)
 		VALUES (?, ?, ?, ?, ?)
 	`, cacheKey, cacheType, string(jsonData), now, expiresAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := r.trim(limits.MaxBytes); err != nil {
+		log.Printf("[Cache] Failed to trim API cache: %v", err)
+	}
+	return nil
 }
 
 // Delete removes a cached entry
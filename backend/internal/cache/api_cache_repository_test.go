@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hyenimc/backend/internal/db"
+)
+
+func TestAPICacheLimits(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "hyenimc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := NewAPICacheRepository(conn)
+
+	cached := func(key string) bool {
+		_, ok, err := r.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	expiry := func(key string) time.Duration {
+		var expiresAt int64
+		conn.QueryRow(`SELECT expires_at FROM api_cache WHERE cache_key = ?`, key).Scan(&expiresAt)
+		return time.Until(time.Unix(expiresAt, 0))
+	}
+
+	// The TTL cap applies to stored and new entries
+	r.Set("old", "test", "x", 30*24*time.Hour)
+	if err := r.SetLimits(APICacheLimits{MaxTTL: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	r.Set("new", "test", "x", 30*24*time.Hour)
+	for _, key := range []string{"old", "new"} {
+		if d := expiry(key); d > time.Hour {
+			t.Errorf("%s expires in %v", key, d)
+		}
+	}
+
+	// Over the size limit the oldest entries go first
+	big := strings.Repeat("a", 100)
+	r.SetLimits(APICacheLimits{MaxBytes: 250})
+	conn.Exec(`DELETE FROM api_cache`)
+	for i, key := range []string{"first", "second", "third"} {
+		r.Set(key, "test", big, time.Hour)
+		conn.Exec(`UPDATE api_cache SET cached_at = ? WHERE cache_key = ?`, i, key)
+	}
+	if cached("first") || !cached("second") || !cached("third") {
+		t.Errorf("size limit: first %v, second %v, third %v", cached("first"), cached("second"), cached("third"))
+	}
+
+	// A disabled cache is emptied and stores nothing
+	r.SetLimits(APICacheLimits{Disabled: true})
+	r.Set("fourth", "test", "x", time.Hour)
+	var rows int
+	conn.QueryRow(`SELECT COUNT(*) FROM api_cache`).Scan(&rows)
+	if rows != 0 || cached("third") {
+		t.Errorf("%d rows in a disabled cache", rows)
+	}
+}
@@ -31,32 +31,37 @@ func Initialize(dataDir string) error {
 		dbPath = filepath.Join(dataDir, "hyenimc.db")
 		log.Printf("[DB] Database path: %s", dbPath)
 
-		// Open database with busy_timeout and WAL mode
-		instance, err = sql.Open("sqlite", dbPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(10000)")
-		if err != nil {
-			err = fmt.Errorf("failed to open database: %w", err)
-			return
-		}
+		instance, err = Open(dbPath)
+	})
+	return err
+}
 
-		// Test connection
-		if err = instance.Ping(); err != nil {
-			err = fmt.Errorf("failed to ping database: %w", err)
-			return
-		}
+// Open opens the SQLite database at path and migrates it to the latest schema
+func Open(path string) (*sql.DB, error) {
+	// Open database with busy_timeout and WAL mode
+	conn, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(10000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
 
-		// Set connection pool settings for SQLite (reduce concurrency)
-		instance.SetMaxOpenConns(1)  // SQLite works best with single writer
-		instance.SetMaxIdleConns(1)
+	// Test connection
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
 
-		log.Println("[DB] Database connection established")
+	// Set connection pool settings for SQLite (reduce concurrency)
+	conn.SetMaxOpenConns(1)  // SQLite works best with single writer
+	conn.SetMaxIdleConns(1)
 
-		// Run migrations
-		if err = runMigrations(instance); err != nil {
-			err = fmt.Errorf("failed to run migrations: %w", err)
-			return
-		}
-	})
-	return err
+	log.Println("[DB] Database connection established")
+
+	// Run migrations
+	if err := runMigrations(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return conn, nil
 }
 
 // Get returns the database instance
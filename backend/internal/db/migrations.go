@@ -371,6 +371,195 @@ var migrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_profiles_installation_status ON profiles(installation_status);
 		`,
 	},
+	{
+		Version: 19,
+		Name:    "add_encryption_key_versions",
+		SQL: `
+			-- Key version used to encrypt each account ('' = original key)
+			ALTER TABLE accounts ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+			
+			-- Active key version (key material itself lives in the key store)
+			CREATE TABLE IF NOT EXISTS encryption_keys (
+				id TEXT PRIMARY KEY,
+				active INTEGER NOT NULL DEFAULT 0,
+				created_at INTEGER NOT NULL
+			);
+			
+			INSERT OR IGNORE INTO encryption_keys (id, active, created_at) VALUES ('', 1, strftime('%s', 'now'));
+		`,
+	},
+	{
+		Version: 20,
+		Name:    "add_yggdrasil_accounts",
+		SQL: `
+			-- SQLite cannot alter a CHECK constraint, so rebuild the accounts table
+			CREATE TABLE accounts_new (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				uuid TEXT NOT NULL,
+				type TEXT NOT NULL CHECK(type IN ('microsoft', 'offline', 'yggdrasil')),
+				encrypted_data TEXT,
+				iv TEXT,
+				auth_tag TEXT,
+				skin_url TEXT,
+				last_used INTEGER NOT NULL,
+				device_id TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				updated_at INTEGER NOT NULL,
+				key_id TEXT NOT NULL DEFAULT '',
+				-- Yggdrasil API root (authlib-injector) for third-party auth accounts
+				auth_server TEXT NOT NULL DEFAULT '',
+				CHECK(type != 'yggdrasil' OR auth_server != '')
+			);
+			
+			INSERT INTO accounts_new (
+				id, name, uuid, type, encrypted_data, iv, auth_tag, skin_url,
+				last_used, device_id, created_at, updated_at, key_id
+			)
+			SELECT
+				id, name, uuid, type, encrypted_data, iv, auth_tag, skin_url,
+				last_used, device_id, created_at, updated_at, key_id
+			FROM accounts;
+			
+			DROP TABLE accounts;
+			ALTER TABLE accounts_new RENAME TO accounts;
+			
+			CREATE INDEX IF NOT EXISTS idx_accounts_last_used ON accounts(last_used DESC);
+			CREATE INDEX IF NOT EXISTS idx_accounts_type ON accounts(type);
+			CREATE INDEX IF NOT EXISTS idx_accounts_device_id ON accounts(device_id);
+		`,
+	},
+	{
+		Version: 21,
+		Name:    "create_profile_trash",
+		SQL: `
+			-- Deleted profiles kept for restore until the retention period ends
+			CREATE TABLE IF NOT EXISTS profile_trash (
+				id TEXT PRIMARY KEY,
+				profile_id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				profile_json TEXT NOT NULL,
+				original_path TEXT NOT NULL,
+				trash_path TEXT NOT NULL DEFAULT '',
+				size_bytes INTEGER NOT NULL DEFAULT 0,
+				deleted_at INTEGER NOT NULL
+			);
+			
+			CREATE INDEX IF NOT EXISTS idx_profile_trash_deleted_at ON profile_trash(deleted_at);
+		`,
+	},
+	{
+		Version: 22,
+		Name:    "create_profile_snapshots",
+		SQL: `
+			-- Point-in-time copies of instance content; file data lives in the
+			-- content-addressed blob store under {dataDir}/snapshots
+			CREATE TABLE IF NOT EXISTS profile_snapshots (
+				id TEXT PRIMARY KEY,
+				profile_id TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				label TEXT NOT NULL DEFAULT '',
+				include_saves INTEGER NOT NULL DEFAULT 0,
+				file_count INTEGER NOT NULL DEFAULT 0,
+				total_bytes INTEGER NOT NULL DEFAULT 0,
+				stored_bytes INTEGER NOT NULL DEFAULT 0,
+				created_at INTEGER NOT NULL,
+				FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE CASCADE
+			);
+			
+			CREATE TABLE IF NOT EXISTS profile_snapshot_files (
+				snapshot_id TEXT NOT NULL,
+				path TEXT NOT NULL,
+				hash TEXT NOT NULL,
+				size INTEGER NOT NULL,
+				mod_time INTEGER NOT NULL,
+				PRIMARY KEY (snapshot_id, path),
+				FOREIGN KEY (snapshot_id) REFERENCES profile_snapshots(id) ON DELETE CASCADE
+			);
+			
+			CREATE INDEX IF NOT EXISTS idx_profile_snapshots_profile ON profile_snapshots(profile_id, created_at);
+			CREATE INDEX IF NOT EXISTS idx_profile_snapshot_files_hash ON profile_snapshot_files(hash);
+		`,
+	},
+	{
+		Version: 23,
+		Name:    "create_profile_tags_and_groups",
+		SQL: `
+			-- Free-form tags; matched case-insensitively
+			CREATE TABLE IF NOT EXISTS profile_tags (
+				profile_id TEXT NOT NULL,
+				tag TEXT NOT NULL COLLATE NOCASE,
+				PRIMARY KEY (profile_id, tag),
+				FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE CASCADE
+			);
+			
+			CREATE INDEX IF NOT EXISTS idx_profile_tags_tag ON profile_tags(tag);
+			
+			-- Ordered groups; a profile belongs to at most one
+			CREATE TABLE IF NOT EXISTS profile_groups (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				sort_order INTEGER NOT NULL DEFAULT 0,
+				created_at INTEGER NOT NULL
+			);
+			
+			CREATE TABLE IF NOT EXISTS profile_group_members (
+				profile_id TEXT PRIMARY KEY,
+				group_id TEXT NOT NULL,
+				sort_order INTEGER NOT NULL DEFAULT 0,
+				FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE CASCADE,
+				FOREIGN KEY (group_id) REFERENCES profile_groups(id) ON DELETE CASCADE
+			);
+			
+			CREATE INDEX IF NOT EXISTS idx_profile_group_members_group ON profile_group_members(group_id, sort_order);
+		`,
+	},
+	{
+		Version: 24,
+		Name:    "keep_profile_snapshots_in_trash",
+		SQL: `
+			-- Snapshots must survive a profile moving to the trash, so they no longer
+			-- cascade with the profile row; purging the trash entry deletes them.
+			-- Both tables are rebuilt under temporary names so dropping the old
+			-- parent cannot cascade into the copied file rows.
+			CREATE TABLE profile_snapshots_new (
+				id TEXT PRIMARY KEY,
+				profile_id TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				label TEXT NOT NULL DEFAULT '',
+				include_saves INTEGER NOT NULL DEFAULT 0,
+				file_count INTEGER NOT NULL DEFAULT 0,
+				total_bytes INTEGER NOT NULL DEFAULT 0,
+				stored_bytes INTEGER NOT NULL DEFAULT 0,
+				created_at INTEGER NOT NULL
+			);
+			
+			CREATE TABLE profile_snapshot_files_new (
+				snapshot_id TEXT NOT NULL,
+				path TEXT NOT NULL,
+				hash TEXT NOT NULL,
+				size INTEGER NOT NULL,
+				mod_time INTEGER NOT NULL,
+				PRIMARY KEY (snapshot_id, path),
+				FOREIGN KEY (snapshot_id) REFERENCES profile_snapshots_new(id) ON DELETE CASCADE
+			);
+			
+			INSERT INTO profile_snapshots_new SELECT
+				id, profile_id, reason, label, include_saves, file_count, total_bytes, stored_bytes, created_at
+			FROM profile_snapshots;
+			INSERT INTO profile_snapshot_files_new SELECT
+				snapshot_id, path, hash, size, mod_time
+			FROM profile_snapshot_files;
+			
+			DROP TABLE profile_snapshot_files;
+			DROP TABLE profile_snapshots;
+			ALTER TABLE profile_snapshots_new RENAME TO profile_snapshots;
+			ALTER TABLE profile_snapshot_files_new RENAME TO profile_snapshot_files;
+			
+			CREATE INDEX IF NOT EXISTS idx_profile_snapshots_profile ON profile_snapshots(profile_id, created_at);
+			CREATE INDEX IF NOT EXISTS idx_profile_snapshot_files_hash ON profile_snapshot_files(hash);
+		`,
+	},
 }
 
 func runMigrations(db *sql.DB) error {
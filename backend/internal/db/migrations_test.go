@@ -0,0 +1,116 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrateTo applies the migrations up to and including version
+func migrateTo(t *testing.T, conn *sql.DB, version int) {
+	t.Helper()
+	all := migrations
+	defer func() { migrations = all }()
+	for i, m := range all {
+		if m.Version == version {
+			migrations = all[:i+1]
+			break
+		}
+	}
+	if err := runMigrations(conn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotsSurviveProfileDeletion(t *testing.T) {
+	conn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	// Data written before the cascade was dropped must come through the rebuild
+	migrateTo(t, conn, 23)
+	mustExec(t, conn, `INSERT INTO profiles (id, name, game_version, loader_type, game_directory, created_at, updated_at)
+		VALUES ('p1', 'Test', '1.21.1', 'vanilla', '/tmp/p1', 0, 0)`)
+	mustExec(t, conn, `INSERT INTO profile_snapshots (id, profile_id, reason, created_at) VALUES ('s1', 'p1', 'manual', 0)`)
+	mustExec(t, conn, `INSERT INTO profile_snapshot_files (snapshot_id, path, hash, size, mod_time) VALUES ('s1', 'mods/a.jar', 'abc', 1, 0)`)
+
+	migrateTo(t, conn, 24)
+	if n := count(t, conn, "profile_snapshot_files"); n != 1 {
+		t.Fatalf("%d snapshot files after migration, want 1", n)
+	}
+
+	// Moving a profile to the trash deletes its row; the snapshots stay
+	mustExec(t, conn, `DELETE FROM profiles WHERE id = 'p1'`)
+	if n := count(t, conn, "profile_snapshots"); n != 1 {
+		t.Errorf("%d snapshots after profile deletion, want 1", n)
+	}
+
+	// Snapshot files still cascade with their snapshot
+	mustExec(t, conn, `DELETE FROM profile_snapshots WHERE id = 's1'`)
+	if n := count(t, conn, "profile_snapshot_files"); n != 0 {
+		t.Errorf("%d snapshot files after snapshot deletion, want 0", n)
+	}
+}
+
+func mustExec(t *testing.T, conn *sql.DB, query string) {
+	t.Helper()
+	if _, err := conn.Exec(query); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func count(t *testing.T, conn *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestYggdrasilMigrationKeepsAccounts(t *testing.T) {
+	conn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	migrateTo(t, conn, 19)
+	mustExec(t, conn, `INSERT INTO accounts (id, name, uuid, type, encrypted_data, iv, auth_tag, skin_url, last_used, device_id, created_at, updated_at, key_id)
+		VALUES ('ms', 'Steve', 'u1', 'microsoft', 'data', 'iv', 'tag', 'https://skin', 5, 'device', 1, 2, 'k1')`)
+	mustExec(t, conn, `INSERT INTO accounts (id, name, uuid, type, last_used, device_id, created_at, updated_at)
+		VALUES ('off', 'Alex', 'u2', 'offline', 3, 'device', 1, 2)`)
+
+	migrateTo(t, conn, 20)
+	if n := count(t, conn, "accounts"); n != 2 {
+		t.Fatalf("%d accounts after migration, want 2", n)
+	}
+	var name, data, iv, tag, skin, keyID, server string
+	var lastUsed int64
+	err = conn.QueryRow(`SELECT name, encrypted_data, iv, auth_tag, skin_url, key_id, last_used, auth_server FROM accounts WHERE id = 'ms'`).
+		Scan(&name, &data, &iv, &tag, &skin, &keyID, &lastUsed, &server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Steve" || data != "data" || iv != "iv" || tag != "tag" || skin != "https://skin" || keyID != "k1" || lastUsed != 5 || server != "" {
+		t.Errorf("copied row: %s %s %s %s %s %s %d %q", name, data, iv, tag, skin, keyID, lastUsed, server)
+	}
+
+	// The rebuilt table admits yggdrasil accounts, but only with an auth server
+	insert := `INSERT INTO accounts (id, name, uuid, type, last_used, device_id, created_at, updated_at, auth_server) VALUES (?, 'n', 'u', ?, 0, 'device', 0, 0, ?)`
+	if _, err := conn.Exec(insert, "ygg", "yggdrasil", "https://auth.example/api/yggdrasil"); err != nil {
+		t.Errorf("yggdrasil account: %v", err)
+	}
+	if _, err := conn.Exec(insert, "ygg-no-server", "yggdrasil", ""); err == nil {
+		t.Error("yggdrasil account without an auth server was accepted")
+	}
+	if _, err := conn.Exec(insert, "mojang", "mojang", ""); err == nil {
+		t.Error("unknown account type was accepted")
+	}
+}
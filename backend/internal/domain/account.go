@@ -7,11 +7,13 @@ type Account struct {
 	ID            string    `json:"id"`
 	Name          string    `json:"name"`
 	UUID          string    `json:"uuid"`
-	Type          string    `json:"type"` // "microsoft" or "offline"
+	Type          string    `json:"type"` // "microsoft", "offline" or "yggdrasil"
 	EncryptedData string    `json:"encrypted_data,omitempty"`
 	IV            string    `json:"iv,omitempty"`
 	AuthTag       string    `json:"auth_tag,omitempty"`
+	KeyID         string    `json:"key_id,omitempty"` // encryption key version ("" = original key)
 	SkinURL       string    `json:"skin_url,omitempty"`
+	AuthServer    string    `json:"auth_server,omitempty"` // Yggdrasil API root (yggdrasil accounts only)
 	LastUsed      int64     `json:"last_used"`
 	DeviceID      string    `json:"device_id"`
 	CreatedAt     time.Time `json:"created_at"`
@@ -23,4 +25,5 @@ type DecryptedTokens struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresAt    int64  `json:"expires_at"`
+	ClientToken  string `json:"client_token,omitempty"` // Yggdrasil client token
 }
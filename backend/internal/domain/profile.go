@@ -29,6 +29,9 @@ type Profile struct {
 	SPAEnabled          bool       `json:"spaEnabled,omitempty"`
 	ServerAddress       string     `json:"serverAddress,omitempty"`
 	Favorite            bool       `json:"favorite"`
+	Tags                []string   `json:"tags,omitempty"`
+	GroupID             string     `json:"groupId,omitempty"`
+	GroupOrder          int        `json:"groupOrder,omitempty"`
 }
 
 // Memory represents JVM memory settings
@@ -0,0 +1,288 @@
+package download
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Kind classifies a failed download or fetch
+type Kind string
+
+const (
+	KindUnknown     Kind = "unknown"
+	KindHTTPStatus  Kind = "http_status"
+	KindDNS         Kind = "dns"
+	KindTLS         Kind = "tls"
+	KindConnReset   Kind = "connection_reset"
+	KindConnRefused Kind = "connection_refused"
+	KindTimeout     Kind = "timeout"
+	KindChecksum    Kind = "checksum_mismatch"
+	KindCanceled    Kind = "canceled"
+	KindUnsupported Kind = "unsupported"
+)
+
+// ErrUnsupportedChecksum is returned for a checksum algorithm the downloader cannot
+// verify; no attempt can fix the request, so it is never retried
+var ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+
+const (
+	baseBackoff = 1 * time.Second
+	maxBackoff  = 30 * time.Second
+	// Servers may ask for long Retry-After delays; never wait longer than this
+	maxRetryAfter = 2 * time.Minute
+)
+
+// HTTPStatusError is returned when a server answers with an unexpected status code
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+}
+
+// NewHTTPStatusError builds an HTTPStatusError from a response
+func NewHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	e := &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header.Clone(),
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.URL = resp.Request.URL.String()
+	}
+	if e.Status == "" {
+		e.Status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return e
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("bad status: %s", e.Status)
+}
+
+// RetryAfter returns the delay requested by the Retry-After header, or 0 if absent
+func (e *HTTPStatusError) RetryAfter() time.Duration {
+	v := e.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// NetworkError wraps a transport-level failure with its classification
+type NetworkError struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// ChecksumError is returned when downloaded content does not match the expected hash
+type ChecksumError struct {
+	Algo string
+	Want string
+	Got  string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s mismatch: got %s, want %s", e.Algo, e.Got, e.Want)
+}
+
+// Classify wraps a raw transport error into a NetworkError.
+// Errors that are already typed (or nil) are returned unchanged.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var statusErr *HTTPStatusError
+	var netErr *NetworkError
+	var sumErr *ChecksumError
+	if errors.As(err, &statusErr) || errors.As(err, &netErr) || errors.As(err, &sumErr) {
+		return err
+	}
+	return &NetworkError{Kind: classifyTransport(err), Err: err}
+}
+
+func classifyTransport(err error) Kind {
+	if errors.Is(err, context.Canceled) {
+		return KindCanceled
+	}
+	if errors.Is(err, ErrUnsupportedChecksum) {
+		return KindUnsupported
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return KindTimeout
+		}
+		return KindDNS
+	}
+
+	var recordErr tls.RecordHeaderError
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &recordErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return KindTLS
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return KindConnRefused
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return KindConnReset
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return KindTimeout
+	}
+	return KindUnknown
+}
+
+// KindOf returns the classification of err
+func KindOf(err error) Kind {
+	if err == nil {
+		return ""
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return KindHTTPStatus
+	}
+	var sumErr *ChecksumError
+	if errors.As(err, &sumErr) {
+		return KindChecksum
+	}
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return netErr.Kind
+	}
+	return classifyTransport(err)
+}
+
+// IsRetryable reports whether another attempt may succeed
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch KindOf(err) {
+	case KindHTTPStatus:
+		var statusErr *HTTPStatusError
+		errors.As(err, &statusErr)
+		return isRetryableStatus(statusErr.StatusCode)
+	case KindCanceled, KindTLS, KindUnsupported:
+		return false
+	default:
+		// DNS, connection, timeout, checksum and unknown errors are worth another try
+		return true
+	}
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout,
+		http.StatusTooEarly,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Backoff returns how long to wait before the next attempt (attempt is zero-based).
+// A Retry-After header on the failed response takes precedence over exponential backoff.
+func Backoff(err error, attempt int) time.Duration {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		if d := statusErr.RetryAfter(); d > 0 {
+			if d > maxRetryAfter {
+				d = maxRetryAfter
+			}
+			return d
+		}
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+	if attempt > 5 {
+		return maxBackoff
+	}
+	d := baseBackoff << uint(attempt)
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// Message returns a short user-facing description of err
+func Message(err error) string {
+	if err == nil {
+		return ""
+	}
+	switch KindOf(err) {
+	case KindHTTPStatus:
+		var statusErr *HTTPStatusError
+		errors.As(err, &statusErr)
+		switch {
+		case statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone:
+			return fmt.Sprintf("File not found on server (%s)", statusErr.Status)
+		case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+			return fmt.Sprintf("Access denied by server (%s)", statusErr.Status)
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return "Server is rate limiting requests"
+		case statusErr.StatusCode >= 500:
+			return fmt.Sprintf("Server error (%s)", statusErr.Status)
+		default:
+			return fmt.Sprintf("Unexpected server response (%s)", statusErr.Status)
+		}
+	case KindDNS:
+		return "Could not resolve server address. Check your internet connection"
+	case KindTLS:
+		return "Secure connection failed (TLS certificate error)"
+	case KindConnReset:
+		return "Connection was interrupted"
+	case KindConnRefused:
+		return "Connection refused by server"
+	case KindTimeout:
+		return "Connection timed out"
+	case KindChecksum:
+		return "Downloaded file is corrupted (checksum mismatch)"
+	case KindCanceled:
+		return "Download cancelled"
+	case KindUnsupported:
+		return err.Error()
+	default:
+		return err.Error()
+	}
+}
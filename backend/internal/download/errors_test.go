@@ -0,0 +1,190 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// get fetches url and reads the whole body, returning the classified error
+func get(t *testing.T, ctx context.Context, url string) error {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Classify(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return NewHTTPStatusError(resp)
+	}
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return Classify(err)
+	}
+	return nil
+}
+
+func TestHTTPStatusClassification(t *testing.T) {
+	cases := []struct {
+		code      int
+		retryable bool
+	}{
+		{http.StatusNotFound, false},
+		{http.StatusForbidden, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusGone, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, true},
+		{http.StatusNotImplemented, false},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.code)
+		}))
+		err := get(t, context.Background(), srv.URL)
+		srv.Close()
+
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != c.code || KindOf(err) != KindHTTPStatus {
+			t.Errorf("%d: got %v (%s)", c.code, err, KindOf(err))
+			continue
+		}
+		if IsRetryable(err) != c.retryable {
+			t.Errorf("%d: retryable = %v, want %v", c.code, !c.retryable, c.retryable)
+		}
+		if Message(err) == "" {
+			t.Errorf("%d: empty message", c.code)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", header)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		header string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"7", 7 * time.Second, 7 * time.Second},
+		{time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat), 15 * time.Second, 20 * time.Second},
+		{"3600", maxRetryAfter, maxRetryAfter},       // capped
+		{"soon", baseBackoff << 2, baseBackoff << 2}, // unparsable: exponential backoff
+		{"0", baseBackoff << 2, baseBackoff << 2},
+	}
+	for _, c := range cases {
+		header = c.header
+		err := get(t, context.Background(), srv.URL)
+		if !IsRetryable(err) {
+			t.Fatalf("429 should be retryable: %v", err)
+		}
+		if d := Backoff(err, 2); d < c.min || d > c.max {
+			t.Errorf("Retry-After %q: backoff %v, want %v..%v", c.header, d, c.min, c.max)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	err := &NetworkError{Kind: KindTimeout, Err: errors.New("timeout")}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, maxBackoff, maxBackoff}
+	for attempt, w := range want {
+		if d := Backoff(err, attempt); d != w {
+			t.Errorf("attempt %d: %v, want %v", attempt, d, w)
+		}
+	}
+}
+
+func TestConnectionReset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		// Promise more body than is sent, then abort the connection
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: 1000\r\n\r\npartial")
+		buf.Flush()
+		conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	err := get(t, context.Background(), srv.URL)
+	if KindOf(err) != KindConnReset || !IsRetryable(err) {
+		t.Errorf("got %v (%s)", err, KindOf(err))
+	}
+}
+
+func TestConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	err = get(t, context.Background(), "http://"+addr)
+	if KindOf(err) != KindConnRefused || !IsRetryable(err) {
+		t.Errorf("got %v (%s)", err, KindOf(err))
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := get(t, ctx, srv.URL)
+	if KindOf(err) != KindTimeout || !IsRetryable(err) {
+		t.Errorf("got %v (%s)", err, KindOf(err))
+	}
+}
+
+func TestCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := get(t, ctx, "http://127.0.0.1:1")
+	if KindOf(err) != KindCanceled || IsRetryable(err) {
+		t.Errorf("got %v (%s)", err, KindOf(err))
+	}
+}
+
+func TestChecksumAndUnsupported(t *testing.T) {
+	sumErr := fmt.Errorf("verify: %w", &ChecksumError{Algo: "sha1", Want: "aa", Got: "bb"})
+	if KindOf(sumErr) != KindChecksum || !IsRetryable(sumErr) {
+		t.Errorf("checksum mismatch: %s, retryable %v", KindOf(sumErr), IsRetryable(sumErr))
+	}
+
+	unsupported := fmt.Errorf("%w: %q", ErrUnsupportedChecksum, "md5")
+	if KindOf(unsupported) != KindUnsupported || IsRetryable(unsupported) {
+		t.Errorf("unsupported algorithm: %s, retryable %v", KindOf(unsupported), IsRetryable(unsupported))
+	}
+	if got := Classify(unsupported); KindOf(got) != KindUnsupported {
+		t.Errorf("classified as %s", KindOf(got))
+	}
+}
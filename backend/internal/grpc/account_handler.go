@@ -2,9 +2,17 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/download"
+	"hyenimc/backend/internal/msauth"
 	"hyenimc/backend/internal/services"
+	"hyenimc/backend/internal/yggdrasil"
 )
 
 // AccountHandler implements the AccountService gRPC service
@@ -33,7 +41,7 @@ func (h *AccountHandler) SaveMicrosoftAccount(ctx context.Context, req *pb.SaveM
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, tokenStatusError(err)
 	}
 
 	return &pb.SaveAccountResponse{
@@ -61,14 +69,15 @@ func (h *AccountHandler) GetAccount(ctx context.Context, req *pb.GetAccountReque
 	}
 
 	return &pb.AccountResponse{
-		Id:        account.ID,
-		Name:      account.Name,
-		Uuid:      account.UUID,
-		Type:      account.Type,
-		SkinUrl:   account.SkinURL,
-		LastUsed:  account.LastUsed,
-		CreatedAt: account.CreatedAt.Unix(),
-		UpdatedAt: account.UpdatedAt.Unix(),
+		Id:         account.ID,
+		Name:       account.Name,
+		Uuid:       account.UUID,
+		Type:       account.Type,
+		SkinUrl:    account.SkinURL,
+		LastUsed:   account.LastUsed,
+		CreatedAt:  account.CreatedAt.Unix(),
+		UpdatedAt:  account.UpdatedAt.Unix(),
+		AuthServer: account.AuthServer,
 	}, nil
 }
 
@@ -82,14 +91,15 @@ func (h *AccountHandler) GetAllAccounts(ctx context.Context, req *pb.GetAllAccou
 	var accountResponses []*pb.AccountResponse
 	for _, account := range accounts {
 		accountResponses = append(accountResponses, &pb.AccountResponse{
-			Id:        account.ID,
-			Name:      account.Name,
-			Uuid:      account.UUID,
-			Type:      account.Type,
-			SkinUrl:   account.SkinURL,
-			LastUsed:  account.LastUsed,
-			CreatedAt: account.CreatedAt.Unix(),
-			UpdatedAt: account.UpdatedAt.Unix(),
+			Id:         account.ID,
+			Name:       account.Name,
+			Uuid:       account.UUID,
+			Type:       account.Type,
+			SkinUrl:    account.SkinURL,
+			LastUsed:   account.LastUsed,
+			CreatedAt:  account.CreatedAt.Unix(),
+			UpdatedAt:  account.UpdatedAt.Unix(),
+			AuthServer: account.AuthServer,
 		})
 	}
 
@@ -102,7 +112,7 @@ func (h *AccountHandler) GetAllAccounts(ctx context.Context, req *pb.GetAllAccou
 func (h *AccountHandler) GetAccountTokens(ctx context.Context, req *pb.GetAccountTokensRequest) (*pb.AccountTokensResponse, error) {
 	tokens, err := h.accountService.GetAccountTokens(ctx, req.AccountId)
 	if err != nil {
-		return nil, err
+		return nil, tokenStatusError(err)
 	}
 
 	return &pb.AccountTokensResponse{
@@ -123,7 +133,7 @@ func (h *AccountHandler) UpdateAccountTokens(ctx context.Context, req *pb.Update
 	)
 
 	if err != nil {
-		return &pb.UpdateAccountTokensResponse{Success: false}, err
+		return &pb.UpdateAccountTokensResponse{Success: false}, tokenStatusError(err)
 	}
 
 	return &pb.UpdateAccountTokensResponse{Success: true}, nil
@@ -148,3 +158,284 @@ func (h *AccountHandler) RemoveAccount(ctx context.Context, req *pb.RemoveAccoun
 
 	return &pb.RemoveAccountResponse{Success: true}, nil
 }
+
+// LoginWithDeviceCode runs the Microsoft device code login and streams its progress
+func (h *AccountHandler) LoginWithDeviceCode(req *pb.DeviceCodeLoginRequest, stream pb.AccountService_LoginWithDeviceCodeServer) error {
+	ctx := stream.Context()
+	var sendErr error
+	send := func(ev *pb.DeviceCodeLoginEvent) {
+		if sendErr == nil {
+			sendErr = stream.Send(ev)
+		}
+	}
+
+	accountID, session, err := h.accountService.LoginWithDeviceCode(ctx, req.ClientId,
+		func(dc *msauth.DeviceCode) {
+			send(&pb.DeviceCodeLoginEvent{
+				Stage:           "device_code",
+				UserCode:        dc.UserCode,
+				VerificationUri: dc.VerificationURI,
+				Message:         dc.Message,
+				ExpiresAt:       time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second).UnixMilli(),
+				Interval:        int32(dc.Interval),
+			})
+		},
+		func(stage string) {
+			send(&pb.DeviceCodeLoginEvent{Stage: stage})
+		},
+	)
+	if err != nil {
+		return authStatusError(err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	return stream.Send(&pb.DeviceCodeLoginEvent{
+		Stage:     "completed",
+		AccountId: accountID,
+		Name:      session.Profile.Name,
+		Uuid:      session.Profile.ID,
+		SkinUrl:   session.Profile.ActiveSkinURL(),
+	})
+}
+
+// authStatusError maps Microsoft/Minecraft service failures to gRPC status codes
+func authStatusError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "login cancelled")
+	case errors.Is(err, msauth.ErrMissingClientID):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, msauth.ErrDeviceCodeExpired):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, msauth.ErrAuthorizationDeclined):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, msauth.ErrNoXboxAccount), errors.Is(err, msauth.ErrXboxUnavailable),
+		errors.Is(err, msauth.ErrChildAccount), errors.Is(err, msauth.ErrNoEntitlement),
+		errors.Is(err, msauth.ErrNoProfile), errors.Is(err, yggdrasil.ErrNoProfile):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, yggdrasil.ErrInvalidCredentials), errors.Is(err, yggdrasil.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, services.ErrKeyringUnavailable):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	var netErr *download.NetworkError
+	if errors.As(err, &netErr) {
+		return status.Errorf(codes.Unavailable, "%s: %v", download.Message(err), err)
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// tokenStatusError reports a locked keyring as FailedPrecondition so callers can tell
+// it from missing or invalid tokens
+func tokenStatusError(err error) error {
+	if errors.Is(err, services.ErrKeyringUnavailable) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
+
+// skinStatusError maps failures of skin and cape requests, which are not logins
+func skinStatusError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, "skin request cancelled")
+	}
+	return authStatusError(err)
+}
+
+// GetAuthHealth returns the auth health of Microsoft accounts
+func (h *AccountHandler) GetAuthHealth(ctx context.Context, req *pb.GetAuthHealthRequest) (*pb.GetAuthHealthResponse, error) {
+	if req.AccountId != "" {
+		health, err := h.accountService.GetAccountAuthHealth(ctx, req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.GetAuthHealthResponse{Accounts: []*pb.AccountAuthHealth{toPbAuthHealth(health)}}, nil
+	}
+
+	all, err := h.accountService.GetAuthHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.GetAuthHealthResponse{}
+	for _, health := range all {
+		resp.Accounts = append(resp.Accounts, toPbAuthHealth(health))
+	}
+	return resp, nil
+}
+
+// RefreshAccount refreshes the tokens of a Microsoft account
+func (h *AccountHandler) RefreshAccount(ctx context.Context, req *pb.RefreshAccountRequest) (*pb.AccountAuthHealth, error) {
+	health, err := h.accountService.RefreshAccount(ctx, req.AccountId, req.Force, services.TokenRefreshWindow)
+	if err != nil {
+		return nil, err
+	}
+	return toPbAuthHealth(health), nil
+}
+
+func toPbAuthHealth(health *services.AuthHealth) *pb.AccountAuthHealth {
+	out := &pb.AccountAuthHealth{
+		AccountId: health.AccountID,
+		Status:    health.Status,
+		ExpiresAt: health.ExpiresAt,
+		CheckedAt: health.CheckedAt.Unix(),
+		Error:     health.Error,
+	}
+	if !health.RefreshedAt.IsZero() {
+		out.RefreshedAt = health.RefreshedAt.Unix()
+	}
+	return out
+}
+
+// RotateEncryptionKey rotates the account encryption key
+func (h *AccountHandler) RotateEncryptionKey(ctx context.Context, req *pb.RotateEncryptionKeyRequest) (*pb.RotateEncryptionKeyResponse, error) {
+	result, err := h.accountService.RotateEncryptionKey(ctx)
+	if err != nil {
+		return nil, tokenStatusError(err)
+	}
+
+	return &pb.RotateEncryptionKeyResponse{
+		KeyId:       result.KeyID,
+		Reencrypted: int32(result.Reencrypted),
+		Skipped:     int32(result.Skipped),
+	}, nil
+}
+
+// GetMinecraftProfile returns the skins and capes of an account
+func (h *AccountHandler) GetMinecraftProfile(ctx context.Context, req *pb.GetMinecraftProfileRequest) (*pb.MinecraftProfile, error) {
+	profile, err := h.accountService.GetMinecraftProfile(ctx, req.AccountId)
+	if err != nil {
+		return nil, skinStatusError(err)
+	}
+	return h.toPbProfile(profile), nil
+}
+
+// UploadSkin uploads a new skin
+func (h *AccountHandler) UploadSkin(ctx context.Context, req *pb.UploadSkinRequest) (*pb.MinecraftProfile, error) {
+	profile, err := h.accountService.UploadSkin(ctx, req.AccountId, req.FilePath, req.Variant)
+	if err != nil {
+		return nil, skinStatusError(err)
+	}
+	return h.toPbProfile(profile), nil
+}
+
+// ResetSkin resets the skin to the default
+func (h *AccountHandler) ResetSkin(ctx context.Context, req *pb.ResetSkinRequest) (*pb.MinecraftProfile, error) {
+	profile, err := h.accountService.ResetSkin(ctx, req.AccountId)
+	if err != nil {
+		return nil, skinStatusError(err)
+	}
+	return h.toPbProfile(profile), nil
+}
+
+// SetActiveCape switches the active cape
+func (h *AccountHandler) SetActiveCape(ctx context.Context, req *pb.SetActiveCapeRequest) (*pb.MinecraftProfile, error) {
+	profile, err := h.accountService.SetActiveCape(ctx, req.AccountId, req.CapeId)
+	if err != nil {
+		return nil, skinStatusError(err)
+	}
+	return h.toPbProfile(profile), nil
+}
+
+// GetCachedSkin returns the cached skin texture of an account
+func (h *AccountHandler) GetCachedSkin(ctx context.Context, req *pb.GetCachedSkinRequest) (*pb.CachedSkinResponse, error) {
+	localPath, url, err := h.accountService.GetCachedSkin(ctx, req.AccountId)
+	if err != nil && url == "" {
+		return nil, err
+	}
+	// A failed download still returns the remote URL so the UI can fall back to it
+	return &pb.CachedSkinResponse{LocalPath: localPath, Url: url}, nil
+}
+
+func (h *AccountHandler) toPbProfile(profile *msauth.Profile) *pb.MinecraftProfile {
+	out := &pb.MinecraftProfile{Id: profile.ID, Name: profile.Name}
+	for _, skin := range profile.Skins {
+		out.Skins = append(out.Skins, &pb.MinecraftSkin{
+			Id:        skin.ID,
+			State:     skin.State,
+			Url:       skin.URL,
+			Variant:   skin.Variant,
+			LocalPath: h.accountService.CachedTexturePath(skin.URL),
+		})
+	}
+	for _, cape := range profile.Capes {
+		out.Capes = append(out.Capes, &pb.MinecraftCape{
+			Id:        cape.ID,
+			State:     cape.State,
+			Url:       cape.URL,
+			Alias:     cape.Alias,
+			LocalPath: h.accountService.CachedTexturePath(cape.URL),
+		})
+	}
+	return out
+}
+
+// AddYggdrasilAccount adds a third-party (authlib-injector) account
+func (h *AccountHandler) AddYggdrasilAccount(ctx context.Context, req *pb.AddYggdrasilAccountRequest) (*pb.SaveAccountResponse, error) {
+	if req.ServerUrl == "" || req.Username == "" {
+		return nil, status.Error(codes.InvalidArgument, "server_url and username are required")
+	}
+	accountID, err := h.accountService.AddYggdrasilAccount(ctx, req.ServerUrl, req.Username, req.Password, req.ProfileName)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	return &pb.SaveAccountResponse{
+		AccountId: accountID,
+	}, nil
+}
+
+// GetLaunchAuth returns launch credentials for an account
+func (h *AccountHandler) GetLaunchAuth(ctx context.Context, req *pb.GetLaunchAuthRequest) (*pb.LaunchAuthResponse, error) {
+	auth, err := h.accountService.GetLaunchAuth(ctx, req.AccountId)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	resp := &pb.LaunchAuthResponse{
+		Name:                      auth.Name,
+		Uuid:                      auth.UUID,
+		AccessToken:               auth.AccessToken,
+		UserType:                  auth.UserType,
+		AuthlibInjectorApiRoot:    auth.AuthlibInjectorAPIRoot,
+		AuthlibInjectorPrefetched: auth.AuthlibInjectorPrefetched,
+	}
+	if auth.AuthlibInjectorAPIRoot != "" && req.AuthlibInjectorPath != "" {
+		resp.JvmArgs = yggdrasil.JVMArgs(req.AuthlibInjectorPath, auth.AuthlibInjectorAPIRoot, auth.AuthlibInjectorPrefetched)
+	}
+	return resp, nil
+}
+
+// ExportAccounts writes an encrypted account bundle
+func (h *AccountHandler) ExportAccounts(ctx context.Context, req *pb.ExportAccountsRequest) (*pb.ExportAccountsResponse, error) {
+	if req.FilePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "file_path is required")
+	}
+	count, err := h.accountService.ExportAccounts(ctx, req.FilePath, req.Passphrase, req.AccountIds)
+	if err != nil {
+		return nil, tokenStatusError(err)
+	}
+
+	return &pb.ExportAccountsResponse{Count: int32(count)}, nil
+}
+
+// ImportAccounts imports an encrypted account bundle
+func (h *AccountHandler) ImportAccounts(ctx context.Context, req *pb.ImportAccountsRequest) (*pb.ImportAccountsResponse, error) {
+	if req.FilePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "file_path is required")
+	}
+	result, err := h.accountService.ImportAccounts(ctx, req.FilePath, req.Passphrase, req.Overwrite)
+	if errors.Is(err, services.ErrWrongPassphrase) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, tokenStatusError(err)
+	}
+
+	return &pb.ImportAccountsResponse{
+		ImportedIds: result.Imported,
+		SkippedIds:  result.Skipped,
+		Unreadable:  int32(result.Unreadable),
+	}, nil
+}
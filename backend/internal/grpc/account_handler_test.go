@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"hyenimc/backend/internal/msauth"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusErrors(t *testing.T) {
+	cancelled := fmt.Errorf("profile: %w", context.Canceled)
+	for _, tc := range []struct {
+		name string
+		err  error
+		code codes.Code
+		msg  string
+	}{
+		{"login cancelled", authStatusError(cancelled), codes.Canceled, "login cancelled"},
+		{"skin cancelled", skinStatusError(cancelled), codes.Canceled, "skin request cancelled"},
+		{"skin no profile", skinStatusError(msauth.ErrNoProfile), codes.PermissionDenied, msauth.ErrNoProfile.Error()},
+		{"skin no client id", skinStatusError(msauth.ErrMissingClientID), codes.FailedPrecondition, msauth.ErrMissingClientID.Error()},
+	} {
+		st := status.Convert(tc.err)
+		if st.Code() != tc.code || st.Message() != tc.msg {
+			t.Errorf("%s: got %v %q", tc.name, st.Code(), st.Message())
+		}
+	}
+}
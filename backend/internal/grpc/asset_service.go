@@ -5,31 +5,71 @@ import (
     "encoding/json"
     "fmt"
     "io"
+    "log"
     "net/http"
+    "net/url"
     "os"
+    "path"
     "path/filepath"
+    "regexp"
     "runtime"
+    "sort"
     "strings"
     "sync"
     "time"
 
     pb "hyenimc/backend/gen/launcher"
+    "hyenimc/backend/internal/download"
 )
 
+const defaultAssetsBaseURL = "https://resources.download.minecraft.net"
+
+// minimum interval between progress events for a single prefetch
+const assetProgressInterval = 250 * time.Millisecond
+
+// assetHashPattern is a lowercase sha1; hashes become paths under objects/, so nothing else is accepted
+var assetHashPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 type assetServiceServer struct {
     pb.UnimplementedAssetServiceServer
+    events pb.DownloadServiceServer
 }
 
-func NewAssetServiceServer() pb.AssetServiceServer { return &assetServiceServer{} }
+// NewAssetServiceServer creates the asset service; progress is published on the given download event bus
+func NewAssetServiceServer(events pb.DownloadServiceServer) pb.AssetServiceServer {
+    return &assetServiceServer{events: events}
+}
 
 type assetIndex struct {
+    MapToResources bool `json:"map_to_resources,omitempty"`
+    Virtual        bool `json:"virtual,omitempty"`
     Objects map[string]struct{
         Hash string `json:"hash"`
         Size int64  `json:"size"`
     } `json:"objects"`
 }
 
+// assetObject is a unique object in the store; several index names may share one hash
+type assetObject struct {
+    Hash  string
+    Size  int64
+    Names []string
+}
+
 func (s *assetServiceServer) PrefetchAssets(ctx context.Context, req *pb.PrefetchAssetsRequest) (*pb.PrefetchAssetsResponse, error) {
+    return s.prefetch(ctx, req, nil)
+}
+
+func (s *assetServiceServer) PrefetchAssetsStream(req *pb.PrefetchAssetsRequest, stream pb.AssetService_PrefetchAssetsStreamServer) error {
+    _, err := s.prefetch(stream.Context(), req, func(p *pb.PrefetchAssetsProgress) error {
+        return stream.Send(p)
+    })
+    return err
+}
+
+// prefetch downloads and verifies every object of an asset index.
+// onProgress (optional) receives throttled progress and a final message with done=true.
+func (s *assetServiceServer) prefetch(ctx context.Context, req *pb.PrefetchAssetsRequest, onProgress func(*pb.PrefetchAssetsProgress) error) (*pb.PrefetchAssetsResponse, error) {
     idxURL := strings.TrimSpace(req.GetAssetsIndexUrl())
     baseDir := strings.TrimSpace(req.GetBaseDir())
     if idxURL == "" || baseDir == "" {
@@ -40,80 +80,244 @@ func (s *assetServiceServer) PrefetchAssets(ctx context.Context, req *pb.Prefetc
         maxPar = int(currentDownloadSettings().GetMaxParallel())
         if maxPar <= 0 { maxPar = 10 }
     }
+    resourcesURL := strings.TrimSuffix(strings.TrimSpace(req.GetResourcesBaseUrl()), "/")
+    if resourcesURL == "" { resourcesURL = defaultAssetsBaseURL }
+    taskID := req.GetTaskId()
+    if taskID == "" { taskID = fmt.Sprintf("assets-%d", time.Now().UnixNano()) }
 
     // fetch index json
-    cctx, cancel := context.WithTimeout(ctx, 60*time.Second)
-    defer cancel()
-    httpReq, err := http.NewRequestWithContext(cctx, http.MethodGet, idxURL, nil)
-    if err != nil { return nil, fmt.Errorf("build request: %w", err) }
-    resp, err := http.DefaultClient.Do(httpReq)
-    if err != nil { return nil, fmt.Errorf("fetch index: %w", err) }
-    defer resp.Body.Close()
-    if resp.StatusCode != http.StatusOK { return nil, fmt.Errorf("index status: %s", resp.Status) }
+    body, err := fetchAssetIndex(ctx, idxURL)
+    if err != nil { return nil, err }
     var idx assetIndex
-    if err := json.NewDecoder(resp.Body).Decode(&idx); err != nil { return nil, fmt.Errorf("decode index: %w", err) }
+    if err := json.Unmarshal(body, &idx); err != nil { return nil, fmt.Errorf("decode index: %w", err) }
+
+    // keep a copy of the index like the vanilla launcher does (<base>/indexes/<id>.json)
+    indexID, err := assetIndexID(idxURL)
+    if err != nil { return nil, err }
+    if err := os.MkdirAll(filepath.Join(baseDir, "indexes"), 0o755); err == nil {
+        _ = os.WriteFile(filepath.Join(baseDir, "indexes", indexID+".json"), body, 0o644)
+    }
+
+    // legacy layouts: copy objects by name in addition to the hashed store
+    var legacyDir string
+    switch {
+    case idx.MapToResources:
+        if gameDir := strings.TrimSpace(req.GetGameDir()); gameDir != "" {
+            legacyDir = filepath.Join(gameDir, "resources")
+        } else {
+            log.Printf("[Assets] index %s uses map_to_resources but no game_dir given; resources/ will not be populated", indexID)
+        }
+    case idx.Virtual:
+        legacyDir = filepath.Join(baseDir, "virtual", indexID)
+    }
+
+    // dedupe by hash so the same object is never written concurrently
+    byHash := make(map[string]*assetObject, len(idx.Objects))
+    var totalBytes int64
+    for name, o := range idx.Objects {
+        if !assetHashPattern.MatchString(o.Hash) {
+            return nil, fmt.Errorf("invalid asset index: object %q has invalid hash %q", name, o.Hash)
+        }
+        obj, ok := byHash[o.Hash]
+        if !ok {
+            obj = &assetObject{Hash: o.Hash, Size: o.Size}
+            byHash[o.Hash] = obj
+            totalBytes += o.Size
+        }
+        obj.Names = append(obj.Names, name)
+    }
+    items := make([]*assetObject, 0, len(byHash))
+    for _, obj := range byHash { items = append(items, obj) }
 
-    // download objects
     objectsDir := filepath.Join(baseDir, "objects")
-    type item struct{ Hash string; Size int64 }
-    items := make([]item, 0, len(idx.Objects))
-    for _, o := range idx.Objects { items = append(items, item{Hash: o.Hash, Size: o.Size}) }
+    evBase := &pb.ProgressEvent{TaskId: taskID, Type: "asset", Name: "Assets " + indexID, ProfileId: req.GetProfileId()}
 
-    sem := make(chan struct{}, maxPar)
-    var wg sync.WaitGroup
     var mu sync.Mutex
     var downloaded, skipped int32
+    var completedBytes int64
+    var failures []*pb.FailedAsset
+    var lastEmit time.Time
+    var sendErr error
 
+    // snapshot must be called with mu held
+    snapshot := func(done bool) *pb.PrefetchAssetsProgress {
+        return &pb.PrefetchAssetsProgress{
+            TaskId: taskID, Total: int32(len(items)), Completed: downloaded + skipped + int32(len(failures)),
+            Downloaded: downloaded, Skipped: skipped, Failed: int32(len(failures)),
+            TotalBytes: totalBytes, CompletedBytes: completedBytes, Done: done,
+        }
+    }
+    // emit must be called with mu held
+    emit := func(force bool) {
+        if !force && time.Since(lastEmit) < assetProgressInterval { return }
+        lastEmit = time.Now()
+        p := snapshot(false)
+        percent := int32(0)
+        if totalBytes > 0 { percent = int32((completedBytes * 100) / totalBytes) }
+        s.publish(&pb.ProgressEvent{TaskId: evBase.TaskId, Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, Status: "downloading", Downloaded: completedBytes, Total: totalBytes, Progress: percent})
+        if onProgress != nil && sendErr == nil { sendErr = onProgress(p) }
+    }
+
+    s.publish(&pb.ProgressEvent{TaskId: evBase.TaskId, Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, Status: "pending", Total: totalBytes})
+
+    sem := make(chan struct{}, maxPar)
+    var wg sync.WaitGroup
     for _, it := range items {
+        if ctx.Err() != nil { break }
         it := it
         wg.Add(1)
         sem <- struct{}{}
         go func() {
             defer wg.Done(); defer func(){ <-sem }()
-            sub := it.Hash[:2]
-            url := fmt.Sprintf("https://resources.download.minecraft.net/%s/%s", sub, it.Hash)
-            dest := filepath.Join(objectsDir, sub, it.Hash)
-            // skip if exists and size matches
-            if fi, err := os.Stat(dest); err == nil && fi.Size() == it.Size {
-                mu.Lock(); skipped++; mu.Unlock(); return
+            dest := filepath.Join(objectsDir, it.Hash[:2], it.Hash)
+            fetched, err := ensureAssetObject(ctx, resourcesURL, dest, it.Hash, it.Size)
+            if err == nil && legacyDir != "" {
+                err = copyLegacyAsset(dest, legacyDir, it)
             }
-            if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil { return }
-            tmp := dest + ".part"
-            // download with retries
-            var last error
-            for attempt := 0; attempt < 3; attempt++ {
-                if err := downloadToFile(ctx, url, tmp); err != nil {
-                    last = err
-                    time.Sleep(time.Duration(attempt+1) * time.Second)
-                    continue
-                }
-                // finalize
-                if err := os.Rename(tmp, dest); err != nil { last = err; continue }
-                _ = writeFileMeta(dest, nil)
-                mu.Lock(); downloaded++; mu.Unlock()
-                last = nil
-                break
+            mu.Lock()
+            defer mu.Unlock()
+            completedBytes += it.Size
+            switch {
+            case err != nil:
+                failures = append(failures, &pb.FailedAsset{Name: it.Names[0], Hash: it.Hash, Error: download.Message(err), ErrorCode: string(download.KindOf(err))})
+                emit(true)
+                return
+            case fetched:
+                downloaded++
+            default:
+                skipped++
             }
-            if last != nil { _ = os.Remove(tmp) }
+            emit(false)
         }()
     }
     wg.Wait()
 
-    return &pb.PrefetchAssetsResponse{Total: int32(len(items)), Downloaded: downloaded, Skipped: skipped}, nil
+    sort.Slice(failures, func(i, j int) bool { return failures[i].Name < failures[j].Name })
+    res := &pb.PrefetchAssetsResponse{Total: int32(len(items)), Downloaded: downloaded, Skipped: skipped, Failed: int32(len(failures)), Failures: failures}
+
+    switch {
+    case ctx.Err() != nil:
+        s.publish(&pb.ProgressEvent{TaskId: evBase.TaskId, Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, Status: "cancelled", ErrorCode: string(download.KindCanceled)})
+        return nil, ctx.Err()
+    case len(failures) > 0:
+        log.Printf("[Assets] %d/%d objects failed for index %s", len(failures), len(items), indexID)
+        s.publish(&pb.ProgressEvent{TaskId: evBase.TaskId, Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, Status: "failed",
+            Error: fmt.Sprintf("%d of %d assets failed: %s", len(failures), len(items), failures[0].Error), ErrorCode: failures[0].ErrorCode,
+            Downloaded: completedBytes, Total: totalBytes})
+    default:
+        s.publish(&pb.ProgressEvent{TaskId: evBase.TaskId, Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, Status: "completed", Progress: 100, Downloaded: completedBytes, Total: totalBytes})
+    }
+
+    if onProgress != nil && sendErr == nil {
+        final := snapshot(true)
+        final.Failures = failures
+        sendErr = onProgress(final)
+    }
+    if sendErr != nil { return nil, sendErr }
+    return res, nil
+}
+
+// assetIndexID derives the index name (e.g. "17") from the URL path, ignoring any query
+func assetIndexID(idxURL string) (string, error) {
+    u, err := url.Parse(idxURL)
+    if err != nil { return "", fmt.Errorf("invalid assets_index_url: %w", err) }
+    id := strings.TrimSuffix(path.Base(u.Path), ".json")
+    if id == "" || id == "." || id == "/" || id == ".." {
+        return "", fmt.Errorf("invalid assets_index_url: no index name in %q", idxURL)
+    }
+    return id, nil
+}
+
+func (s *assetServiceServer) publish(ev *pb.ProgressEvent) {
+    if s.events == nil { return }
+    _, _ = s.events.PublishProgress(context.Background(), ev)
+}
+
+func fetchAssetIndex(ctx context.Context, idxURL string) ([]byte, error) {
+    cctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+    defer cancel()
+    httpReq, err := http.NewRequestWithContext(cctx, http.MethodGet, idxURL, nil)
+    if err != nil { return nil, fmt.Errorf("build request: %w", err) }
+    resp, err := outboundClient.Do(httpReq)
+    if err != nil { return nil, fmt.Errorf("fetch index: %w", download.Classify(err)) }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK { return nil, fmt.Errorf("fetch index: %w", download.NewHTTPStatusError(resp)) }
+    body, err := io.ReadAll(resp.Body)
+    if err != nil { return nil, fmt.Errorf("fetch index: %w", download.Classify(err)) }
+    return body, nil
+}
+
+// ensureAssetObject makes sure dest holds the object with the given sha1.
+// Existing files are verified by size and hash; fetched reports whether a download happened.
+func ensureAssetObject(ctx context.Context, resourcesURL, dest, hash string, size int64) (fetched bool, err error) {
+    if fi, err := os.Stat(dest); err == nil && fi.Size() == size {
+        if verifyChecksum(dest, "sha1", hash) == nil { return false, nil }
+        log.Printf("[Assets] corrupt object %s, re-downloading", hash)
+    }
+    if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil { return false, err }
+    url := fmt.Sprintf("%s/%s/%s", resourcesURL, hash[:2], hash)
+    tmp := dest + ".part"
+    // download with retries
+    var last error
+    for attempt := 0; attempt < 3; attempt++ {
+        if attempt > 0 {
+            select {
+            case <-ctx.Done(): return false, ctx.Err()
+            case <-time.After(download.Backoff(last, attempt-1)):
+            }
+        }
+        if last = downloadToFile(ctx, url, tmp); last == nil {
+            last = verifyChecksum(tmp, "sha1", hash)
+        }
+        if last == nil {
+            // finalize
+            if last = os.Rename(tmp, dest); last == nil { break }
+        }
+        _ = os.Remove(tmp)
+        if ctx.Err() != nil || !download.IsRetryable(last) { break }
+    }
+    if last != nil { return false, last }
+    _ = writeFileMeta(dest, nil)
+    return true, nil
+}
+
+// copyLegacyAsset mirrors an object to its named path for virtual/map_to_resources indexes
+func copyLegacyAsset(src, legacyDir string, obj *assetObject) error {
+    for _, name := range obj.Names {
+        dest := filepath.Join(legacyDir, filepath.FromSlash(name))
+        // never let a crafted index write outside of the legacy dir
+        if rel, err := filepath.Rel(legacyDir, dest); err != nil || strings.HasPrefix(rel, "..") {
+            return fmt.Errorf("invalid asset name: %s", name)
+        }
+        if fi, err := os.Stat(dest); err == nil && fi.Size() == obj.Size { continue }
+        if err := copyFile(src, dest); err != nil { return err }
+    }
+    return nil
+}
+
+func copyFile(src, dest string) error {
+    if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil { return err }
+    in, err := os.Open(src)
+    if err != nil { return err }
+    defer in.Close()
+    out, err := os.Create(dest)
+    if err != nil { return err }
+    if _, err := io.Copy(out, in); err != nil { _ = out.Close(); return err }
+    return out.Close()
 }
 
 func downloadToFile(ctx context.Context, url, dest string) error {
     // Windows antivirus can transiently lock; small retries inside
     req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
     if err != nil { return err }
-    resp, err := http.DefaultClient.Do(req)
-    if err != nil { return err }
+    resp, err := outboundClient.Do(req)
+    if err != nil { return download.Classify(err) }
     defer resp.Body.Close()
-    if resp.StatusCode != http.StatusOK { return fmt.Errorf("status: %s", resp.Status) }
+    if resp.StatusCode != http.StatusOK { return download.NewHTTPStatusError(resp) }
     f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
     if err != nil { return err }
     defer f.Close()
-    if _, err := io.Copy(f, resp.Body); err != nil { return err }
+    if _, err := io.Copy(f, resp.Body); err != nil { return download.Classify(err) }
     if runtime.GOOS == "windows" { _ = f.Sync() }
     return nil
 }
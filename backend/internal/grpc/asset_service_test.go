@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "hyenimc/backend/gen/launcher"
+)
+
+// assetServer serves index at /indexes/17.json and payload's object under /objects
+func assetServer(t *testing.T, index string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/indexes/17.json":
+			w.Write([]byte(index))
+		case r.URL.Path == "/objects/"+sha1Hex(payload)[:2]+"/"+sha1Hex(payload):
+			w.Write([]byte(payload))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPrefetchAssetsVirtualIndexWithQuery(t *testing.T) {
+	hash := sha1Hex(payload)
+	srv := assetServer(t, fmt.Sprintf(`{"virtual": true, "objects": {"sounds/a.ogg": {"hash": %q, "size": %d}}}`, hash, len(payload)))
+	base := t.TempDir()
+
+	res, err := (&assetServiceServer{}).prefetch(context.Background(), &pb.PrefetchAssetsRequest{
+		AssetsIndexUrl:   srv.URL + "/indexes/17.json?sig=abc/def",
+		BaseDir:          base,
+		ResourcesBaseUrl: srv.URL + "/objects",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Downloaded != 1 || res.Failed != 0 {
+		t.Errorf("result %+v", res)
+	}
+	for _, p := range []string{
+		filepath.Join(base, "indexes", "17.json"),
+		filepath.Join(base, "objects", hash[:2], hash),
+		filepath.Join(base, "virtual", "17", "sounds", "a.ogg"),
+	} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("missing %s: %v", p, err)
+		}
+	}
+}
+
+func TestPrefetchAssetsRejectsInvalidHashes(t *testing.T) {
+	for _, hash := range []string{"../..", "../../../../etc/passwd", strings.ToUpper(sha1Hex(payload)), "ab", ""} {
+		srv := assetServer(t, fmt.Sprintf(`{"objects": {"evil": {"hash": %q, "size": 1}}}`, hash))
+		base := filepath.Join(t.TempDir(), "assets")
+
+		_, err := (&assetServiceServer{}).prefetch(context.Background(), &pb.PrefetchAssetsRequest{
+			AssetsIndexUrl:   srv.URL + "/indexes/17.json",
+			BaseDir:          base,
+			ResourcesBaseUrl: srv.URL + "/objects",
+		}, nil)
+		if err == nil || !strings.Contains(err.Error(), "invalid hash") {
+			t.Errorf("hash %q: got %v", hash, err)
+		}
+		if _, err := os.Stat(filepath.Join(base, "objects")); !os.IsNotExist(err) {
+			t.Errorf("hash %q: objects written: %v", hash, err)
+		}
+	}
+}
+
+func TestAssetIndexID(t *testing.T) {
+	cases := map[string]string{
+		"https://piston-meta.mojang.com/v1/packages/abc/17.json":      "17",
+		"https://example.com/indexes/1.7.10.json?token=x/y":           "1.7.10",
+		"https://example.com/indexes/legacy.json#frag":                "legacy",
+		"https://example.com/indexes/pre-1.6?download=1&name=../../x": "pre-1.6",
+	}
+	for in, want := range cases {
+		if got, err := assetIndexID(in); err != nil || got != want {
+			t.Errorf("%s: got %q, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"https://example.com/", "https://example.com", "https://example.com/..?x"} {
+		if got, err := assetIndexID(in); err == nil {
+			t.Errorf("%s: expected an error, got %q", in, got)
+		}
+	}
+}
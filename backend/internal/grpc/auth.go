@@ -0,0 +1,123 @@
+package grpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// minAuthTokenLength rejects handshake secrets too short to be unguessable
+const minAuthTokenLength = 32
+
+// Auth is the per-launch secret every call must present as
+// "authorization: Bearer <token>" metadata
+type Auth struct {
+	Token string
+	// Publish appends the token to .grpc-port (address|pid|token). Leave it
+	// false when the launcher handed the token over on stdin.
+	Publish bool
+}
+
+// NewAuthToken returns a fresh random secret
+func NewAuthToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate auth token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ReadAuthToken reads the secret the launcher writes as the first line of r,
+// giving up after timeout so a launcher that never writes cannot hang startup
+func ReadAuthToken(r io.Reader, timeout time.Duration) (string, error) {
+	tokens, err := ReadAuthTokens(r, 1, timeout)
+	if err != nil {
+		return "", err
+	}
+	return tokens[0], nil
+}
+
+// ReadAuthTokens reads n secrets the launcher writes one per line on r: the gRPC
+// token first, then the REST gateway token when the gateway is enabled
+func ReadAuthTokens(r io.Reader, n int, timeout time.Duration) ([]string, error) {
+	type result struct {
+		lines []string
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		br := bufio.NewReaderSize(r, 256)
+		var lines []string
+		for len(lines) < n {
+			line, err := br.ReadString('\n')
+			if err == io.EOF && line != "" {
+				err = nil
+			}
+			if err != nil {
+				ch <- result{nil, err}
+				return
+			}
+			lines = append(lines, line)
+		}
+		ch <- result{lines, nil}
+	}()
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return nil, fmt.Errorf("failed to read auth token: %w", res.err)
+		}
+		tokens := make([]string, len(res.lines))
+		for i, line := range res.lines {
+			token := strings.TrimSpace(line)
+			if len(token) < minAuthTokenLength || strings.ContainsAny(token, " \t|") {
+				return nil, fmt.Errorf("auth token must be at least %d characters without spaces", minAuthTokenLength)
+			}
+			tokens[i] = token
+		}
+		return tokens, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("timed out waiting for auth token")
+	}
+}
+
+// authorize checks the bearer token in the incoming metadata
+func (a Auth) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid auth token")
+}
+
+// ServerOptions returns the interceptors enforcing the token on unary and streaming calls
+func (a Auth) ServerOptions() []grpclib.ServerOption {
+	return []grpclib.ServerOption{
+		grpclib.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (any, error) {
+			if err := a.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpclib.ChainStreamInterceptor(func(srv any, ss grpclib.ServerStream, _ *grpclib.StreamServerInfo, handler grpclib.StreamHandler) error {
+			if err := a.authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
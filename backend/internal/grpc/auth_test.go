@@ -0,0 +1,173 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "hyenimc/backend/gen/launcher"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef"
+
+// streamDesc is a server-streaming method that sends one empty message
+var streamDesc = grpclib.StreamDesc{
+	StreamName:    "Watch",
+	ServerStreams: true,
+	Handler: func(_ any, stream grpclib.ServerStream) error {
+		return stream.SendMsg(&emptypb.Empty{})
+	},
+}
+
+// serve starts an authenticated server on addr and returns a client connection
+func serve(t *testing.T, addr string) (*grpclib.ClientConn, string) {
+	t.Helper()
+	dir := t.TempDir()
+	lis, address, err := listen(addr, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpclib.NewServer(Auth{Token: testToken}.ServerOptions()...)
+	pb.RegisterHealthServiceServer(server, NewHealthServiceServer())
+	server.RegisterService(&grpclib.ServiceDesc{
+		ServiceName: "test.Stream",
+		HandlerType: (*any)(nil),
+		Streams:     []grpclib.StreamDesc{streamDesc},
+	}, struct{}{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpclib.NewClient(address, grpclib.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, address
+}
+
+func checkAuth(t *testing.T, conn *grpclib.ClientConn) {
+	t.Helper()
+	client := pb.NewHealthServiceClient(conn)
+	for name, header := range map[string]string{
+		"missing": "",
+		"wrong":   "Bearer " + strings.Repeat("0", len(testToken)),
+		"scheme":  testToken,
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if header != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", header)
+		}
+		if _, err := client.Check(ctx, &emptypb.Empty{}); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("unary %s: expected Unauthenticated, got %v", name, err)
+		}
+		stream, err := conn.NewStream(ctx, &streamDesc, "/test.Stream/Watch")
+		if err == nil {
+			stream.CloseSend()
+			err = stream.RecvMsg(&emptypb.Empty{})
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("stream %s: expected Unauthenticated, got %v", name, err)
+		}
+		cancel()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+testToken)
+	if res, err := client.Check(ctx, &emptypb.Empty{}); err != nil || res.Status != "SERVING" {
+		t.Errorf("unary with token: %v %v", res, err)
+	}
+	stream, err := conn.NewStream(ctx, &streamDesc, "/test.Stream/Watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.CloseSend()
+	if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+		t.Errorf("stream with token: %v", err)
+	}
+}
+
+func TestAuthTCP(t *testing.T) {
+	conn, _ := serve(t, "")
+	checkAuth(t, conn)
+}
+
+func TestAuthUnixSocket(t *testing.T) {
+	// macOS limits socket paths to 104 bytes, more than TempDir can promise
+	dir, err := os.MkdirTemp("", "hmc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "s.sock")
+	// A stale socket from a crashed run is replaced
+	if lis, _, err := listen("unix:"+path, dir); err == nil {
+		lis.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+		lis.Close()
+	}
+
+	conn, address := serve(t, "unix:"+path)
+	if address != "unix:"+path {
+		t.Errorf("address %s", address)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket permissions %o", perm)
+	}
+	checkAuth(t, conn)
+}
+
+func TestListenRefusesNonSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grpc.sock")
+	os.WriteFile(path, []byte("keep"), 0600)
+	if _, _, err := listen("unix:"+path, ""); err == nil {
+		t.Fatal("expected an error for a regular file")
+	}
+}
+
+func TestReadAuthToken(t *testing.T) {
+	token, err := ReadAuthToken(strings.NewReader(testToken+"\r\nrest"), time.Second)
+	if err != nil || token != testToken {
+		t.Errorf("got %q, %v", token, err)
+	}
+	for _, in := range []string{"", "short\n", testToken[:16] + " " + testToken + "\n"} {
+		if _, err := ReadAuthToken(strings.NewReader(in), time.Second); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	defer r.Close()
+	if _, err := ReadAuthToken(r, 50*time.Millisecond); err == nil {
+		t.Error("expected a timeout")
+	}
+}
+
+func TestReadAuthTokens(t *testing.T) {
+	httpToken := strings.Repeat("f", 32)
+	tokens, err := ReadAuthTokens(strings.NewReader(testToken+"\n"+httpToken+"\n"), 2, time.Second)
+	if err != nil || len(tokens) != 2 || tokens[0] != testToken || tokens[1] != httpToken {
+		t.Errorf("got %q, %v", tokens, err)
+	}
+	for _, in := range []string{testToken + "\n", testToken + "\nshort\n"} {
+		if _, err := ReadAuthTokens(strings.NewReader(in), 2, time.Second); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"time"
 
 	"hyenimc/backend/internal/cache"
 	"hyenimc/backend/internal/services"
+	settingssvc "hyenimc/backend/internal/settings"
 
 	pb "hyenimc/backend/gen/launcher"
 )
@@ -31,6 +34,10 @@ type cacheServiceServer struct {
 
 // NewCacheServiceServer creates a new cache service server
 func NewCacheServiceServer(db *sql.DB, curseforgeAPIKey string) pb.CacheServiceServer {
+	return newCacheServiceServer(db, curseforgeAPIKey)
+}
+
+func newCacheServiceServer(db *sql.DB, curseforgeAPIKey string) *cacheServiceServer {
 	// Initialize repositories
 	apiCacheRepo := cache.NewAPICacheRepository(db)
 	loaderVersionsRepo := cache.NewLoaderVersionsRepository(db)
@@ -57,6 +64,36 @@ func NewCacheServiceServer(db *sql.DB, curseforgeAPIKey string) pb.CacheServiceS
 	}
 }
 
+// watchSettings applies cache.enabled, cache.ttl_days and cache.max_size_gb to the
+// API cache now and whenever they change
+func (s *cacheServiceServer) watchSettings(svc *settingssvc.Service) {
+	if svc == nil {
+		return
+	}
+	if cur, err := svc.Get(); err == nil {
+		s.applySettings(cur)
+	}
+	changes, _ := svc.Subscribe(4)
+	go func() {
+		for change := range changes {
+			if change.Has(settingssvc.KeyCacheEnabled) || change.Has(settingssvc.KeyCacheTTLDays) || change.Has(settingssvc.KeyCacheMaxSizeGB) {
+				s.applySettings(change.Settings)
+			}
+		}
+	}()
+}
+
+func (s *cacheServiceServer) applySettings(cur *settingssvc.GlobalSettings) {
+	limits := cache.APICacheLimits{
+		Disabled: !cur.CacheEnabled,
+		MaxTTL:   time.Duration(cur.CacheTTLDays) * 24 * time.Hour,
+		MaxBytes: int64(cur.CacheMaxSizeGB) << 30,
+	}
+	if err := s.apiCacheRepo.SetLimits(limits); err != nil {
+		log.Printf("[Cache] Failed to apply cache settings: %v", err)
+	}
+}
+
 // GetMinecraftVersions retrieves Minecraft versions (cached)
 func (s *cacheServiceServer) GetMinecraftVersions(ctx context.Context, req *pb.GetMinecraftVersionsRequest) (*pb.GetMinecraftVersionsResponse, error) {
 	manifest, err := s.minecraftVersions.GetVersions(req.ForceRefresh)
@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+)
+
+// downloadLimiter is a counting semaphore whose size can change while downloads run.
+// Shrinking it never interrupts active downloads; new ones wait until enough finish.
+type downloadLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	active int
+	wake   chan struct{} // closed and replaced whenever a slot may have opened
+}
+
+func newDownloadLimiter(limit int) *downloadLimiter {
+	if limit <= 0 {
+		limit = 10
+	}
+	return &downloadLimiter{limit: limit, wake: make(chan struct{})}
+}
+
+// Acquire blocks until a slot is free or ctx is done
+func (l *downloadLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.active < l.limit {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		wake := l.wake
+		l.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (l *downloadLimiter) Release() {
+	l.mu.Lock()
+	l.active--
+	l.broadcastLocked()
+	l.mu.Unlock()
+}
+
+// SetLimit changes the number of concurrent downloads
+func (l *downloadLimiter) SetLimit(limit int) {
+	if limit <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit == l.limit {
+		return
+	}
+	l.limit = limit
+	l.broadcastLocked()
+}
+
+// Limit returns the current number of allowed concurrent downloads
+func (l *downloadLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+func (l *downloadLimiter) broadcastLocked() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
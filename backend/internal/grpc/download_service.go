@@ -9,16 +9,25 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/download"
+	"hyenimc/backend/internal/httpclient"
+	settingssvc "hyenimc/backend/internal/settings"
 )
 
+// outboundClient is used by every gRPC service that fetches from the network.
+// Downloads bound each request with their own context timeout.
+var outboundClient = httpclient.New(0)
+
 // checksumReq represents a checksum request
 type checksumReq interface {
 	GetAlgo() string
@@ -89,18 +98,55 @@ type downloadServiceServer struct {
 	mu    sync.RWMutex
 	subs  map[chan *pb.ProgressEvent]struct{}
 	tasks map[string]context.CancelFunc
-	dlSem chan struct{}
+	dlLimit *downloadLimiter
+	// Defaults for requests that set none, kept current by watchSettings
+	timeoutMs  atomic.Int32
+	maxRetries atomic.Int32
 }
 
 func NewDownloadServiceServer() pb.DownloadServiceServer {
-	sz := currentDownloadSettings().GetMaxParallel()
-	if sz <= 0 {
-		sz = 10
+	return newDownloadServiceServer()
+}
+
+func newDownloadServiceServer() *downloadServiceServer {
+	s := &downloadServiceServer{
+		subs:    make(map[chan *pb.ProgressEvent]struct{}),
+		tasks:   make(map[string]context.CancelFunc),
+		dlLimit: newDownloadLimiter(settingssvc.DefaultDownloadMaxParallel),
+	}
+	s.timeoutMs.Store(settingssvc.DefaultDownloadTimeoutMs)
+	s.maxRetries.Store(settingssvc.DefaultDownloadMaxRetries)
+	return s
+}
+
+// watchSettings applies download.max_parallel, download.timeout_ms and
+// download.max_retries now and whenever they change; running downloads keep theirs
+func (s *downloadServiceServer) watchSettings(svc *settingssvc.Service) {
+	if svc == nil {
+		return
+	}
+	if cur, err := svc.Get(); err == nil {
+		s.applySettings(cur)
+	}
+	changes, _ := svc.Subscribe(4)
+	go func() {
+		for change := range changes {
+			if !change.Has(settingssvc.KeyDownloadMaxParallel) && !change.Has(settingssvc.KeyDownloadTimeoutMs) && !change.Has(settingssvc.KeyDownloadMaxRetries) {
+				continue
+			}
+			s.applySettings(change.Settings)
+			log.Printf("[Download] Settings applied: %d parallel, %dms timeout, %d retries", s.dlLimit.Limit(), s.timeoutMs.Load(), s.maxRetries.Load())
+		}
+	}()
+}
+
+func (s *downloadServiceServer) applySettings(cur *settingssvc.GlobalSettings) {
+	s.dlLimit.SetLimit(int(cur.DownloadMaxParallel))
+	if cur.DownloadTimeoutMs > 0 {
+		s.timeoutMs.Store(cur.DownloadTimeoutMs)
 	}
-	return &downloadServiceServer{
-		subs:  make(map[chan *pb.ProgressEvent]struct{}),
-		tasks: make(map[string]context.CancelFunc),
-		dlSem: make(chan struct{}, sz),
+	if cur.DownloadMaxRetries >= 0 {
+		s.maxRetries.Store(cur.DownloadMaxRetries)
 	}
 }
 
@@ -156,164 +202,152 @@ func (s *downloadServiceServer) broadcast(ev *pb.ProgressEvent) {
 }
 
 func (s *downloadServiceServer) StartDownload(ctx context.Context, req *pb.DownloadRequest) (*pb.DownloadStarted, error) {
+	taskID, dlCtx, err := s.register(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		_ = s.run(dlCtx, taskID, req)
+	}()
+	return &pb.DownloadStarted{TaskId: taskID}, nil
+}
+
+// Download runs a managed download and blocks until it finishes.
+// Progress is broadcast like StartDownload, and the task can be cancelled via Cancel or ctx.
+func (s *downloadServiceServer) Download(ctx context.Context, req *pb.DownloadRequest) error {
+	taskID, dlCtx, err := s.register(ctx, req)
+	if err != nil {
+		return err
+	}
+	return s.run(dlCtx, taskID, req)
+}
+
+// register validates a download request and tracks it as a cancellable task
+func (s *downloadServiceServer) register(parent context.Context, req *pb.DownloadRequest) (string, context.Context, error) {
 	url := strings.TrimSpace(req.GetUrl())
 	dest := strings.TrimSpace(req.GetDestPath())
 	if url == "" || dest == "" {
-		return nil, fmt.Errorf("url and dest_path are required")
+		return "", nil, fmt.Errorf("url and dest_path are required")
 	}
 	taskID := req.GetTaskId()
 	if taskID == "" {
 		taskID = fmt.Sprintf("dl-%d", time.Now().UnixNano())
 	}
 
-	dlCtx, cancel := context.WithCancel(context.Background())
+	dlCtx, cancel := context.WithCancel(parent)
 	s.mu.Lock()
 	if s.tasks == nil {
 		s.tasks = make(map[string]context.CancelFunc)
 	}
 	s.tasks[taskID] = cancel
 	s.mu.Unlock()
+	return taskID, dlCtx, nil
+}
 
-	go func() {
-		// global concurrency guard
-		s.dlSem <- struct{}{}
-		defer func() {
-			<-s.dlSem
-			s.mu.Lock()
+// run performs a registered download task with retries and checksum verification
+func (s *downloadServiceServer) run(dlCtx context.Context, taskID string, req *pb.DownloadRequest) error {
+	url := strings.TrimSpace(req.GetUrl())
+	dest := strings.TrimSpace(req.GetDestPath())
+	defer func() {
+		s.mu.Lock()
+		if cancel, ok := s.tasks[taskID]; ok {
+			cancel() // release context resources
 			delete(s.tasks, taskID)
-			s.mu.Unlock()
-		}()
-		started := time.Now()
-		evBase := &pb.ProgressEvent{TaskId: taskID, Type: req.GetType(), Name: req.GetName(), ProfileId: req.GetProfileId(), FileName: filepath.Base(dest)}
-		s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "pending", Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
-		maxRetries := int(req.GetMaxRetries())
-		if maxRetries <= 0 {
-			maxRetries = int(currentDownloadSettings().GetMaxRetries())
-			if maxRetries <= 0 {
-				maxRetries = 5
-			}
-		}
-		timeoutMs := int(currentDownloadSettings().GetRequestTimeoutMs())
-		if timeoutMs <= 0 {
-			timeoutMs = 3000
 		}
-		tmp := dest + ".part"
-		// ensure dir
-		_ = os.MkdirAll(filepath.Dir(dest), 0o755)
+		s.mu.Unlock()
+	}()
+	// global concurrency guard
+	if err := s.dlLimit.Acquire(dlCtx); err != nil {
+		return context.Canceled
+	}
+	defer s.dlLimit.Release()
+	evBase := &pb.ProgressEvent{TaskId: taskID, Type: req.GetType(), Name: req.GetName(), ProfileId: req.GetProfileId(), FileName: filepath.Base(dest)}
+	s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "pending", Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
+	maxRetries := int(req.GetMaxRetries())
+	if maxRetries <= 0 {
+		maxRetries = int(s.maxRetries.Load())
+	}
+	timeoutMs := int(s.timeoutMs.Load())
+	tmp := dest + ".part"
+	// ensure dir
+	_ = os.MkdirAll(filepath.Dir(dest), 0o755)
 
-		var total int64 = 0
-		var err error
-		for attempt := 0; attempt <= maxRetries; attempt++ {
-			err = s.downloadOnce(dlCtx, url, tmp, &total, timeoutMs, func(downloaded int64) {
-				// emit progress
-				percent := int32(0)
-				if total > 0 {
-					percent = int32((downloaded * 100) / total)
-				}
-				s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "downloading", Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName, Total: total, Downloaded: downloaded, Progress: percent})
-			})
-			if err != nil {
-				// Check if error is retryable
-				if !isRetryableError(err) {
-					// Permanent error (404, 403, etc) - fail immediately
-					break
-				}
-				
-				// Last attempt - no need to wait
-				if attempt >= maxRetries {
-					break
-				}
-				
-				// Exponential backoff: 1s, 2s, 4s, 8s, 16s, max 30s
-				backoffDelay := time.Duration(1<<uint(attempt)) * time.Second
-				maxBackoff := 30 * time.Second
-				if backoffDelay > maxBackoff {
-					backoffDelay = maxBackoff
-				}
-				
-				s.broadcast(&pb.ProgressEvent{
-					TaskId:    taskID,
-					Status:    "retrying",
-					Error:     fmt.Sprintf("Attempt %d/%d failed: %v. Retrying in %v...", attempt+1, maxRetries+1, err, backoffDelay),
-					Type:      evBase.Type,
-					Name:      evBase.Name,
-					ProfileId: evBase.ProfileId,
-					FileName:  evBase.FileName,
-				})
-				
-				select {
-				case <-dlCtx.Done():
-					err = context.Canceled
-					attempt = maxRetries + 1 // Force exit from retry loop
-				case <-time.After(backoffDelay):
-				}
-				continue
+	var total int64 = 0
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err = s.downloadOnce(dlCtx, url, tmp, &total, timeoutMs, func(downloaded int64) {
+			// emit progress
+			percent := int32(0)
+			if total > 0 {
+				percent = int32((downloaded * 100) / total)
 			}
-			
-			// verify checksum if provided (inside retry loop)
+			s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "downloading", Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName, Total: total, Downloaded: downloaded, Progress: percent})
+		})
+		// verify checksum if provided (inside retry loop)
+		if err == nil {
 			if c := req.GetChecksum(); c != nil && c.GetValue() != "" {
-				if verr := verifyChecksum(tmp, c.GetAlgo(), c.GetValue()); verr != nil {
+				if err = verifyChecksum(tmp, c.GetAlgo(), c.GetValue()); err != nil {
 					_ = os.Remove(tmp) // Remove corrupted file
-					
-					if attempt < maxRetries {
-						err = verr // Set error for retry
-						s.broadcast(&pb.ProgressEvent{
-							TaskId:    taskID,
-							Status:    "retrying",
-							Error:     fmt.Sprintf("Checksum mismatch (attempt %d/%d). Retrying...", attempt+1, maxRetries+1),
-							Type:      evBase.Type,
-							Name:      evBase.Name,
-							ProfileId: evBase.ProfileId,
-							FileName:  evBase.FileName,
-						})
-						
-						// Backoff before retry
-						backoffDelay := time.Duration(1<<uint(attempt)) * time.Second
-						select {
-						case <-dlCtx.Done():
-							err = context.Canceled
-							attempt = maxRetries + 1
-						case <-time.After(backoffDelay):
-						}
-						continue
-					}
-					
-					// Final checksum failure
-					s.broadcast(&pb.ProgressEvent{
-						TaskId:    taskID,
-						Status:    "failed",
-						Error:     fmt.Sprintf("Checksum verification failed after all retries: %v", verr),
-						Type:      evBase.Type,
-						Name:      evBase.Name,
-						ProfileId: evBase.ProfileId,
-						FileName:  evBase.FileName,
-					})
-					return
 				}
 			}
-			
-			// Success
+		}
+		if err == nil {
 			break
 		}
-		if err != nil {
-			s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "failed", Error: err.Error(), Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
-			return
+		// The task itself was cancelled; a per-attempt timeout is still retryable
+		if dlCtx.Err() != nil {
+			err = context.Canceled
+			break
 		}
-		// atomic rename
-		if err := os.Rename(tmp, dest); err != nil {
-			s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "failed", Error: fmt.Sprintf("finalize: %v", err), Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
-			return
+		// Permanent error (404, 403, TLS, etc) - fail immediately
+		if !download.IsRetryable(err) {
+			break
 		}
-		// write sidecar metadata for integrity & cache bookkeeping
-		if err := writeFileMeta(dest, req.GetChecksum()); err != nil {
-			// non-fatal
-			fmt.Printf("[Download] write meta failed for %s: %v\n", dest, err)
+		// Last attempt - no need to wait
+		if attempt >= maxRetries {
+			break
 		}
-		s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "completed", Progress: 100, Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
-		_ = started
-	}()
 
-	return &pb.DownloadStarted{TaskId: taskID}, nil
+		backoffDelay := download.Backoff(err, attempt)
+		s.broadcast(&pb.ProgressEvent{
+			TaskId:    taskID,
+			Status:    "retrying",
+			Error:     fmt.Sprintf("Attempt %d/%d failed: %s. Retrying in %v...", attempt+1, maxRetries+1, download.Message(err), backoffDelay),
+			ErrorCode: string(download.KindOf(err)),
+			Type:      evBase.Type,
+			Name:      evBase.Name,
+			ProfileId: evBase.ProfileId,
+			FileName:  evBase.FileName,
+		})
+
+		select {
+		case <-dlCtx.Done():
+			err = context.Canceled
+			attempt = maxRetries + 1 // Force exit from retry loop
+		case <-time.After(backoffDelay):
+		}
+	}
+	if err != nil {
+		status := "failed"
+		if errors.Is(err, context.Canceled) {
+			status = "cancelled"
+		}
+		log.Printf("[Download] %s failed: %v", url, err)
+		s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: status, Error: download.Message(err), ErrorCode: string(download.KindOf(err)), Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
+		return err
+	}
+	// atomic rename
+	if err := os.Rename(tmp, dest); err != nil {
+		s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "failed", Error: fmt.Sprintf("finalize: %v", err), Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
+		return fmt.Errorf("finalize: %w", err)
+	}
+	// write sidecar metadata for integrity & cache bookkeeping
+	if err := writeFileMeta(dest, req.GetChecksum()); err != nil {
+		// non-fatal
+		fmt.Printf("[Download] write meta failed for %s: %v\n", dest, err)
+	}
+	s.broadcast(&pb.ProgressEvent{TaskId: taskID, Status: "completed", Progress: 100, Type: evBase.Type, Name: evBase.Name, ProfileId: evBase.ProfileId, FileName: evBase.FileName})
+	return nil
 }
 
 func (s *downloadServiceServer) Cancel(ctx context.Context, in *pb.DownloadCancel) (*pb.Ack, error) {
@@ -328,54 +362,6 @@ func (s *downloadServiceServer) Cancel(ctx context.Context, in *pb.DownloadCance
 	return &pb.Ack{Ok: false}, nil
 }
 
-// isRetryableError determines if an error should trigger a retry
-func isRetryableError(err error) bool {
-	if err == nil {
-		return false
-	}
-	
-	errStr := err.Error()
-	
-	// Network errors are retryable
-	if strings.Contains(errStr, "connection refused") ||
-		strings.Contains(errStr, "connection reset") ||
-		strings.Contains(errStr, "timeout") ||
-		strings.Contains(errStr, "temporary failure") ||
-		strings.Contains(errStr, "no such host") ||
-		strings.Contains(errStr, "EOF") {
-		return true
-	}
-	
-	// HTTP 5xx errors are retryable (server issues)
-	if strings.Contains(errStr, "503") || // Service Unavailable
-		strings.Contains(errStr, "502") || // Bad Gateway
-		strings.Contains(errStr, "504") || // Gateway Timeout
-		strings.Contains(errStr, "500") {  // Internal Server Error
-		return true
-	}
-	
-	// HTTP 429 (Rate Limit) is retryable
-	if strings.Contains(errStr, "429") {
-		return true
-	}
-	
-	// Permanent errors (DO NOT retry)
-	if strings.Contains(errStr, "404") || // Not Found
-		strings.Contains(errStr, "403") || // Forbidden
-		strings.Contains(errStr, "401") || // Unauthorized
-		strings.Contains(errStr, "410") {  // Gone
-		return false
-	}
-	
-	// Context errors are not retryable
-	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-		return false
-	}
-	
-	// Default: retry for unknown errors (conservative approach)
-	return true
-}
-
 // downloadOnce supports resume if tmp exists and server honors Range.
 func (s *downloadServiceServer) downloadOnce(ctx context.Context, url, tmp string, totalOut *int64, timeoutMs int, onProgress func(downloaded int64)) error {
     // resume if possible
@@ -397,13 +383,13 @@ func (s *downloadServiceServer) downloadOnce(ctx context.Context, url, tmp strin
 	if downloaded > 0 {
 		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", downloaded))
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := outboundClient.Do(req)
 	if err != nil {
-		return err
+		return download.Classify(err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		return fmt.Errorf("bad status: %s", resp.Status)
+		return download.NewHTTPStatusError(resp)
 	}
 	// compute total
 	if resp.ContentLength > 0 {
@@ -439,7 +425,7 @@ func (s *downloadServiceServer) downloadOnce(ctx context.Context, url, tmp strin
 			if errors.Is(rerr, io.EOF) {
 				break
 			}
-			return rerr
+			return download.Classify(rerr)
 		}
 	}
 	return nil
@@ -459,7 +445,7 @@ func verifyChecksum(path, algo, wantHex string) error {
 		}
 		got := hex.EncodeToString(h.Sum(nil))
 		if !strings.EqualFold(got, wantHex) {
-			return fmt.Errorf("sha1 mismatch: got %s", got)
+			return &download.ChecksumError{Algo: "sha1", Want: wantHex, Got: got}
 		}
 	case "sha256":
 		h := sha256.New()
@@ -468,10 +454,10 @@ func verifyChecksum(path, algo, wantHex string) error {
 		}
 		got := hex.EncodeToString(h.Sum(nil))
 		if !strings.EqualFold(got, wantHex) {
-			return fmt.Errorf("sha256 mismatch: got %s", got)
+			return &download.ChecksumError{Algo: "sha256", Want: wantHex, Got: got}
 		}
 	default:
-		return fmt.Errorf("unsupported checksum algo: %s", algo)
+		return fmt.Errorf("%w: %q", download.ErrUnsupportedChecksum, algo)
 	}
 	return nil
 }
@@ -0,0 +1,243 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/db"
+	"hyenimc/backend/internal/download"
+	settingssvc "hyenimc/backend/internal/settings"
+)
+
+const payload = "hyenimc download payload"
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// faultServer serves payload, letting fail decide how the n-th request (from 1) misbehaves.
+// fail returns true when it has written the response itself.
+func faultServer(t *testing.T, fail func(n int, w http.ResponseWriter, r *http.Request) bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		if fail != nil && fail(n, w, r) {
+			return
+		}
+		body := payload
+		if rng := r.Header.Get("Range"); rng != "" {
+			from, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			body = payload[from:]
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, len(payload)-1, len(payload)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// runDownload downloads url with the given checksum and returns the last progress event
+func runDownload(t *testing.T, url string, checksum *pb.Checksum) (dest string, last *pb.ProgressEvent, err error) {
+	t.Helper()
+	s := newDownloadServiceServer()
+	events := make(chan *pb.ProgressEvent, 256)
+	s.subs[events] = struct{}{}
+
+	dest = filepath.Join(t.TempDir(), "file.bin")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	err = s.Download(ctx, &pb.DownloadRequest{Url: url, DestPath: dest, Checksum: checksum, MaxRetries: 2})
+
+	for len(events) > 0 {
+		last = <-events
+	}
+	return dest, last, err
+}
+
+func TestDownloadNotFoundIsNotRetried(t *testing.T) {
+	srv, requests := faultServer(t, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		http.NotFound(w, r)
+		return true
+	})
+	dest, last, err := runDownload(t, srv.URL, nil)
+	if download.KindOf(err) != download.KindHTTPStatus || requests.Load() != 1 {
+		t.Fatalf("got %v after %d requests", err, requests.Load())
+	}
+	if last.GetStatus() != "failed" || last.GetErrorCode() != string(download.KindHTTPStatus) {
+		t.Errorf("last event %+v", last)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("destination created: %v", err)
+	}
+}
+
+func TestDownloadRetriesServerErrorHonoringRetryAfter(t *testing.T) {
+	srv, requests := faultServer(t, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return true
+		}
+		return false
+	})
+	start := time.Now()
+	dest, _, err := runDownload(t, srv.URL, &pb.Checksum{Algo: "sha1", Value: sha1Hex(payload)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 2 || time.Since(start) < time.Second {
+		t.Errorf("%d requests in %v", requests.Load(), time.Since(start))
+	}
+	if data, _ := os.ReadFile(dest); string(data) != payload {
+		t.Errorf("content %q", data)
+	}
+}
+
+func TestDownloadResumesAfterConnectionReset(t *testing.T) {
+	srv, requests := faultServer(t, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		if n > 1 {
+			return false
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return true
+		}
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n%s", len(payload), payload[:10])
+		buf.Flush()
+		conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+		return true
+	})
+	var ranged atomic.Bool
+	srv.Config.Handler = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") == "bytes=10-" {
+				ranged.Store(true)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}(srv.Config.Handler)
+
+	dest, _, err := runDownload(t, srv.URL, &pb.Checksum{Algo: "sha1", Value: sha1Hex(payload)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 2 || !ranged.Load() {
+		t.Errorf("%d requests, resumed with range: %v", requests.Load(), ranged.Load())
+	}
+	if data, _ := os.ReadFile(dest); string(data) != payload {
+		t.Errorf("content %q", data)
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	srv, requests := faultServer(t, nil)
+	dest, last, err := runDownload(t, srv.URL, &pb.Checksum{Algo: "sha1", Value: sha1Hex("something else")})
+	var sumErr *download.ChecksumError
+	if !errors.As(err, &sumErr) {
+		t.Fatalf("got %v", err)
+	}
+	// Every attempt downloads and fails verification
+	if requests.Load() != 3 {
+		t.Errorf("%d requests, want 3", requests.Load())
+	}
+	if last.GetErrorCode() != string(download.KindChecksum) {
+		t.Errorf("last event %+v", last)
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Errorf("corrupted partial file kept: %v", err)
+	}
+}
+
+func TestDownloadUnsupportedChecksumIsTerminal(t *testing.T) {
+	srv, requests := faultServer(t, nil)
+	_, last, err := runDownload(t, srv.URL, &pb.Checksum{Algo: "md5", Value: "00"})
+	if !errors.Is(err, download.ErrUnsupportedChecksum) || requests.Load() != 1 {
+		t.Fatalf("got %v after %d requests", err, requests.Load())
+	}
+	if last.GetErrorCode() != string(download.KindUnsupported) {
+		t.Errorf("last event %+v", last)
+	}
+}
+
+func TestDownloadOnceTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv, _ := faultServer(t, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		return true
+	})
+	defer close(release)
+
+	s := newDownloadServiceServer()
+	var total int64
+	tmp := filepath.Join(t.TempDir(), "file.part")
+	err := s.downloadOnce(context.Background(), srv.URL, tmp, &total, 100, func(int64) {})
+	if download.KindOf(err) != download.KindTimeout || !download.IsRetryable(err) {
+		t.Errorf("got %v (%s)", err, download.KindOf(err))
+	}
+}
+
+func TestDownloadSettingsApplyLive(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "hyenimc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	svc := settingssvc.NewService(settingssvc.NewRepository(conn))
+	if err := svc.SetValues(map[string]string{settingssvc.KeyDownloadTimeoutMs: "1500"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stored values apply at startup
+	s := newDownloadServiceServer()
+	s.watchSettings(svc)
+	if s.timeoutMs.Load() != 1500 || s.maxRetries.Load() != settingssvc.DefaultDownloadMaxRetries {
+		t.Fatalf("timeout %d, retries %d", s.timeoutMs.Load(), s.maxRetries.Load())
+	}
+
+	// Changes apply to the next download; zero retries means a single attempt
+	if err := svc.SetValues(map[string]string{settingssvc.KeyDownloadMaxRetries: "0", settingssvc.KeyDownloadMaxParallel: "3"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for s.maxRetries.Load() != 0 || s.dlLimit.Limit() != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("retries %d, parallel %d", s.maxRetries.Load(), s.dlLimit.Limit())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	srv, requests := faultServer(t, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := s.Download(context.Background(), &pb.DownloadRequest{Url: srv.URL, DestPath: dest}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("%d requests", requests.Load())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/services"
 )
 
 // instanceServiceServer provides log streaming (skeleton)
@@ -14,12 +15,16 @@ type instanceServiceServer struct {
 	mu         sync.RWMutex
 	subs       map[string]map[chan *pb.LogLine]struct{} // profile_id -> subscribers
 	stateSubs  map[string]map[chan *pb.StateEvent]struct{} // profile_id -> state subscribers
+	profiles   *services.ProfileService
+	downloads  *downloadServiceServer // used by VerifyInstance to repair files
 }
 
-func NewInstanceServiceServer() pb.InstanceServiceServer {
+func NewInstanceServiceServer(profiles *services.ProfileService, downloads *downloadServiceServer) pb.InstanceServiceServer {
 	return &instanceServiceServer{
 		subs:      make(map[string]map[chan *pb.LogLine]struct{}),
 		stateSubs: make(map[string]map[chan *pb.StateEvent]struct{}),
+		profiles:  profiles,
+		downloads: downloads,
 	}
 }
 
@@ -0,0 +1,633 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/download"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	mojangVersionManifestV2 = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+	mojangLibrariesURL      = "https://libraries.minecraft.net/"
+	// version JSON inheritance never goes deeper than a couple of levels; guard against cycles
+	maxVersionChainDepth = 8
+)
+
+// versionArtifact is a downloadable file described by a version JSON
+type versionArtifact struct {
+	Path string `json:"path,omitempty"`
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+	URL  string `json:"url"`
+}
+
+type versionRule struct {
+	Action string `json:"action"`
+	OS     *struct {
+		Name string `json:"name,omitempty"`
+		Arch string `json:"arch,omitempty"`
+	} `json:"os,omitempty"`
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+type versionLibrary struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+	// Fabric/Quilt meta put checksums on the library itself
+	SHA1      string `json:"sha1,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	Downloads *struct {
+		Artifact    *versionArtifact            `json:"artifact,omitempty"`
+		Classifiers map[string]*versionArtifact `json:"classifiers,omitempty"`
+	} `json:"downloads,omitempty"`
+	Natives map[string]string `json:"natives,omitempty"`
+	Rules   []versionRule     `json:"rules,omitempty"`
+}
+
+type versionAssetIndex struct {
+	ID   string `json:"id"`
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+	URL  string `json:"url"`
+}
+
+// versionJSON is the subset of a Minecraft version JSON needed for verification
+type versionJSON struct {
+	ID           string `json:"id"`
+	InheritsFrom string `json:"inheritsFrom,omitempty"`
+	Downloads    struct {
+		Client *versionArtifact `json:"client,omitempty"`
+	} `json:"downloads"`
+	AssetIndex *versionAssetIndex `json:"assetIndex,omitempty"`
+	Libraries  []versionLibrary   `json:"libraries"`
+}
+
+// verifyTarget is a single file that must exist with the given checksum
+type verifyTarget struct {
+	Path string
+	Kind string
+	SHA1 string
+	Size int64
+	URL  string
+}
+
+// verifyReporter serializes progress messages onto the stream
+type verifyReporter struct {
+	mu        sync.Mutex
+	stream    pb.InstanceService_VerifyInstanceServer
+	versionID string
+	lastSent  time.Time
+	err       error
+}
+
+func (r *verifyReporter) send(p *pb.VerifyInstanceProgress, force bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || (!force && time.Since(r.lastSent) < 250*time.Millisecond) {
+		return
+	}
+	r.lastSent = time.Now()
+	p.VersionId = r.versionID
+	r.err = r.stream.Send(p)
+}
+
+// VerifyInstance checks libraries, client jar, natives and assets of a profile against
+// the checksums in its version JSON chain and optionally re-downloads broken files.
+func (s *instanceServiceServer) VerifyInstance(req *pb.VerifyInstanceRequest, stream pb.InstanceService_VerifyInstanceServer) error {
+	ctx := stream.Context()
+	if s.profiles == nil {
+		return status.Error(codes.Unavailable, "profile service not available")
+	}
+	if strings.TrimSpace(req.GetProfileId()) == "" {
+		return status.Error(codes.InvalidArgument, "profile_id is required")
+	}
+	p, err := s.profiles.GetProfile(ctx, req.GetProfileId())
+	if err != nil {
+		return status.Errorf(codes.NotFound, "profile: %v", err)
+	}
+	inst := p.GameDirectory
+	versionID := strings.TrimSpace(req.GetVersionId())
+	if versionID == "" {
+		versionID = profileVersionID(p)
+	}
+	// Custom game directories live anywhere, so the shared dir comes from the data dir
+	sharedDir := s.profiles.SharedDir()
+	librariesDir := filepath.Join(sharedDir, "libraries")
+	assetsDir := filepath.Join(sharedDir, "assets")
+
+	rep := &verifyReporter{stream: stream, versionID: versionID}
+	rep.send(&pb.VerifyInstanceProgress{Phase: "resolving"}, true)
+
+	var issues []*pb.InstanceFileIssue
+	chain, err := s.loadVersionChain(ctx, inst, versionID, p.ID, req.GetRepair())
+	if err != nil {
+		var missing *missingVersionError
+		if !errors.As(err, &missing) {
+			return status.Errorf(codes.FailedPrecondition, "resolve version %s: %v", versionID, err)
+		}
+		// Without the version JSON there is nothing more to check
+		issues = append(issues, &pb.InstanceFileIssue{Path: missing.path, Kind: "version", Problem: "missing"})
+		rep.send(&pb.VerifyInstanceProgress{Phase: "done", Done: true, Issues: issues}, true)
+		return rep.err
+	}
+
+	targets := collectVersionTargets(chain, inst, librariesDir)
+
+	// The asset index must be valid before its objects can be enumerated
+	if idx := chainAssetIndex(chain); idx != nil && !req.GetSkipAssets() {
+		indexTarget := verifyTarget{Path: filepath.Join(assetsDir, "indexes", idx.ID+".json"), Kind: "asset_index", SHA1: idx.SHA1, Size: idx.Size, URL: idx.URL}
+		if issue := checkTarget(indexTarget); issue != nil {
+			if req.GetRepair() {
+				s.repairIssue(ctx, issue, p.ID, indexTarget.SHA1)
+			}
+			if !issue.GetRepaired() {
+				issues = append(issues, issue)
+			}
+		}
+		if objects, err := readAssetObjects(indexTarget.Path); err == nil {
+			targets = append(targets, objects(assetsDir)...)
+		} else {
+			log.Printf("[Verify] cannot read asset index %s: %v", indexTarget.Path, err)
+		}
+	}
+
+	total := int32(len(targets))
+	rep.send(&pb.VerifyInstanceProgress{Phase: "checking", Total: total}, true)
+
+	maxPar := int(currentDownloadSettings().GetMaxParallel())
+	if maxPar <= 0 {
+		maxPar = 10
+	}
+	var mu sync.Mutex
+	var checked int32
+	sem := make(chan struct{}, maxPar)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		t := t
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			issue := checkTarget(t)
+			mu.Lock()
+			checked++
+			if issue != nil {
+				issues = append(issues, issue)
+			}
+			cur := checked
+			mu.Unlock()
+			rep.send(&pb.VerifyInstanceProgress{Phase: "checking", Current: cur, Total: total, File: relPath(sharedDir, inst, t.Path)}, false)
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var repaired int32
+	if req.GetRepair() {
+		var toRepair []*pb.InstanceFileIssue
+		for _, issue := range issues {
+			if issue.GetRepairable() && !issue.GetRepaired() {
+				toRepair = append(toRepair, issue)
+			}
+		}
+		rTotal := int32(len(toRepair))
+		rep.send(&pb.VerifyInstanceProgress{Phase: "repairing", Total: rTotal}, true)
+		var done int32
+		for _, issue := range toRepair {
+			if ctx.Err() != nil {
+				break
+			}
+			issue := issue
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				s.repairIssue(ctx, issue, p.ID, issue.GetExpectedSha1())
+				mu.Lock()
+				done++
+				if issue.GetRepaired() {
+					repaired++
+				}
+				cur := done
+				mu.Unlock()
+				rep.send(&pb.VerifyInstanceProgress{Phase: "repairing", Current: cur, Total: rTotal, File: relPath(sharedDir, inst, issue.GetPath())}, false)
+			}()
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Kind != issues[j].Kind {
+			return issues[i].Kind < issues[j].Kind
+		}
+		return issues[i].Path < issues[j].Path
+	})
+	log.Printf("[Verify] profile %s (%s): checked %d files, %d issues, %d repaired", p.ID, versionID, checked, len(issues), repaired)
+	rep.send(&pb.VerifyInstanceProgress{Phase: "done", Done: true, Checked: checked, Repaired: repaired, Total: total, Current: checked, Issues: issues}, true)
+	return rep.err
+}
+
+// repairIssue re-downloads a broken file through the download service and records the outcome
+func (s *instanceServiceServer) repairIssue(ctx context.Context, issue *pb.InstanceFileIssue, profileID, sha1 string) {
+	if !issue.GetRepairable() || s.downloads == nil {
+		return
+	}
+	dlType := "minecraft"
+	if strings.HasPrefix(issue.GetKind(), "asset") {
+		dlType = "asset"
+	}
+	// a stale partial file would be resumed and fail verification again
+	_ = os.Remove(issue.GetPath() + ".part")
+	dl := &pb.DownloadRequest{
+		Url:       issue.GetUrl(),
+		DestPath:  issue.GetPath(),
+		ProfileId: profileID,
+		Type:      dlType,
+		Name:      filepath.Base(issue.GetPath()),
+	}
+	if sha1 != "" {
+		dl.Checksum = &pb.Checksum{Algo: "sha1", Value: sha1}
+	}
+	if err := s.downloads.Download(ctx, dl); err != nil {
+		issue.RepairError = download.Message(err)
+		return
+	}
+	issue.Repaired = true
+}
+
+type missingVersionError struct{ path string }
+
+func (e *missingVersionError) Error() string { return "version json not found: " + e.path }
+
+// loadVersionChain reads the version JSON and its inheritsFrom parents (child first).
+// With repair enabled, a missing vanilla version JSON is fetched from the Mojang manifest.
+func (s *instanceServiceServer) loadVersionChain(ctx context.Context, inst, versionID, profileID string, repair bool) ([]*versionJSON, error) {
+	var chain []*versionJSON
+	seen := map[string]bool{}
+	for id := versionID; id != ""; {
+		if seen[id] || len(chain) >= maxVersionChainDepth {
+			return nil, fmt.Errorf("circular version dependency: %s", id)
+		}
+		seen[id] = true
+		path := filepath.Join(inst, "versions", id, id+".json")
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) && repair {
+			if ferr := s.fetchVanillaVersionJSON(ctx, id, path, profileID); ferr != nil {
+				log.Printf("[Verify] cannot fetch version json %s: %v", id, ferr)
+			} else {
+				data, err = os.ReadFile(path)
+			}
+		}
+		if os.IsNotExist(err) {
+			return nil, &missingVersionError{path: path}
+		}
+		if err != nil {
+			return nil, err
+		}
+		var v versionJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		if v.ID == "" {
+			v.ID = id
+		}
+		chain = append(chain, &v)
+		id = v.InheritsFrom
+	}
+	return chain, nil
+}
+
+// fetchVanillaVersionJSON downloads a vanilla version JSON listed in the Mojang manifest
+func (s *instanceServiceServer) fetchVanillaVersionJSON(ctx context.Context, id, dest, profileID string) error {
+	if s.downloads == nil {
+		return fmt.Errorf("download service not available")
+	}
+	cctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(cctx, http.MethodGet, mojangVersionManifestV2, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		return download.Classify(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return download.NewHTTPStatusError(resp)
+	}
+	var manifest struct {
+		Versions []struct {
+			ID   string `json:"id"`
+			URL  string `json:"url"`
+			SHA1 string `json:"sha1"`
+		} `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return fmt.Errorf("decode manifest: %w", err)
+	}
+	for _, v := range manifest.Versions {
+		if v.ID != id {
+			continue
+		}
+		dl := &pb.DownloadRequest{Url: v.URL, DestPath: dest, ProfileId: profileID, Type: "minecraft", Name: id + ".json"}
+		if v.SHA1 != "" {
+			dl.Checksum = &pb.Checksum{Algo: "sha1", Value: v.SHA1}
+		}
+		return s.downloads.Download(ctx, dl)
+	}
+	return fmt.Errorf("version %s not in manifest", id)
+}
+
+// collectVersionTargets lists client jar, libraries and natives for a resolved chain.
+// Libraries are checked where the launcher loads them from (see libraryPath).
+func collectVersionTargets(chain []*versionJSON, inst, librariesDir string) []verifyTarget {
+	var targets []verifyTarget
+	seen := map[string]bool{}
+	add := func(t verifyTarget) {
+		if seen[t.Path] {
+			return
+		}
+		seen[t.Path] = true
+		targets = append(targets, t)
+	}
+
+	// The client jar lives next to the version that declares it (the vanilla parent)
+	for _, v := range chain {
+		if c := v.Downloads.Client; c != nil {
+			add(verifyTarget{Path: filepath.Join(inst, "versions", v.ID, v.ID+".jar"), Kind: "client", SHA1: c.SHA1, Size: c.Size, URL: c.URL})
+			break
+		}
+	}
+
+	for _, v := range chain {
+		for _, lib := range v.Libraries {
+			if !rulesAllow(lib.Rules) {
+				continue
+			}
+			if t, ok := libraryTarget(lib); ok {
+				t.Path = libraryPath(inst, librariesDir, t.Path)
+				add(t)
+			}
+			if t, ok := nativesTarget(lib); ok {
+				t.Path = libraryPath(inst, librariesDir, t.Path)
+				add(t)
+			}
+		}
+	}
+	return targets
+}
+
+// libraryPath returns the file the launcher loads for the repository path rel: the
+// instance's own libraries/ copy when there is one, the shared library otherwise
+func libraryPath(inst, sharedLibrariesDir, rel string) string {
+	local := filepath.Join(inst, "libraries", filepath.FromSlash(rel))
+	if _, err := os.Stat(local); err == nil {
+		return local
+	}
+	return filepath.Join(sharedLibrariesDir, filepath.FromSlash(rel))
+}
+
+// libraryTarget returns the artifact of lib with its repository path as Path
+func libraryTarget(lib versionLibrary) (verifyTarget, bool) {
+	if lib.Downloads != nil && lib.Downloads.Artifact != nil {
+		a := lib.Downloads.Artifact
+		path := a.Path
+		if path == "" {
+			path, _ = mavenPath(lib.Name)
+		}
+		if path == "" {
+			return verifyTarget{}, false
+		}
+		return verifyTarget{Path: path, Kind: "library", SHA1: a.SHA1, Size: a.Size, URL: a.URL}, true
+	}
+	// old-style natives-only entries have downloads.classifiers but no artifact
+	if lib.Downloads != nil && lib.Natives != nil {
+		return verifyTarget{}, false
+	}
+	path, ok := mavenPath(lib.Name)
+	if !ok {
+		return verifyTarget{}, false
+	}
+	base := lib.URL
+	if base == "" {
+		base = mojangLibrariesURL
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return verifyTarget{Path: path, Kind: "library", SHA1: lib.SHA1, Size: lib.Size, URL: base + path}, true
+}
+
+// nativesTarget returns the natives jar of lib for this OS with its repository path as Path
+func nativesTarget(lib versionLibrary) (verifyTarget, bool) {
+	if lib.Natives == nil || lib.Downloads == nil {
+		return verifyTarget{}, false
+	}
+	classifier, ok := lib.Natives[mojangOSName()]
+	if !ok {
+		return verifyTarget{}, false
+	}
+	arch := "64"
+	if runtime.GOARCH == "386" || runtime.GOARCH == "arm" {
+		arch = "32"
+	}
+	classifier = strings.ReplaceAll(classifier, "${arch}", arch)
+	a := lib.Downloads.Classifiers[classifier]
+	if a == nil || a.Path == "" {
+		return verifyTarget{}, false
+	}
+	return verifyTarget{Path: a.Path, Kind: "natives", SHA1: a.SHA1, Size: a.Size, URL: a.URL}, true
+}
+
+// mavenPath converts group:artifact:version[:classifier][@ext] to a repository path
+func mavenPath(name string) (string, bool) {
+	ext := "jar"
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		ext = name[i+1:]
+		name = name[:i]
+	}
+	parts := strings.Split(name, ":")
+	if len(parts) < 3 {
+		return "", false
+	}
+	group, artifact, version := strings.ReplaceAll(parts[0], ".", "/"), parts[1], parts[2]
+	file := artifact + "-" + version
+	if len(parts) > 3 && parts[3] != "" {
+		file += "-" + parts[3]
+	}
+	return fmt.Sprintf("%s/%s/%s/%s.%s", group, artifact, version, file, ext), true
+}
+
+func mojangOSName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "osx"
+	case "windows":
+		return "windows"
+	default:
+		return "linux"
+	}
+}
+
+// rulesAllow evaluates library rules the way the vanilla launcher does (last match wins)
+func rulesAllow(rules []versionRule) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	allowed := false
+	for _, r := range rules {
+		// feature rules only apply to arguments
+		if len(r.Features) > 0 {
+			continue
+		}
+		if r.OS != nil {
+			if r.OS.Name != "" && r.OS.Name != mojangOSName() {
+				continue
+			}
+			if r.OS.Arch == "x86" && runtime.GOARCH != "386" {
+				continue
+			}
+		}
+		allowed = r.Action == "allow"
+	}
+	return allowed
+}
+
+func chainAssetIndex(chain []*versionJSON) *versionAssetIndex {
+	for _, v := range chain {
+		if v.AssetIndex != nil && v.AssetIndex.ID != "" {
+			return v.AssetIndex
+		}
+	}
+	return nil
+}
+
+// readAssetObjects parses an asset index and returns a constructor for object targets
+func readAssetObjects(indexPath string) (func(assetsDir string) []verifyTarget, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	var idx assetIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+	for name, o := range idx.Objects {
+		if !assetHashPattern.MatchString(o.Hash) {
+			return nil, fmt.Errorf("invalid asset index: object %q has invalid hash %q", name, o.Hash)
+		}
+	}
+	return func(assetsDir string) []verifyTarget {
+		seen := make(map[string]bool, len(idx.Objects))
+		targets := make([]verifyTarget, 0, len(idx.Objects))
+		for _, o := range idx.Objects {
+			if seen[o.Hash] {
+				continue
+			}
+			seen[o.Hash] = true
+			targets = append(targets, verifyTarget{
+				Path: filepath.Join(assetsDir, "objects", o.Hash[:2], o.Hash),
+				Kind: "asset",
+				SHA1: o.Hash,
+				Size: o.Size,
+				URL:  fmt.Sprintf("%s/%s/%s", defaultAssetsBaseURL, o.Hash[:2], o.Hash),
+			})
+		}
+		return targets
+	}, nil
+}
+
+// checkTarget returns an issue if the file is missing or does not match its size/sha1
+func checkTarget(t verifyTarget) *pb.InstanceFileIssue {
+	issue := &pb.InstanceFileIssue{
+		Path:         t.Path,
+		Kind:         t.Kind,
+		ExpectedSha1: t.SHA1,
+		ExpectedSize: t.Size,
+		Url:          t.URL,
+		Repairable:   t.URL != "",
+	}
+	fi, err := os.Stat(t.Path)
+	if os.IsNotExist(err) {
+		issue.Problem = "missing"
+		return issue
+	}
+	if err != nil || fi.IsDir() {
+		issue.Problem = "unreadable"
+		return issue
+	}
+	issue.ActualSize = fi.Size()
+	if t.Size > 0 && fi.Size() != t.Size {
+		issue.Problem = "size_mismatch"
+		return issue
+	}
+	if t.SHA1 == "" {
+		return nil
+	}
+	got, err := computeSha1(t.Path)
+	if err != nil {
+		issue.Problem = "unreadable"
+		return issue
+	}
+	if !strings.EqualFold(got, t.SHA1) {
+		issue.Problem = "hash_mismatch"
+		issue.ActualSha1 = got
+		return issue
+	}
+	return nil
+}
+
+// profileVersionID derives the launched version id from a profile's game/loader versions
+func profileVersionID(p *domain.Profile) string {
+	gv, lv := p.GameVersion, p.LoaderVersion
+	switch strings.ToLower(p.LoaderType) {
+	case "fabric":
+		return fmt.Sprintf("fabric-loader-%s-%s", lv, gv)
+	case "quilt":
+		return fmt.Sprintf("quilt-loader-%s-%s", lv, gv)
+	case "neoforge":
+		return fmt.Sprintf("neoforge-%s", lv)
+	case "forge":
+		return forgeVersionID(lv)
+	default:
+		return gv
+	}
+}
+
+// relPath shortens a path for progress display
+func relPath(sharedDir, inst, path string) string {
+	for _, base := range []string{sharedDir, inst} {
+		if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
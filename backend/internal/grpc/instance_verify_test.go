@@ -0,0 +1,205 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/db"
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/profile"
+	"hyenimc/backend/internal/services"
+
+	grpclib "google.golang.org/grpc"
+)
+
+// verifyStream records the progress messages of a VerifyInstance call
+type verifyStream struct {
+	grpclib.ServerStream
+	ctx    context.Context
+	events []*pb.VerifyInstanceProgress
+}
+
+func (s *verifyStream) Context() context.Context { return s.ctx }
+
+func (s *verifyStream) Send(p *pb.VerifyInstanceProgress) error {
+	s.events = append(s.events, p)
+	return nil
+}
+
+// redirectTransport sends every outbound request to target, whatever its host
+type redirectTransport struct{ target *url.URL }
+
+func (rt redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host, r.Host = rt.target.Scheme, rt.target.Host, ""
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// verifyFixture is a profile with a custom game directory whose instance has a
+// truncated local library, a corrupt asset and a missing natives jar
+type verifyFixture struct {
+	server    *instanceServiceServer
+	profileID string
+	gameDir   string
+	sharedDir string
+	library   string // truncated copy in the instance
+	asset     string
+	natives   string
+}
+
+func newVerifyFixture(t *testing.T) *verifyFixture {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	prev := outboundClient
+	outboundClient = &http.Client{Transport: redirectTransport{target}}
+	t.Cleanup(func() { outboundClient = prev })
+
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "app", "data")
+	os.MkdirAll(dataDir, 0700)
+	conn, err := db.Open(filepath.Join(dataDir, "hyenimc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	profiles := services.NewProfileService(profile.NewRepository(conn), dataDir)
+
+	f := &verifyFixture{
+		server:    &instanceServiceServer{profiles: profiles, downloads: newDownloadServiceServer()},
+		gameDir:   filepath.Join(root, "games", "survival"),
+		sharedDir: profiles.SharedDir(),
+	}
+	p, err := profiles.ImportProfile(context.Background(), &domain.Profile{
+		Name: "Survival", GameVersion: "1.21.1", LoaderType: "vanilla", GameDirectory: f.gameDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.profileID = p.ID
+
+	hash := sha1Hex(payload)
+	artifact := func(path string) map[string]any {
+		return map[string]any{"path": path, "sha1": hash, "size": len(payload), "url": srv.URL + "/" + path}
+	}
+	index := fmt.Sprintf(`{"objects": {"minecraft/sounds/click.ogg": {"hash": %q, "size": %d}}}`, hash, len(payload))
+	version := map[string]any{
+		"id":         "1.21.1",
+		"downloads":  map[string]any{"client": artifact("client.jar")},
+		"assetIndex": map[string]any{"id": "17", "sha1": sha1Hex(index), "size": len(index), "url": srv.URL + "/17.json"},
+		"libraries": []map[string]any{
+			{"name": "com.example:shared:1.0", "downloads": map[string]any{"artifact": artifact("com/example/shared/1.0/shared-1.0.jar")}},
+			{"name": "com.example:local:1.0", "downloads": map[string]any{"artifact": artifact("com/example/local/1.0/local-1.0.jar")}},
+			{
+				"name":    "org.lwjgl:lwjgl:3.3.3",
+				"natives": map[string]string{"linux": "natives-linux", "osx": "natives-osx", "windows": "natives-windows"},
+				"downloads": map[string]any{"classifiers": map[string]any{
+					"natives-linux":   artifact("org/lwjgl/lwjgl/3.3.3/lwjgl-3.3.3-natives-linux.jar"),
+					"natives-osx":     artifact("org/lwjgl/lwjgl/3.3.3/lwjgl-3.3.3-natives-osx.jar"),
+					"natives-windows": artifact("org/lwjgl/lwjgl/3.3.3/lwjgl-3.3.3-natives-windows.jar"),
+				}},
+			},
+		},
+	}
+	data, _ := json.Marshal(version)
+
+	libraries := filepath.Join(f.sharedDir, "libraries")
+	f.library = filepath.Join(f.gameDir, "libraries", "com", "example", "local", "1.0", "local-1.0.jar")
+	f.asset = filepath.Join(f.sharedDir, "assets", "objects", hash[:2], hash)
+	f.natives = filepath.Join(libraries, "org", "lwjgl", "lwjgl", "3.3.3", "lwjgl-3.3.3-natives-"+mojangOSName()+".jar")
+	for path, content := range map[string]string{
+		filepath.Join(f.gameDir, "versions", "1.21.1", "1.21.1.json"):                 string(data),
+		filepath.Join(f.gameDir, "versions", "1.21.1", "1.21.1.jar"):                  payload,
+		filepath.Join(f.sharedDir, "assets", "indexes", "17.json"):                    index,
+		filepath.Join(libraries, "com", "example", "shared", "1.0", "shared-1.0.jar"): payload,
+		// The launcher loads the instance copy, so the intact shared one does not help
+		filepath.Join(libraries, "com", "example", "local", "1.0", "local-1.0.jar"): payload,
+		f.library: payload[:5],
+		f.asset:   "corrupt asset, same size", // same length as payload
+	} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func (f *verifyFixture) verify(t *testing.T, repair bool) *pb.VerifyInstanceProgress {
+	t.Helper()
+	stream := &verifyStream{ctx: context.Background()}
+	if err := f.server.VerifyInstance(&pb.VerifyInstanceRequest{ProfileId: f.profileID, Repair: repair}, stream); err != nil {
+		t.Fatal(err)
+	}
+	last := stream.events[len(stream.events)-1]
+	if !last.Done {
+		t.Fatalf("last event %+v", last)
+	}
+	return last
+}
+
+// issuesByPath returns the problem of every reported issue, keyed by path
+func issuesByPath(done *pb.VerifyInstanceProgress) map[string]string {
+	issues := map[string]string{}
+	for _, issue := range done.Issues {
+		issues[issue.Path] = issue.Problem
+	}
+	return issues
+}
+
+func TestVerifyInstanceReportsBrokenFiles(t *testing.T) {
+	f := newVerifyFixture(t)
+	done := f.verify(t, false)
+
+	want := map[string]string{f.library: "size_mismatch", f.asset: "hash_mismatch", f.natives: "missing"}
+	if got := issuesByPath(done); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("issues %v, want %v", got, want)
+	}
+	if done.Checked != 5 || done.Repaired != 0 {
+		t.Errorf("checked %d, repaired %d", done.Checked, done.Repaired)
+	}
+	if data, _ := os.ReadFile(f.library); string(data) != payload[:5] {
+		t.Error("verify-only changed the library")
+	}
+	if _, err := os.Stat(f.natives); !os.IsNotExist(err) {
+		t.Errorf("verify-only downloaded natives: %v", err)
+	}
+}
+
+func TestVerifyInstanceRepairsBrokenFiles(t *testing.T) {
+	f := newVerifyFixture(t)
+	done := f.verify(t, true)
+	if done.Repaired != 3 {
+		t.Errorf("repaired %d, issues %v", done.Repaired, done.Issues)
+	}
+	for _, path := range []string{f.library, f.asset, f.natives} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != payload {
+			t.Errorf("%s after repair: %q, %v", path, data, err)
+		}
+	}
+
+	if again := f.verify(t, false); len(again.Issues) != 0 {
+		t.Errorf("issues after repair: %v", issuesByPath(again))
+	}
+}
+
+func TestReadAssetObjectsRejectsInvalidHashes(t *testing.T) {
+	for _, hash := range []string{"ab", "../../../../etc/passwd", "ABCDEF0123456789ABCDEF0123456789ABCDEF01"} {
+		path := filepath.Join(t.TempDir(), "17.json")
+		os.WriteFile(path, []byte(fmt.Sprintf(`{"objects": {"a": {"hash": %q, "size": 1}}}`, hash)), 0644)
+		if _, err := readAssetObjects(path); err == nil {
+			t.Errorf("%q: expected an error", hash)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// socketName is the default Unix socket inside the data directory
+const socketName = "grpc.sock"
+
+// listen opens the gRPC listener and returns the address clients dial.
+//
+// addr is a TCP address (127.0.0.1:0 when empty) or "unix:" followed by a socket
+// path; a bare "unix:" uses <dataDir>/grpc.sock. The socket is owner-only, so other
+// users cannot connect at all. Windows 10 1803 and later support Unix sockets
+// natively, which stand in for named pipes there.
+func listen(addr, dataDir string) (net.Listener, string, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, "", err
+		}
+		return lis, lis.Addr().String(), nil
+	}
+
+	path = strings.TrimPrefix(path, "//")
+	if path == "" {
+		path = filepath.Join(dataDir, socketName)
+	}
+	// A socket left by a crashed run blocks the bind; never follow anything else there
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, "", fmt.Errorf("%s exists and is not a socket", path)
+		}
+		os.Remove(path)
+	}
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, "", err
+	}
+	// The data directory is 0700, so nobody else can connect before this tightens the socket itself
+	if err := os.Chmod(path, 0600); err != nil {
+		lis.Close()
+		return nil, "", fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return lis, "unix:" + path, nil
+}
@@ -55,7 +55,7 @@ func downloadLibrary(ctx context.Context, baseURL, groupPath, artifact, version,
     defer cancel()
     req, err := http.NewRequestWithContext(cctx, http.MethodGet, url, nil)
     if err != nil { return err }
-    resp, err := http.DefaultClient.Do(req)
+    resp, err := outboundClient.Do(req)
     if err != nil { return err }
     defer resp.Body.Close()
     if resp.StatusCode != http.StatusOK { return fmt.Errorf("library status: %s", resp.Status) }
@@ -130,7 +130,7 @@ func (s *loaderServiceServer) fetchNeoForge(ctx context.Context, gameVersion str
     url := "https://maven.neoforged.net/api/maven/versions/releases/net/neoforged/neoforge"
     req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
     if err != nil { return nil, status.Errorf(codes.Internal, "build request: %v", err) }
-    resp, err := http.DefaultClient.Do(req)
+    resp, err := outboundClient.Do(req)
     if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch neoforge: %v", err) }
     defer resp.Body.Close()
     if resp.StatusCode != http.StatusOK { return nil, status.Errorf(codes.Unavailable, "neoforge status: %s", resp.Status) }
@@ -144,7 +144,7 @@ func (s *loaderServiceServer) fetchNeoForge(ctx context.Context, gameVersion str
         // re-issue request because body is consumed
         r2, err2 := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
         if err2 != nil { return nil, status.Errorf(codes.Internal, "build request2: %v", err2) }
-        resp2, err2 := http.DefaultClient.Do(r2)
+        resp2, err2 := outboundClient.Do(r2)
         if err2 != nil { return nil, status.Errorf(codes.Unavailable, "fetch neoforge2: %v", err2) }
         defer resp2.Body.Close()
         if resp2.StatusCode != http.StatusOK { return nil, status.Errorf(codes.Unavailable, "neoforge status2: %s", resp2.Status) }
@@ -215,7 +215,7 @@ func forgeVersionID(forgeVersion string) string {
 func (s *loaderServiceServer) fetchForge(ctx context.Context, gameVersion string) ([]*pb.LoaderVersion, error) {
     req, err := http.NewRequestWithContext(ctx, http.MethodGet, forgeMavenMetadata, nil)
     if err != nil { return nil, status.Errorf(codes.Internal, "build request: %v", err) }
-    resp, err := http.DefaultClient.Do(req)
+    resp, err := outboundClient.Do(req)
     if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch forge: %v", err) }
     defer resp.Body.Close()
     if resp.StatusCode != http.StatusOK { return nil, status.Errorf(codes.Unavailable, "forge status: %s", resp.Status) }
@@ -310,7 +310,7 @@ func (s *loaderServiceServer) fetchFabric(ctx context.Context, gameVersion strin
     url := fmt.Sprintf("https://meta.fabricmc.net/v2/versions/loader/%s", gameVersion)
     req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
     if err != nil { return nil, status.Errorf(codes.Internal, "build request: %v", err) }
-    resp, err := http.DefaultClient.Do(req)
+    resp, err := outboundClient.Do(req)
     if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch fabric: %v", err) }
     defer resp.Body.Close()
     if resp.StatusCode != http.StatusOK { return nil, status.Errorf(codes.Unavailable, "fabric status: %s", resp.Status) }
@@ -329,7 +329,7 @@ func (s *loaderServiceServer) fetchQuilt(ctx context.Context, gameVersion string
     url := fmt.Sprintf("https://meta.quiltmc.org/v3/versions/loader/%s", gameVersion)
     req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
     if err != nil { return nil, status.Errorf(codes.Internal, "build request: %v", err) }
-    resp, err := http.DefaultClient.Do(req)
+    resp, err := outboundClient.Do(req)
     if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch quilt: %v", err) }
     defer resp.Body.Close()
     if resp.StatusCode != http.StatusOK { return nil, status.Errorf(codes.Unavailable, "quilt status: %s", resp.Status) }
@@ -529,7 +529,7 @@ func (s *loaderServiceServer) Install(ctx context.Context, req *pb.InstallReques
         defer cancel()
         reqHttp, err := http.NewRequestWithContext(cctx, http.MethodGet, url, nil)
         if err != nil { return nil, status.Errorf(codes.Internal, "profile request: %v", err) }
-        resp, err := http.DefaultClient.Do(reqHttp)
+        resp, err := outboundClient.Do(reqHttp)
         if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch profile: %v", err) }
         defer resp.Body.Close()
         if resp.StatusCode != http.StatusOK {
@@ -580,7 +580,7 @@ func (s *loaderServiceServer) Install(ctx context.Context, req *pb.InstallReques
             defer cancel()
             reqHttp, err := http.NewRequestWithContext(cctx, http.MethodGet, installerURL, nil)
             if err != nil { return nil, status.Errorf(codes.Internal, "installer request: %v", err) }
-            resp, err := http.DefaultClient.Do(reqHttp)
+            resp, err := outboundClient.Do(reqHttp)
             if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch installer: %v", err) }
             defer resp.Body.Close()
             if resp.StatusCode != http.StatusOK { return nil, status.Errorf(codes.Unavailable, "installer status: %s", resp.Status) }
@@ -635,7 +635,7 @@ func (s *loaderServiceServer) Install(ctx context.Context, req *pb.InstallReques
         defer cancel()
         reqHttp, err := http.NewRequestWithContext(cctx, http.MethodGet, url, nil)
         if err != nil { return nil, status.Errorf(codes.Internal, "profile request: %v", err) }
-        resp, err := http.DefaultClient.Do(reqHttp)
+        resp, err := outboundClient.Do(reqHttp)
         if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch profile: %v", err) }
         defer resp.Body.Close()
         if resp.StatusCode != http.StatusOK {
@@ -687,7 +687,7 @@ func (s *loaderServiceServer) Install(ctx context.Context, req *pb.InstallReques
             defer cancel()
             reqHttp, err := http.NewRequestWithContext(cctx, http.MethodGet, installerURL, nil)
             if err != nil { return nil, status.Errorf(codes.Internal, "installer request: %v", err) }
-            resp, err := http.DefaultClient.Do(reqHttp)
+            resp, err := outboundClient.Do(reqHttp)
             if err != nil { return nil, status.Errorf(codes.Unavailable, "fetch installer: %v", err) }
             defer resp.Body.Close()
             if resp.StatusCode != http.StatusOK { return nil, status.Errorf(codes.Unavailable, "installer status: %s", resp.Status) }
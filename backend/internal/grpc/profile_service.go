@@ -2,10 +2,15 @@ package grpc
 
 import (
 	"context"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "hyenimc/backend/gen/launcher"
 	"hyenimc/backend/internal/cache"
 	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/profile"
 	"hyenimc/backend/internal/services"
 )
 
@@ -14,13 +19,17 @@ type profileServiceServer struct {
 	pb.UnimplementedProfileServiceServer
 	service   *services.ProfileService
 	statsRepo *cache.ProfileStatsRepository
+	cloner    *services.ProfileCloneService
+	servers   *services.ServerListService
 }
 
 // NewProfileServiceServer creates a new protobuf server backed by internal service
-func NewProfileServiceServer(svc *services.ProfileService, statsRepo *cache.ProfileStatsRepository) pb.ProfileServiceServer {
+func NewProfileServiceServer(svc *services.ProfileService, statsRepo *cache.ProfileStatsRepository, cloner *services.ProfileCloneService, servers *services.ServerListService) pb.ProfileServiceServer {
 	return &profileServiceServer{
 		service:   svc,
 		statsRepo: statsRepo,
+		cloner:    cloner,
+		servers:   servers,
 	}
 }
 
@@ -45,34 +54,130 @@ func (s *profileServiceServer) GetProfile(ctx context.Context, req *pb.GetProfil
 		return nil, err
 	}
 
-	// Get stats and update total play time
-	pbProfile := toPbProfile(p)
-	if stats, err := s.statsRepo.Get(p.ID); err == nil && stats != nil {
-		pbProfile.TotalPlayTime = stats.TotalPlayTime
-	}
-
-	return pbProfile, nil
+	s.applyStats(p)
+	return toPbProfile(p), nil
 }
 
-func (s *profileServiceServer) ListProfiles(ctx context.Context, _ *pb.ListProfilesRequest) (*pb.ListProfilesResponse, error) {
+func (s *profileServiceServer) ListProfiles(ctx context.Context, req *pb.ListProfilesRequest) (*pb.ListProfilesResponse, error) {
 	list, err := s.service.ListProfiles(ctx)
 	if err != nil {
 		return nil, err
 	}
+
+	// Launch statistics first, so play time and last played can be sorted on
+	for _, p := range list {
+		s.applyStats(p)
+	}
+
+	list, err = s.service.FilterProfiles(list, services.ProfileQuery{
+		Search:             req.GetSearch(),
+		LoaderType:         req.GetLoaderType(),
+		GameVersion:        req.GetGameVersion(),
+		Tags:               req.GetTags(),
+		GroupID:            req.GetGroupId(),
+		FavoritesOnly:      req.GetFavoritesOnly(),
+		InstallationStatus: req.GetInstallationStatus(),
+		Sort:               req.GetSort(),
+		Reverse:            req.GetReverse(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	res := &pb.ListProfilesResponse{Profiles: make([]*pb.Profile, 0, len(list))}
 	for _, p := range list {
-		pbProfile := toPbProfile(p)
+		res.Profiles = append(res.Profiles, toPbProfile(p))
+	}
+	return res, nil
+}
 
-		// Get stats and update total play time
-		if stats, err := s.statsRepo.Get(p.ID); err == nil && stats != nil {
-			pbProfile.TotalPlayTime = stats.TotalPlayTime
+// applyStats fills play time and last played from the launch statistics
+func (s *profileServiceServer) applyStats(p *domain.Profile) {
+	if stats, err := s.statsRepo.Get(p.ID); err == nil && stats != nil {
+		p.TotalPlayTime = stats.TotalPlayTime
+		if stats.LastLaunchedAt != nil && stats.LastLaunchedAt.After(p.LastPlayed) {
+			p.LastPlayed = *stats.LastLaunchedAt
 		}
+	}
+}
 
-		res.Profiles = append(res.Profiles, pbProfile)
+func (s *profileServiceServer) SetProfileTags(ctx context.Context, req *pb.SetProfileTagsRequest) (*pb.Profile, error) {
+	p, err := s.service.SetProfileTags(ctx, req.GetProfileId(), req.GetTags())
+	if err != nil {
+		return nil, err
+	}
+	return toPbProfile(p), nil
+}
+
+func (s *profileServiceServer) ListTags(ctx context.Context, _ *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	tags, err := s.service.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.ListTagsResponse{Tags: make([]*pb.TagCount, 0, len(tags))}
+	for _, t := range tags {
+		res.Tags = append(res.Tags, &pb.TagCount{Tag: t.Tag, Count: int32(t.Count)})
 	}
 	return res, nil
 }
 
+func (s *profileServiceServer) ListProfileGroups(ctx context.Context, _ *pb.ListProfileGroupsRequest) (*pb.ListProfileGroupsResponse, error) {
+	groups, err := s.service.ListGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toPbProfileGroups(groups), nil
+}
+
+func (s *profileServiceServer) CreateProfileGroup(ctx context.Context, req *pb.CreateProfileGroupRequest) (*pb.ProfileGroup, error) {
+	g, err := s.service.CreateGroup(ctx, req.GetName())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return toPbProfileGroup(g), nil
+}
+
+func (s *profileServiceServer) RenameProfileGroup(ctx context.Context, req *pb.RenameProfileGroupRequest) (*pb.ProfileGroup, error) {
+	g, err := s.service.RenameGroup(ctx, req.GetId(), req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return toPbProfileGroup(g), nil
+}
+
+func (s *profileServiceServer) DeleteProfileGroup(ctx context.Context, req *pb.DeleteProfileGroupRequest) (*pb.DeleteProfileGroupResponse, error) {
+	if err := s.service.DeleteGroup(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteProfileGroupResponse{Success: true}, nil
+}
+
+func (s *profileServiceServer) ReorderProfileGroups(ctx context.Context, req *pb.ReorderProfileGroupsRequest) (*pb.ListProfileGroupsResponse, error) {
+	groups, err := s.service.ReorderGroups(ctx, req.GetIds())
+	if err != nil {
+		return nil, err
+	}
+	return toPbProfileGroups(groups), nil
+}
+
+func (s *profileServiceServer) AssignProfileGroup(ctx context.Context, req *pb.AssignProfileGroupRequest) (*pb.Profile, error) {
+	p, err := s.service.AssignProfileGroup(ctx, req.GetProfileId(), req.GetGroupId())
+	if err != nil {
+		return nil, err
+	}
+	return toPbProfile(p), nil
+}
+
+func (s *profileServiceServer) ReorderGroupProfiles(ctx context.Context, req *pb.ReorderGroupProfilesRequest) (*pb.ReorderGroupProfilesResponse, error) {
+	if req.GetGroupId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "group_id is required")
+	}
+	if err := s.service.ReorderGroupProfiles(ctx, req.GetGroupId(), req.GetProfileIds()); err != nil {
+		return nil, err
+	}
+	return &pb.ReorderGroupProfilesResponse{Success: true}, nil
+}
+
 func (s *profileServiceServer) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.Profile, error) {
 	updates := map[string]interface{}{}
 	if patch := req.GetPatch(); patch != nil {
@@ -139,18 +244,99 @@ func (s *profileServiceServer) UpdateProfile(ctx context.Context, req *pb.Update
 		}
 	}
 
+	var oldAddress string
+	if old, err := s.service.GetProfile(ctx, req.GetId()); err == nil {
+		oldAddress = old.ServerAddress
+	}
+
 	p, err := s.service.UpdateProfile(ctx, req.GetId(), updates)
 	if err != nil {
 		return nil, err
 	}
+
+	// Keep the in-game server list in sync with a newly set server address
+	if p.ServerAddress != "" && p.ServerAddress != oldAddress {
+		if _, _, err := s.servers.PinProfileServer(ctx, p.ID, "", ""); err != nil {
+			log.Printf("[Profile] Failed to pin server %s for %s: %v", p.ServerAddress, p.ID, err)
+		}
+	}
 	return toPbProfile(p), nil
 }
 
 func (s *profileServiceServer) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest) (*pb.DeleteProfileResponse, error) {
-	if err := s.service.DeleteProfile(ctx, req.GetId()); err != nil {
+	entry, err := s.service.TrashProfile(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if !req.GetPermanent() {
+		return &pb.DeleteProfileResponse{Success: true, TrashId: entry.ID, TrashedBytes: entry.SizeBytes}, nil
+	}
+
+	result, err := s.service.PurgeTrash(ctx, entry.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteProfileResponse{Success: true, ReclaimedBytes: result.ReclaimedBytes}, nil
+}
+
+func (s *profileServiceServer) ListTrash(ctx context.Context, req *pb.ListTrashRequest) (*pb.ListTrashResponse, error) {
+	entries, err := s.service.ListTrash(ctx)
+	if err != nil {
 		return nil, err
 	}
-	return &pb.DeleteProfileResponse{Success: true}, nil
+	resp := &pb.ListTrashResponse{}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, &pb.TrashEntry{
+			Id:           e.ID,
+			ProfileId:    e.ProfileID,
+			Name:         e.Name,
+			GameVersion:  e.Profile.GameVersion,
+			LoaderType:   e.Profile.LoaderType,
+			OriginalPath: e.OriginalPath,
+			HasFiles:     e.TrashPath != "",
+			SizeBytes:    e.SizeBytes,
+			DeletedAt:    e.DeletedAt.Unix(),
+			ExpiresAt:    s.service.TrashExpiry(e).Unix(),
+		})
+	}
+	return resp, nil
+}
+
+func (s *profileServiceServer) RestoreProfile(ctx context.Context, req *pb.RestoreProfileRequest) (*pb.Profile, error) {
+	if req.GetTrashId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "trash_id is required")
+	}
+	p, err := s.service.RestoreProfile(ctx, req.GetTrashId())
+	if err != nil {
+		return nil, err
+	}
+	return toPbProfile(p), nil
+}
+
+func (s *profileServiceServer) PurgeTrash(ctx context.Context, req *pb.PurgeTrashRequest) (*pb.PurgeTrashResponse, error) {
+	result, err := s.service.PurgeTrash(ctx, req.GetTrashId(), req.GetAll())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PurgeTrashResponse{Purged: int32(result.Purged), ReclaimedBytes: result.ReclaimedBytes}, nil
+}
+
+func (s *profileServiceServer) CloneProfile(ctx context.Context, req *pb.CloneProfileRequest) (*pb.CloneProfileResponse, error) {
+	if req.GetSourceId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "source_id is required")
+	}
+	result, err := s.cloner.CloneProfile(ctx, req.GetSourceId(), services.CloneOptions{
+		Name:    req.GetName(),
+		Include: req.GetInclude(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CloneProfileResponse{
+		Profile:     toPbProfile(result.Profile),
+		FilesLinked: int32(result.Linked),
+		FilesCopied: int32(result.Copied),
+	}, nil
 }
 
 func toPbProfile(p *domain.Profile) *pb.Profile {
@@ -184,5 +370,26 @@ func toPbProfile(p *domain.Profile) *pb.Profile {
 		Favorite:           p.Favorite,
 		ServerAddress:      p.ServerAddress,
 		InstallationStatus: p.InstallationStatus,
+		Tags:               p.Tags,
+		GroupId:            p.GroupID,
+		GroupOrder:         int32(p.GroupOrder),
+	}
+}
+
+func toPbProfileGroup(g *profile.Group) *pb.ProfileGroup {
+	return &pb.ProfileGroup{
+		Id:           g.ID,
+		Name:         g.Name,
+		SortOrder:    int32(g.SortOrder),
+		ProfileCount: int32(g.ProfileCount),
+		CreatedAt:    g.CreatedAt.Unix(),
+	}
+}
+
+func toPbProfileGroups(groups []*profile.Group) *pb.ListProfileGroupsResponse {
+	res := &pb.ListProfileGroupsResponse{Groups: make([]*pb.ProfileGroup, 0, len(groups))}
+	for _, g := range groups {
+		res.Groups = append(res.Groups, toPbProfileGroup(g))
 	}
+	return res
 }
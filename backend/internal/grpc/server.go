@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"net"
 	"os"
 	"path/filepath"
 
@@ -12,37 +11,42 @@ import (
 	"hyenimc/backend/internal/cache"
 	"hyenimc/backend/internal/services"
 	"hyenimc/backend/internal/settings"
+	"hyenimc/backend/internal/snapshot"
 
 	grpclib "google.golang.org/grpc"
 )
 
-// StartGRPCServer starts the gRPC server, prints the chosen address to stdout, and serves forever.
-// If addr is empty, it will use 127.0.0.1:0 to pick a free port.
-func StartGRPCServer(addr string, db *sql.DB, dataDir string, profileSvc *services.ProfileService, settingsSvc *settings.Service, accountSvc *services.AccountService) error {
-	if addr == "" {
-		addr = "127.0.0.1:0"
+// StartGRPCServer starts the gRPC server, writes the chosen address to .grpc-port, and serves forever.
+// If addr is empty, it will use 127.0.0.1:0 to pick a free port; "unix:<path>" serves on a Unix socket.
+// Every call must carry auth.Token. Services are also registered with mirror when it is not nil
+// (the REST gateway, which has its own token).
+func StartGRPCServer(addr string, auth Auth, db *sql.DB, dataDir string, profileSvc *services.ProfileService, settingsSvc *settings.Service, accountSvc *services.AccountService, mirror grpclib.ServiceRegistrar) error {
+	if auth.Token == "" {
+		return fmt.Errorf("an auth token is required")
 	}
 
-	lis, err := net.Listen("tcp", addr)
+	// Security: Ensure parent directory exists and has proper permissions
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		log.Printf("Warning: failed to create data directory: %v", err)
+	}
+
+	lis, address, err := listen(addr, dataDir)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
 	// Write the chosen address to a file for Electron Main to read
-	address := lis.Addr().String()
 	portFile := filepath.Join(dataDir, ".grpc-port")
 	
-	// Security: Ensure parent directory exists and has proper permissions
-	if err := os.MkdirAll(dataDir, 0700); err != nil {
-		log.Printf("Warning: failed to create data directory: %v", err)
-	}
-	
 	// Security: Remove old port file if exists (prevent symlink attacks)
 	os.Remove(portFile)
 	
 	// Write with restrictive permissions (owner read/write only)
-	// Format: address|pid for process validation
+	// Format: address|pid for process validation, plus |token unless the launcher supplied it
 	content := fmt.Sprintf("%s|%d", address, os.Getpid())
+	if auth.Publish {
+		content += "|" + auth.Token
+	}
 	if err := os.WriteFile(portFile, []byte(content), 0600); err != nil {
 		return fmt.Errorf("failed to write port file: %w", err)
 	}
@@ -50,7 +54,7 @@ func StartGRPCServer(addr string, db *sql.DB, dataDir string, profileSvc *servic
 	// Log server start (all logs go to stderr now)
 	log.Printf("gRPC server listening on %s (port file: %s)", address, portFile)
 
-	server := grpclib.NewServer()
+	server := grpclib.NewServer(auth.ServerOptions()...)
 
 	// Get CurseForge API key from environment (optional)
 	curseforgeAPIKey := os.Getenv("CURSEFORGE_API_KEY")
@@ -60,22 +64,56 @@ func StartGRPCServer(addr string, db *sql.DB, dataDir string, profileSvc *servic
 
 	// Create profile stats repository
 	profileStatsRepo := cache.NewProfileStatsRepository(db)
+	profileCloneSvc := services.NewProfileCloneService(profileSvc,
+		cache.NewModRepository(db), cache.NewResourcePackRepository(db), cache.NewShaderPackRepository(db))
+	snapshotSvc := services.NewSnapshotService(profileSvc, snapshot.NewRepository(db), dataDir)
+	snapshotSvc.SetLimits(func() services.SnapshotLimits {
+		count, age := settingsSvc.SnapshotLimits()
+		return services.SnapshotLimits{MaxPerProfile: count, MaxAge: age}
+	})
+	serverListSvc := services.NewServerListService(profileSvc)
 	
+	// Settings first: other services read it through globalSettingsService
+	settingsServer := NewSettingsServiceServer(settingsSvc, profileSvc)
+
+	// Download service doubles as the progress event bus for other services
+	downloadSvc := newDownloadServiceServer()
+	downloadSvc.watchSettings(settingsSvc)
+	cacheSvc := newCacheServiceServer(db, curseforgeAPIKey)
+	cacheSvc.watchSettings(settingsSvc)
+
 	// Register services
-	pb.RegisterProfileServiceServer(server, NewProfileServiceServer(profileSvc, profileStatsRepo))
-	pb.RegisterDownloadServiceServer(server, NewDownloadServiceServer())
-	pb.RegisterInstanceServiceServer(server, NewInstanceServiceServer())
-	pb.RegisterVersionServiceServer(server, NewVersionServiceServer())
-	pb.RegisterHealthServiceServer(server, NewHealthServiceServer())
-	pb.RegisterLoaderServiceServer(server, NewLoaderServiceServer())
-	pb.RegisterAssetServiceServer(server, NewAssetServiceServer())
-	pb.RegisterSettingsServiceServer(server, NewSettingsServiceServer(settingsSvc))
-	pb.RegisterModServiceServer(server, NewModServiceServer(db, dataDir))
-	pb.RegisterCacheServiceServer(server, NewCacheServiceServer(db, curseforgeAPIKey))
-	pb.RegisterAccountServiceServer(server, NewAccountHandler(accountSvc))
+	var reg grpclib.ServiceRegistrar = server
+	if mirror != nil {
+		reg = teeRegistrar{server, mirror}
+	}
+	pb.RegisterProfileServiceServer(reg, NewProfileServiceServer(profileSvc, profileStatsRepo, profileCloneSvc, serverListSvc))
+	pb.RegisterSnapshotServiceServer(reg, NewSnapshotServiceServer(snapshotSvc))
+	pb.RegisterWorldServiceServer(reg, NewWorldServiceServer(services.NewWorldService(profileSvc)))
+	pb.RegisterServerListServiceServer(reg, NewServerListServiceServer(serverListSvc))
+	pb.RegisterServerServiceServer(reg, NewServerServiceServer(services.NewServerStatusService(profileSvc)))
+	pb.RegisterDownloadServiceServer(reg, downloadSvc)
+	pb.RegisterInstanceServiceServer(reg, NewInstanceServiceServer(profileSvc, downloadSvc))
+	pb.RegisterVersionServiceServer(reg, NewVersionServiceServer())
+	pb.RegisterHealthServiceServer(reg, NewHealthServiceServer())
+	pb.RegisterLoaderServiceServer(reg, NewLoaderServiceServer())
+	pb.RegisterAssetServiceServer(reg, NewAssetServiceServer(downloadSvc))
+	pb.RegisterSettingsServiceServer(reg, settingsServer)
+	pb.RegisterModServiceServer(reg, NewModServiceServer(db, dataDir))
+	pb.RegisterCacheServiceServer(reg, cacheSvc)
+	pb.RegisterAccountServiceServer(reg, NewAccountHandler(accountSvc))
 
 	if err := server.Serve(lis); err != nil {
 		return fmt.Errorf("failed to serve gRPC: %w", err)
 	}
 	return nil
 }
+
+// teeRegistrar registers every service with each of its registrars
+type teeRegistrar []grpclib.ServiceRegistrar
+
+func (t teeRegistrar) RegisterService(desc *grpclib.ServiceDesc, impl any) {
+	for _, r := range t {
+		r.RegisterService(desc, impl)
+	}
+}
@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/services"
+)
+
+// serverListServiceServer adapts ServerListService to protobuf ServerListServiceServer
+type serverListServiceServer struct {
+	pb.UnimplementedServerListServiceServer
+	service *services.ServerListService
+}
+
+// NewServerListServiceServer creates a new server list service server
+func NewServerListServiceServer(svc *services.ServerListService) pb.ServerListServiceServer {
+	return &serverListServiceServer{service: svc}
+}
+
+func (s *serverListServiceServer) GetServerList(ctx context.Context, req *pb.GetServerListRequest) (*pb.ServerList, error) {
+	servers, err := s.service.GetServers(ctx, req.GetProfileId())
+	if err != nil {
+		return nil, err
+	}
+	return toPbServerList(servers), nil
+}
+
+func (s *serverListServiceServer) SetServerList(ctx context.Context, req *pb.SetServerListRequest) (*pb.ServerList, error) {
+	entries := make([]*services.ServerEntry, 0, len(req.GetServers()))
+	for _, e := range req.GetServers() {
+		if e.GetAddress() == "" {
+			return nil, status.Error(codes.InvalidArgument, "every server needs an address")
+		}
+		entries = append(entries, &services.ServerEntry{
+			Name:           e.GetName(),
+			Address:        e.GetAddress(),
+			Icon:           e.GetIcon(),
+			AcceptTextures: e.GetAcceptTextures(),
+			Hidden:         e.GetHidden(),
+		})
+	}
+	servers, err := s.service.SetServers(ctx, req.GetProfileId(), entries)
+	if err != nil {
+		return nil, err
+	}
+	return toPbServerList(servers), nil
+}
+
+func (s *serverListServiceServer) PinProfileServer(ctx context.Context, req *pb.PinProfileServerRequest) (*pb.ServerList, error) {
+	servers, _, err := s.service.PinProfileServer(ctx, req.GetProfileId(), req.GetName(), req.GetIcon())
+	if err != nil {
+		return nil, err
+	}
+	return toPbServerList(servers), nil
+}
+
+func toPbServerList(servers []*services.ServerEntry) *pb.ServerList {
+	resp := &pb.ServerList{Pinned: len(servers) > 0 && servers[0].Pinned}
+	for _, e := range servers {
+		resp.Servers = append(resp.Servers, &pb.ServerEntry{
+			Name:           e.Name,
+			Address:        e.Address,
+			Icon:           e.Icon,
+			AcceptTextures: e.AcceptTextures,
+			Hidden:         e.Hidden,
+			Pinned:         e.Pinned,
+		})
+	}
+	return resp
+}
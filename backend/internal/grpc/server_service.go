@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/services"
+)
+
+// serverServiceServer adapts ServerStatusService to protobuf ServerServiceServer
+type serverServiceServer struct {
+	pb.UnimplementedServerServiceServer
+	service *services.ServerStatusService
+}
+
+// NewServerServiceServer creates a new server status service server
+func NewServerServiceServer(svc *services.ServerStatusService) pb.ServerServiceServer {
+	return &serverServiceServer{service: svc}
+}
+
+func (s *serverServiceServer) GetServerStatus(ctx context.Context, req *pb.GetServerStatusRequest) (*pb.ServerStatus, error) {
+	var result *services.ServerStatus
+	switch {
+	case req.GetAddress() != "":
+		result = s.service.GetServerStatus(ctx, req.GetAddress(), req.GetRefresh())
+	case req.GetProfileId() != "":
+		var err error
+		result, err = s.service.GetProfileServerStatus(ctx, req.GetProfileId(), req.GetRefresh())
+		if err != nil {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "address or profile_id is required")
+	}
+	return toPbServerStatus(result), nil
+}
+
+func toPbServerStatus(r *services.ServerStatus) *pb.ServerStatus {
+	resp := &pb.ServerStatus{
+		Address:  r.Address,
+		Online:   r.Online,
+		Error:    r.Error,
+		CachedAt: r.CachedAt.Unix(),
+	}
+	if st := r.Status; st != nil {
+		resp.ResolvedHost = st.Host
+		resp.ResolvedPort = int32(st.Port)
+		resp.Version = st.Version
+		resp.Protocol = int32(st.Protocol)
+		resp.PlayersOnline = int32(st.Online)
+		resp.PlayersMax = int32(st.Max)
+		resp.Motd = st.MOTD
+		resp.DescriptionJson = string(st.Description)
+		resp.Favicon = st.Favicon
+		resp.LatencyMs = st.Latency.Milliseconds()
+		resp.Legacy = st.Legacy
+		for _, p := range st.Sample {
+			resp.Sample = append(resp.Sample, &pb.ServerPlayer{Name: p.Name, Id: p.ID})
+		}
+	}
+	return resp
+}
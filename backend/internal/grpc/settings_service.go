@@ -2,9 +2,16 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/services"
 	settingssvc "hyenimc/backend/internal/settings"
 )
 
@@ -13,13 +20,19 @@ var globalSettingsService *settingssvc.Service
 
 type settingsServiceServer struct {
 	pb.UnimplementedSettingsServiceServer
-	service *settingssvc.Service
+	service  *settingssvc.Service
+	profiles *services.ProfileService
+	transfer *services.SettingsTransferService
 }
 
 // NewSettingsServiceServer creates a new settings service server
-func NewSettingsServiceServer(service *settingssvc.Service) pb.SettingsServiceServer {
+func NewSettingsServiceServer(service *settingssvc.Service, profiles *services.ProfileService) pb.SettingsServiceServer {
 	globalSettingsService = service // Store globally for other services
-	return &settingsServiceServer{service: service}
+	return &settingsServiceServer{
+		service:  service,
+		profiles: profiles,
+		transfer: services.NewSettingsTransferService(service, profiles),
+	}
 }
 
 func (s *settingsServiceServer) GetSettings(ctx context.Context, _ *pb.GetSettingsRequest) (*pb.GetSettingsResponse, error) {
@@ -29,30 +42,70 @@ func (s *settingsServiceServer) GetSettings(ctx context.Context, _ *pb.GetSettin
 		return nil, err
 	}
 
-	return &pb.GetSettingsResponse{
-		Settings: &pb.GlobalSettings{
-			Download: &pb.DownloadSettings{
-				RequestTimeoutMs: globalSettings.DownloadTimeoutMs,
-				MaxRetries:       globalSettings.DownloadMaxRetries,
-				MaxParallel:      globalSettings.DownloadMaxParallel,
-			},
-			Java: &pb.JavaSettings{
-				JavaPath:  globalSettings.JavaPath,
-				MemoryMin: globalSettings.MemoryMin,
-				MemoryMax: globalSettings.MemoryMax,
-			},
-			Resolution: &pb.ResolutionSettings{
-				Width:      globalSettings.ResolutionWidth,
-				Height:     globalSettings.ResolutionHeight,
-				Fullscreen: globalSettings.Fullscreen,
-			},
-			Cache: &pb.CacheSettings{
-				Enabled:   globalSettings.CacheEnabled,
-				MaxSizeGb: globalSettings.CacheMaxSizeGB,
-				TtlDays:   globalSettings.CacheTTLDays,
-			},
+	return &pb.GetSettingsResponse{Settings: toPbSettings(globalSettings)}, nil
+}
+
+// WatchSettings sends the current settings and then every change until the client goes away
+func (s *settingsServiceServer) WatchSettings(_ *pb.WatchSettingsRequest, stream pb.SettingsService_WatchSettingsServer) error {
+	changes, unsubscribe := s.service.Subscribe(16)
+	defer unsubscribe()
+
+	current, err := s.service.Get()
+	if err != nil {
+		log.Printf("[Settings] Failed to get settings: %v", err)
+		return err
+	}
+	if err := stream.Send(&pb.SettingsChangeEvent{Settings: toPbSettings(current), Timestamp: time.Now().UnixMilli()}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case change, ok := <-changes:
+			if !ok {
+				return nil
+			}
+			ev := &pb.SettingsChangeEvent{
+				ChangedKeys: change.Keys,
+				Settings:    toPbSettings(change.Settings),
+				Timestamp:   time.Now().UnixMilli(),
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toPbSettings(globalSettings *settingssvc.GlobalSettings) *pb.GlobalSettings {
+	return &pb.GlobalSettings{
+		Download: &pb.DownloadSettings{
+			RequestTimeoutMs: globalSettings.DownloadTimeoutMs,
+			MaxRetries:       globalSettings.DownloadMaxRetries,
+			MaxParallel:      globalSettings.DownloadMaxParallel,
+		},
+		Java: &pb.JavaSettings{
+			JavaPath:  globalSettings.JavaPath,
+			MemoryMin: globalSettings.MemoryMin,
+			MemoryMax: globalSettings.MemoryMax,
+		},
+		Resolution: &pb.ResolutionSettings{
+			Width:      globalSettings.ResolutionWidth,
+			Height:     globalSettings.ResolutionHeight,
+			Fullscreen: globalSettings.Fullscreen,
+		},
+		Cache: &pb.CacheSettings{
+			Enabled:   globalSettings.CacheEnabled,
+			MaxSizeGb: globalSettings.CacheMaxSizeGB,
+			TtlDays:   globalSettings.CacheTTLDays,
+		},
+		Update: &pb.UpdateSettings{
+			CheckIntervalHours: globalSettings.UpdateCheckIntervalHours,
+			AutoDownload:       globalSettings.UpdateAutoDownload,
 		},
-	}, nil
+	}
 }
 
 func (s *settingsServiceServer) UpdateSettings(ctx context.Context, in *pb.UpdateSettingsRequest) (*pb.UpdateSettingsResponse, error) {
@@ -62,26 +115,39 @@ func (s *settingsServiceServer) UpdateSettings(ctx context.Context, in *pb.Updat
 		return &pb.UpdateSettingsResponse{Ok: false}, nil
 	}
 
-	globalSettings := &settingssvc.GlobalSettings{
-		JavaPath:  pbSettings.Java.JavaPath,
-		MemoryMin: pbSettings.Java.MemoryMin,
-		MemoryMax: pbSettings.Java.MemoryMax,
-		
-		ResolutionWidth:  pbSettings.Resolution.Width,
-		ResolutionHeight: pbSettings.Resolution.Height,
-		Fullscreen:       pbSettings.Resolution.Fullscreen,
-		
-		DownloadTimeoutMs:  pbSettings.Download.RequestTimeoutMs,
-		DownloadMaxRetries: pbSettings.Download.MaxRetries,
-		DownloadMaxParallel: pbSettings.Download.MaxParallel,
-		
-		CacheEnabled:   pbSettings.Cache.Enabled,
-		CacheMaxSizeGB: pbSettings.Cache.MaxSizeGb,
-		CacheTTLDays:   pbSettings.Cache.TtlDays,
-	}
-
-	if err := s.service.Update(globalSettings); err != nil {
+	// Only sections present in the request are written
+	values := make(map[string]string)
+	if java := pbSettings.GetJava(); java != nil {
+		values[settingssvc.KeyJavaPath] = java.GetJavaPath()
+		values[settingssvc.KeyMemoryMin] = strconv.Itoa(int(java.GetMemoryMin()))
+		values[settingssvc.KeyMemoryMax] = strconv.Itoa(int(java.GetMemoryMax()))
+	}
+	if res := pbSettings.GetResolution(); res != nil {
+		values[settingssvc.KeyResolutionWidth] = strconv.Itoa(int(res.GetWidth()))
+		values[settingssvc.KeyResolutionHeight] = strconv.Itoa(int(res.GetHeight()))
+		values[settingssvc.KeyFullscreen] = strconv.FormatBool(res.GetFullscreen())
+	}
+	if dl := pbSettings.GetDownload(); dl != nil {
+		values[settingssvc.KeyDownloadTimeoutMs] = strconv.Itoa(int(dl.GetRequestTimeoutMs()))
+		values[settingssvc.KeyDownloadMaxRetries] = strconv.Itoa(int(dl.GetMaxRetries()))
+		values[settingssvc.KeyDownloadMaxParallel] = strconv.Itoa(int(dl.GetMaxParallel()))
+	}
+	if cache := pbSettings.GetCache(); cache != nil {
+		values[settingssvc.KeyCacheEnabled] = strconv.FormatBool(cache.GetEnabled())
+		values[settingssvc.KeyCacheMaxSizeGB] = strconv.Itoa(int(cache.GetMaxSizeGb()))
+		values[settingssvc.KeyCacheTTLDays] = strconv.Itoa(int(cache.GetTtlDays()))
+	}
+	if upd := pbSettings.GetUpdate(); upd != nil {
+		values[settingssvc.KeyUpdateCheckIntervalHours] = strconv.Itoa(int(upd.GetCheckIntervalHours()))
+		values[settingssvc.KeyUpdateAutoDownload] = strconv.FormatBool(upd.GetAutoDownload())
+	}
+
+	if err := s.service.SetValues(values, nil); err != nil {
 		log.Printf("[Settings] Failed to update settings: %v", err)
+		var verr *settingssvc.ValidationError
+		if errors.As(err, &verr) {
+			return &pb.UpdateSettingsResponse{Ok: false}, status.Error(codes.InvalidArgument, verr.Error())
+		}
 		return &pb.UpdateSettingsResponse{Ok: false}, err
 	}
 
@@ -89,6 +155,59 @@ func (s *settingsServiceServer) UpdateSettings(ctx context.Context, in *pb.Updat
 	return &pb.UpdateSettingsResponse{Ok: true}, nil
 }
 
+func (s *settingsServiceServer) GetSettingsSchema(ctx context.Context, _ *pb.GetSettingsSchemaRequest) (*pb.GetSettingsSchemaResponse, error) {
+	resp := &pb.GetSettingsSchemaResponse{}
+	for _, def := range settingssvc.Definitions() {
+		resp.Settings = append(resp.Settings, &pb.SettingDefinition{
+			Key:          def.Key,
+			Type:         def.Type,
+			DefaultValue: def.Default,
+			Min:          def.Min,
+			Max:          def.Max,
+			Options:      def.Options,
+			Description:  def.Description,
+		})
+	}
+	return resp, nil
+}
+
+func (s *settingsServiceServer) GetSettingValues(ctx context.Context, in *pb.GetSettingValuesRequest) (*pb.GetSettingValuesResponse, error) {
+	values, err := s.service.Values()
+	if err != nil {
+		log.Printf("[Settings] Failed to get setting values: %v", err)
+		return nil, err
+	}
+	if keys := in.GetKeys(); len(keys) > 0 {
+		filtered := make(map[string]string, len(keys))
+		for _, key := range keys {
+			if v, ok := values[key]; ok {
+				filtered[key] = v
+			}
+		}
+		values = filtered
+	}
+	return &pb.GetSettingValuesResponse{Values: values}, nil
+}
+
+// SetSettingValues reports validation failures in the response so the UI can
+// show them next to the offending fields
+func (s *settingsServiceServer) SetSettingValues(ctx context.Context, in *pb.SetSettingValuesRequest) (*pb.SetSettingValuesResponse, error) {
+	err := s.service.SetValues(in.GetValues(), in.GetResetKeys())
+	if err == nil {
+		return &pb.SetSettingValuesResponse{Ok: true}, nil
+	}
+	var verr *settingssvc.ValidationError
+	if !errors.As(err, &verr) {
+		log.Printf("[Settings] Failed to set setting values: %v", err)
+		return nil, err
+	}
+	resp := &pb.SetSettingValuesResponse{Ok: false}
+	for _, fe := range verr.Fields {
+		resp.Errors = append(resp.Errors, &pb.SettingError{Key: fe.Key, Message: fe.Message})
+	}
+	return resp, nil
+}
+
 func (s *settingsServiceServer) ResetCache(ctx context.Context, _ *pb.ResetCacheRequest) (*pb.UpdateSettingsResponse, error) {
 	if err := s.service.Reset(); err != nil {
 		log.Printf("[Settings] Failed to reset settings: %v", err)
@@ -99,6 +218,80 @@ func (s *settingsServiceServer) ResetCache(ctx context.Context, _ *pb.ResetCache
 	return &pb.UpdateSettingsResponse{Ok: true}, nil
 }
 
+func (s *settingsServiceServer) ExportSettings(ctx context.Context, in *pb.ExportSettingsRequest) (*pb.ExportSettingsResponse, error) {
+	if in.GetFilePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "file_path is required")
+	}
+	nSettings, nProfiles, err := s.transfer.ExportSettings(ctx, in.GetFilePath(), in.GetIncludeProfiles(), in.GetProfileIds())
+	if err != nil {
+		log.Printf("[Settings] Failed to export settings: %v", err)
+		return nil, err
+	}
+	return &pb.ExportSettingsResponse{Settings: int32(nSettings), Profiles: int32(nProfiles)}, nil
+}
+
+func (s *settingsServiceServer) ImportSettings(ctx context.Context, in *pb.ImportSettingsRequest) (*pb.ImportSettingsResponse, error) {
+	if in.GetFilePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "file_path is required")
+	}
+	result, err := s.transfer.ImportSettings(ctx, in.GetFilePath(), services.SettingsImportOptions{
+		DryRun:            in.GetDryRun(),
+		IncludeProfiles:   in.GetIncludeProfiles(),
+		OverwriteProfiles: in.GetOverwriteProfiles(),
+	})
+	if err != nil {
+		log.Printf("[Settings] Failed to import settings: %v", err)
+		return nil, err
+	}
+
+	resp := &pb.ImportSettingsResponse{Applied: result.Applied}
+	for _, d := range result.Settings {
+		resp.Settings = append(resp.Settings, &pb.SettingValueChange{Key: d.Key, OldValue: d.Old, NewValue: d.New})
+	}
+	for _, d := range result.Profiles {
+		resp.Profiles = append(resp.Profiles, &pb.ProfileImportChange{ProfileId: d.ID, Name: d.Name, Action: d.Action, Fields: d.Fields})
+	}
+	for _, fe := range result.Errors {
+		resp.Errors = append(resp.Errors, &pb.SettingError{Key: fe.Key, Message: fe.Message})
+	}
+	return resp, nil
+}
+
+func (s *settingsServiceServer) GetEffectiveSettings(ctx context.Context, in *pb.GetEffectiveSettingsRequest) (*pb.GetEffectiveSettingsResponse, error) {
+	var overrides *settingssvc.ProfileOverrides
+	var pack *settingssvc.PackRecommendations
+	if in.GetProfileId() != "" {
+		if s.profiles == nil {
+			return nil, status.Error(codes.Unavailable, "profile service not available")
+		}
+		p, err := s.profiles.GetProfile(ctx, in.GetProfileId())
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "profile: %v", err)
+		}
+		overrides = &settingssvc.ProfileOverrides{
+			JavaPath:         p.JavaPath,
+			MemoryMin:        p.Memory.Min,
+			MemoryMax:        p.Memory.Max,
+			ResolutionWidth:  p.Resolution.Width,
+			ResolutionHeight: p.Resolution.Height,
+			Fullscreen:       p.Fullscreen,
+		}
+		pack = services.LoadHyeniPackRecommendations(p.GameDirectory)
+	}
+
+	effective, err := s.service.Effective(overrides, pack)
+	if err != nil {
+		log.Printf("[Settings] Failed to resolve effective settings: %v", err)
+		return nil, err
+	}
+
+	resp := &pb.GetEffectiveSettingsResponse{}
+	for _, e := range effective {
+		resp.Settings = append(resp.Settings, &pb.EffectiveSetting{Key: e.Key, Value: e.Value, Origin: e.Origin})
+	}
+	return resp, nil
+}
+
 // currentDownloadSettings returns current download settings for use by other services
 func currentDownloadSettings() *pb.DownloadSettings {
 	if globalSettingsService == nil {
@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/services"
+	"hyenimc/backend/internal/snapshot"
+)
+
+// snapshotServiceServer adapts SnapshotService to protobuf SnapshotServiceServer
+type snapshotServiceServer struct {
+	pb.UnimplementedSnapshotServiceServer
+	service *services.SnapshotService
+}
+
+// NewSnapshotServiceServer creates a new snapshot service server
+func NewSnapshotServiceServer(svc *services.SnapshotService) pb.SnapshotServiceServer {
+	return &snapshotServiceServer{service: svc}
+}
+
+func (s *snapshotServiceServer) CreateSnapshot(ctx context.Context, req *pb.CreateSnapshotRequest) (*pb.Snapshot, error) {
+	if req.GetProfileId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "profile_id is required")
+	}
+	switch req.GetReason() {
+	case "", services.SnapshotReasonManual, services.SnapshotReasonHyeniPackUpdate, services.SnapshotReasonModUpdate:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown snapshot reason %q", req.GetReason())
+	}
+
+	snap, err := s.service.CreateSnapshot(ctx, req.GetProfileId(), services.SnapshotOptions{
+		Reason:       req.GetReason(),
+		Label:        req.GetLabel(),
+		IncludeSaves: req.GetIncludeSaves(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPbSnapshot(snap), nil
+}
+
+func (s *snapshotServiceServer) ListSnapshots(ctx context.Context, req *pb.ListSnapshotsRequest) (*pb.ListSnapshotsResponse, error) {
+	snaps, err := s.service.ListSnapshots(ctx, req.GetProfileId())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListSnapshotsResponse{}
+	for _, snap := range snaps {
+		resp.Snapshots = append(resp.Snapshots, toPbSnapshot(snap))
+	}
+	return resp, nil
+}
+
+func (s *snapshotServiceServer) DiffSnapshot(ctx context.Context, req *pb.DiffSnapshotRequest) (*pb.DiffSnapshotResponse, error) {
+	if req.GetSnapshotId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "snapshot_id is required")
+	}
+	changes, err := s.service.DiffSnapshot(ctx, req.GetSnapshotId(), req.GetAgainstId())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.DiffSnapshotResponse{}
+	for _, c := range changes {
+		resp.Changes = append(resp.Changes, &pb.SnapshotFileChange{
+			Path:    c.Path,
+			Change:  c.Change,
+			OldSize: c.OldSize,
+			NewSize: c.NewSize,
+		})
+	}
+	return resp, nil
+}
+
+func (s *snapshotServiceServer) RollbackSnapshot(ctx context.Context, req *pb.RollbackSnapshotRequest) (*pb.RollbackSnapshotResponse, error) {
+	if req.GetSnapshotId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "snapshot_id is required")
+	}
+	result, err := s.service.RollbackSnapshot(ctx, req.GetSnapshotId())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RollbackSnapshotResponse{
+		Backup:       toPbSnapshot(result.Backup),
+		FilesWritten: int32(result.Written),
+		FilesRemoved: int32(result.Removed),
+	}, nil
+}
+
+func (s *snapshotServiceServer) DeleteSnapshot(ctx context.Context, req *pb.DeleteSnapshotRequest) (*pb.DeleteSnapshotResponse, error) {
+	if err := s.service.DeleteSnapshot(ctx, req.GetSnapshotId()); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteSnapshotResponse{Success: true}, nil
+}
+
+func toPbSnapshot(snap *snapshot.Snapshot) *pb.Snapshot {
+	return &pb.Snapshot{
+		Id:           snap.ID,
+		ProfileId:    snap.ProfileID,
+		Reason:       snap.Reason,
+		Label:        snap.Label,
+		IncludeSaves: snap.IncludeSaves,
+		FileCount:    int32(snap.FileCount),
+		TotalBytes:   snap.TotalBytes,
+		StoredBytes:  snap.StoredBytes,
+		CreatedAt:    snap.CreatedAt.Unix(),
+	}
+}
@@ -48,7 +48,7 @@ func (s *versionServiceServer) fetchMinecraftVersions(ctx context.Context) ([]*p
 	if err != nil {
 		return nil, fmt.Errorf("build request: %w", err)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := outboundClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("fetch manifest: %w", err)
 	}
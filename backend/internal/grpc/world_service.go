@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "hyenimc/backend/gen/launcher"
+	"hyenimc/backend/internal/services"
+)
+
+// worldServiceServer adapts WorldService to protobuf WorldServiceServer
+type worldServiceServer struct {
+	pb.UnimplementedWorldServiceServer
+	service *services.WorldService
+}
+
+// NewWorldServiceServer creates a new world service server
+func NewWorldServiceServer(svc *services.WorldService) pb.WorldServiceServer {
+	return &worldServiceServer{service: svc}
+}
+
+func (s *worldServiceServer) ListWorlds(ctx context.Context, req *pb.ListWorldsRequest) (*pb.ListWorldsResponse, error) {
+	worlds, err := s.service.ListWorlds(ctx, req.GetProfileId())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListWorldsResponse{}
+	for _, w := range worlds {
+		resp.Worlds = append(resp.Worlds, toPbWorld(w))
+	}
+	return resp, nil
+}
+
+func (s *worldServiceServer) GetWorld(ctx context.Context, req *pb.GetWorldRequest) (*pb.World, error) {
+	w, err := s.service.GetWorld(ctx, req.GetProfileId(), req.GetFolderName())
+	if err != nil {
+		return nil, err
+	}
+	return toPbWorld(w), nil
+}
+
+func (s *worldServiceServer) BackupWorld(ctx context.Context, req *pb.BackupWorldRequest) (*pb.BackupWorldResponse, error) {
+	path, size, err := s.service.BackupWorld(ctx, req.GetProfileId(), req.GetFolderName(), req.GetDestPath())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BackupWorldResponse{Path: path, SizeBytes: size}, nil
+}
+
+func (s *worldServiceServer) RestoreWorld(ctx context.Context, req *pb.RestoreWorldRequest) (*pb.World, error) {
+	if req.GetArchivePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "archive_path is required")
+	}
+	w, err := s.service.RestoreWorld(ctx, req.GetProfileId(), req.GetArchivePath(), req.GetFolderName(), req.GetOverwrite())
+	if err != nil {
+		return nil, worldStatusError(err)
+	}
+	return toPbWorld(w), nil
+}
+
+func (s *worldServiceServer) DeleteWorld(ctx context.Context, req *pb.DeleteWorldRequest) (*pb.DeleteWorldResponse, error) {
+	// Refuse before backing up, so a running world leaves no stray backup behind
+	inUse, err := s.service.WorldInUse(ctx, req.GetProfileId(), req.GetFolderName())
+	if err != nil {
+		return nil, err
+	}
+	if inUse {
+		return nil, worldStatusError(services.ErrWorldInUse)
+	}
+
+	resp := &pb.DeleteWorldResponse{Success: true}
+	if req.GetBackup() {
+		path, _, err := s.service.BackupWorld(ctx, req.GetProfileId(), req.GetFolderName(), "")
+		if err != nil {
+			return nil, err
+		}
+		resp.BackupPath = path
+	}
+	if err := s.service.DeleteWorld(ctx, req.GetProfileId(), req.GetFolderName()); err != nil {
+		return nil, worldStatusError(err)
+	}
+	return resp, nil
+}
+
+func (s *worldServiceServer) CopyWorld(ctx context.Context, req *pb.CopyWorldRequest) (*pb.World, error) {
+	if req.GetSourceProfileId() == "" || req.GetTargetProfileId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "source_profile_id and target_profile_id are required")
+	}
+	w, err := s.service.CopyWorld(ctx, req.GetSourceProfileId(), req.GetFolderName(), req.GetTargetProfileId(), req.GetNewName())
+	if err != nil {
+		return nil, err
+	}
+	return toPbWorld(w), nil
+}
+
+// worldStatusError reports a world held by a running game as a failed precondition
+func worldStatusError(err error) error {
+	if errors.Is(err, services.ErrWorldInUse) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
+
+func toPbWorld(w *services.World) *pb.World {
+	var lastPlayed int64
+	if !w.LastPlayed.IsZero() {
+		lastPlayed = w.LastPlayed.Unix()
+	}
+	return &pb.World{
+		FolderName:  w.FolderName,
+		Name:        w.Name,
+		GameMode:    w.GameMode,
+		Hardcore:    w.Hardcore,
+		LastPlayed:  lastPlayed,
+		Version:     w.Version,
+		DataVersion: w.DataVersion,
+		SizeBytes:   w.SizeBytes,
+		HasIcon:     w.HasIcon,
+		Error:       w.Error,
+	}
+}
@@ -0,0 +1,351 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const maxRequestBody = 8 << 20
+
+// privateMethods never reach the REST surface: they hand out account secrets
+// that only the launcher, holding the gRPC token, may see
+var privateMethods = map[string]bool{
+	"launcher.AccountService/GetAccountTokens": true,
+	"launcher.AccountService/GetLaunchAuth":    true,
+	"launcher.AccountService/ExportAccounts":   true,
+}
+
+var (
+	unmarshalOptions = protojson.UnmarshalOptions{}
+	marshalOptions   = protojson.MarshalOptions{EmitUnpopulated: true}
+)
+
+// Gateway exposes registered gRPC services as JSON over HTTP:
+//
+//	POST /api/rpc/{Service}/{Method}  unary call, protojson request and response
+//	POST /api/rpc/{Service}/{Method}  server-streaming call, answered as Server-Sent Events
+//	GET  /api/rpc/{Service}/{Method}?request={json}  the same, for EventSource
+//	GET  /api/rpc                     lists services and methods
+//
+// Service is the full (launcher.ProfileService) or short (ProfileService) name.
+// It implements grpc.ServiceRegistrar so the same Register*Server calls that
+// populate the gRPC server populate it, keeping both APIs at parity except for
+// the methods returning account secrets, which stay gRPC-only.
+type Gateway struct {
+	mu       sync.RWMutex
+	services map[string]*gatewayService
+}
+
+type gatewayService struct {
+	name    string
+	impl    any
+	unary   map[string]grpc.MethodDesc
+	streams map[string]grpc.StreamDesc
+}
+
+// NewGateway creates an empty gateway
+func NewGateway() *Gateway {
+	return &Gateway{services: make(map[string]*gatewayService)}
+}
+
+// RegisterService implements grpc.ServiceRegistrar
+func (g *Gateway) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	svc := &gatewayService{
+		name:    desc.ServiceName,
+		impl:    impl,
+		unary:   make(map[string]grpc.MethodDesc, len(desc.Methods)),
+		streams: make(map[string]grpc.StreamDesc, len(desc.Streams)),
+	}
+	for _, m := range desc.Methods {
+		if !privateMethods[desc.ServiceName+"/"+m.MethodName] {
+			svc.unary[m.MethodName] = m
+		}
+	}
+	for _, s := range desc.Streams {
+		// Client streams cannot be expressed as a single JSON request
+		if s.ServerStreams && !s.ClientStreams && !privateMethods[desc.ServiceName+"/"+s.StreamName] {
+			svc.streams[s.StreamName] = s
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.services[desc.ServiceName] = svc
+	if i := strings.LastIndex(desc.ServiceName, "."); i >= 0 {
+		g.services[desc.ServiceName[i+1:]] = svc
+	}
+}
+
+func (g *Gateway) lookup(service string) *gatewayService {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.services[service]
+}
+
+// ServeHTTP routes /api/rpc requests
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/rpc"), "/")
+	if path == "" {
+		g.listMethods(w, r)
+		return
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		writeError(w, status.Error(codes.NotFound, "expected /api/rpc/{Service}/{Method}"))
+		return
+	}
+	svc := g.lookup(parts[0])
+	if svc == nil {
+		writeError(w, status.Errorf(codes.Unimplemented, "unknown service %s", parts[0]))
+		return
+	}
+
+	if m, ok := svc.unary[parts[1]]; ok {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		g.callUnary(w, r, svc, m)
+		return
+	}
+	if s, ok := svc.streams[parts[1]]; ok {
+		if r.Method != http.MethodPost && r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		g.callStream(w, r, svc, s)
+		return
+	}
+	writeError(w, status.Errorf(codes.Unimplemented, "unknown method %s/%s", svc.name, parts[1]))
+}
+
+func (g *Gateway) listMethods(w http.ResponseWriter, r *http.Request) {
+	type method struct {
+		Name      string `json:"name"`
+		Streaming bool   `json:"streaming"`
+	}
+	g.mu.RLock()
+	out := map[string][]method{}
+	for key, svc := range g.services {
+		if key != svc.name {
+			continue // short alias
+		}
+		var methods []method
+		for name := range svc.unary {
+			methods = append(methods, method{Name: name})
+		}
+		for name := range svc.streams {
+			methods = append(methods, method{Name: name, Streaming: true})
+		}
+		sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+		out[svc.name] = methods
+	}
+	g.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"services": out})
+}
+
+// requestBody returns the JSON request: the body, or the request query parameter for GET
+func requestBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	var data []byte
+	if r.Method == http.MethodGet {
+		data = []byte(r.URL.Query().Get("request"))
+	} else {
+		var err error
+		data, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to read request body: %v", err)
+		}
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		data = []byte("{}")
+	}
+	return data, nil
+}
+
+func decoder(data []byte) func(any) error {
+	return func(v any) error {
+		msg, ok := v.(proto.Message)
+		if !ok {
+			return status.Errorf(codes.Internal, "request type %T is not a protobuf message", v)
+		}
+		if err := unmarshalOptions.Unmarshal(data, msg); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		return nil
+	}
+}
+
+// rpcContext carries the HTTP request's cancellation and headers into the handler
+func rpcContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		switch k := strings.ToLower(key); k {
+		case "authorization", "cookie":
+		default:
+			md.Append(k, values...)
+		}
+	}
+	return metadata.NewIncomingContext(r.Context(), md)
+}
+
+func (g *Gateway) callUnary(w http.ResponseWriter, r *http.Request, svc *gatewayService, m grpc.MethodDesc) {
+	data, err := requestBody(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp, err := m.Handler(svc.impl, rpcContext(r), decoder(data), nil)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		writeError(w, status.Errorf(codes.Internal, "response type %T is not a protobuf message", resp))
+		return
+	}
+	out, err := marshalOptions.Marshal(msg)
+	if err != nil {
+		writeError(w, status.Errorf(codes.Internal, "failed to encode response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+func (g *Gateway) callStream(w http.ResponseWriter, r *http.Request, svc *gatewayService, s grpc.StreamDesc) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, status.Error(codes.Internal, "streaming is not supported by this connection"))
+		return
+	}
+	data, err := requestBody(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	stream := &sseStream{ctx: rpcContext(r), w: w, flusher: flusher, decode: decoder(data)}
+	err = s.Handler(svc.impl, stream)
+	if err != nil {
+		st := status.Convert(err)
+		if r.Context().Err() == nil {
+			log.Printf("[HTTP] Stream %s/%s ended with error: %v", svc.name, s.StreamName, err)
+		}
+		stream.event("error", map[string]any{"code": st.Code().String(), "message": st.Message()})
+		return
+	}
+	stream.event("end", map[string]any{})
+}
+
+// sseStream is a grpc.ServerStream writing each message as an SSE "message" event
+type sseStream struct {
+	ctx      context.Context
+	w        http.ResponseWriter
+	flusher  http.Flusher
+	decode   func(any) error
+	received bool
+	mu       sync.Mutex
+}
+
+func (s *sseStream) SetHeader(metadata.MD) error  { return nil }
+func (s *sseStream) SendHeader(metadata.MD) error { return nil }
+func (s *sseStream) SetTrailer(metadata.MD)       {}
+func (s *sseStream) Context() context.Context     { return s.ctx }
+
+func (s *sseStream) RecvMsg(m any) error {
+	if s.received {
+		return io.EOF
+	}
+	s.received = true
+	return s.decode(m)
+}
+
+func (s *sseStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "message type %T is not a protobuf message", m)
+	}
+	data, err := marshalOptions.Marshal(msg)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to encode message: %v", err)
+	}
+	return s.write("message", data)
+}
+
+func (s *sseStream) event(name string, v any) {
+	data, _ := json.Marshal(v)
+	s.write(name, data)
+}
+
+func (s *sseStream) write(event string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return status.Errorf(codes.Unavailable, "client went away: %v", err)
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// writeError writes a gRPC status as JSON with the matching HTTP status code
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(st.Code()))
+	json.NewEncoder(w).Encode(map[string]any{"code": st.Code().String(), "message": st.Message()})
+}
+
+// httpStatus maps gRPC codes as grpc-gateway does
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "hyenimc/backend/gen/launcher"
+)
+
+func TestGatewayHidesPrivateMethods(t *testing.T) {
+	g := NewGateway()
+	pb.RegisterAccountServiceServer(g, pb.UnimplementedAccountServiceServer{})
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/rpc", nil))
+	var list struct {
+		Services map[string][]struct{ Name string } `json:"services"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	listed := map[string]bool{}
+	for _, m := range list.Services["launcher.AccountService"] {
+		listed[m.Name] = true
+	}
+	if !listed["GetAllAccounts"] {
+		t.Errorf("public methods missing: %v", listed)
+	}
+
+	for key := range privateMethods {
+		method := key[strings.Index(key, "/")+1:]
+		if listed[method] {
+			t.Errorf("%s is listed", key)
+		}
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/rpc/AccountService/"+method, strings.NewReader("{}")))
+		if rec.Code != http.StatusNotImplemented || !strings.Contains(rec.Body.String(), "unknown method") {
+			t.Errorf("%s: %d %s", key, rec.Code, rec.Body)
+		}
+	}
+}
@@ -1,46 +1,161 @@
 package http
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"hyenimc/backend/internal/domain"
 	"hyenimc/backend/internal/services"
 )
 
-// Server represents the HTTP server
+// Origins allowed by default: the Tauri webview on each platform
+var DefaultAllowedOrigins = []string{"tauri://localhost", "http://tauri.localhost", "https://tauri.localhost"}
+
+// Server represents the HTTP server. Every route except /health needs the
+// per-session bearer token; browsers are only let in from allowed origins.
 type Server struct {
 	profileService *services.ProfileService
+	gateway        *Gateway
 	mux            *http.ServeMux
+	token          string
+	publishToken   bool // write token to .http-token on Start
+	allowedOrigins map[string]bool
 }
 
-// NewServer creates a new HTTP server
-func NewServer(profileService *services.ProfileService) *Server {
+// NewServer creates a new HTTP server. token is the session token the launcher
+// handed over; when empty a fresh one is generated and published in .http-token.
+// gateway may be nil to serve only the profile routes.
+func NewServer(profileService *services.ProfileService, gateway *Gateway, allowedOrigins []string, token string) (*Server, error) {
+	publish := token == ""
+	if publish {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate session token: %w", err)
+		}
+		token = hex.EncodeToString(buf)
+	}
 	s := &Server{
 		profileService: profileService,
+		gateway:        gateway,
 		mux:            http.NewServeMux(),
+		token:          token,
+		publishToken:   publish,
+		allowedOrigins: make(map[string]bool, len(allowedOrigins)),
+	}
+	for _, origin := range allowedOrigins {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" && origin != "*" {
+			s.allowedOrigins[origin] = true
+		}
 	}
 	s.setupRoutes()
-	return s
+	return s, nil
+}
+
+// Token returns the session token clients send as "Authorization: Bearer <token>"
+func (s *Server) Token() string {
+	return s.token
 }
 
 func (s *Server) setupRoutes() {
 	s.mux.HandleFunc("/api/profiles", s.handleProfiles)
 	s.mux.HandleFunc("/api/profiles/", s.handleProfile)
 	s.mux.HandleFunc("/health", s.handleHealth)
+	if s.gateway != nil {
+		s.mux.Handle("/api/rpc", s.gateway)
+		s.mux.Handle("/api/rpc/", s.gateway)
+	}
+}
+
+// Start listens on a loopback address (127.0.0.1:0 when addr is empty), writes
+// .http-port (address|pid) and, for a generated token, .http-token next to .grpc-port
+// in dataDir, and serves in the background.
+func (s *Server) Start(addr, dataDir string) (string, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if !isLoopbackHost(host) {
+		return "", fmt.Errorf("refusing to listen on non-loopback address %s", addr)
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	address := lis.Addr().String()
+
+	// Same rules as .grpc-port: replace, never follow, owner-only
+	portFile := filepath.Join(dataDir, ".http-port")
+	tokenFile := filepath.Join(dataDir, ".http-token")
+	os.Remove(portFile)
+	os.Remove(tokenFile)
+	if s.publishToken {
+		if err := os.WriteFile(tokenFile, []byte(s.token), 0600); err != nil {
+			lis.Close()
+			return "", fmt.Errorf("failed to write token file: %w", err)
+		}
+	}
+	if err := os.WriteFile(portFile, []byte(fmt.Sprintf("%s|%d", address, os.Getpid())), 0600); err != nil {
+		lis.Close()
+		return "", fmt.Errorf("failed to write port file: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		// No WriteTimeout: SSE streams stay open
+	}
+	go func() {
+		if err := srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+			log.Printf("[HTTP] Server stopped: %v", err)
+		}
+	}()
+	log.Printf("[HTTP] REST gateway listening on %s (port file: %s)", address, portFile)
+	return address, nil
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS for local development
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	// A loopback Host defeats DNS rebinding: pages on other hosts cannot reach us by name
+	if !isLoopbackHost(hostOnly(r.Host)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Browsers send Origin on cross-origin requests; only allowed origins get in,
+	// and only they get CORS headers. Scripts without an Origin rely on the token.
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !s.allowedOrigins[origin] {
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Add("Vary", "Origin")
+	}
 
 	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.URL.Path != "/health" && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="hyenimc"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
@@ -48,7 +163,33 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.mux.ServeHTTP(w, r)
 }
 
+// authorized checks the bearer token. EventSource cannot set headers, so GET
+// requests may pass it as the access_token query parameter instead.
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok && r.Method == http.MethodGet {
+		token, ok = r.URL.Query().Get("access_token"), true
+	}
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func hostOnly(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return strings.Trim(hostport, "[]")
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef"
+
+func TestServerTokenFile(t *testing.T) {
+	// A token handed over by the launcher never touches disk
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".http-token"), []byte("stale"), 0600)
+	s, err := NewServer(nil, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := s.Start("127.0.0.1:0", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".http-token")); !os.IsNotExist(err) {
+		t.Errorf(".http-token written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".http-port")); err != nil {
+		t.Errorf(".http-port: %v", err)
+	}
+	for token, want := range map[string]int{testToken: http.StatusNotFound, "wrong": http.StatusUnauthorized} {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/api/missing", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("token %q: status %d, want %d", token, resp.StatusCode, want)
+		}
+	}
+
+	// A generated token is published for development tools
+	dir = t.TempDir()
+	s, err = NewServer(nil, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("127.0.0.1:0", dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, ".http-token")); err != nil || string(data) != s.Token() {
+		t.Errorf(".http-token %q, %v", data, err)
+	}
+}
@@ -0,0 +1,242 @@
+// Package httpclient builds every outbound HTTP client of the backend so that
+// proxy, trust store, User-Agent and timeouts are configured in one place.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Proxy modes
+const (
+	ProxyModeSystem = "system" // HTTP_PROXY / HTTPS_PROXY / NO_PROXY environment variables
+	ProxyModeManual = "manual" // Config.ProxyURL
+	ProxyModeNone   = "none"   // always connect directly
+)
+
+// DefaultUserAgent is sent when a request does not set its own User-Agent
+const DefaultUserAgent = "HyeniMC/1.0"
+
+// Config describes how outbound connections are made
+type Config struct {
+	ProxyMode       string
+	ProxyURL        string   // http://, https://, socks5:// or socks5h:// (manual mode)
+	NoProxy         []string // hosts, domain suffixes (.example.com), IPs or CIDRs that bypass the proxy
+	CABundle        string   // PEM file trusted in addition to the system roots
+	UserAgent       string
+	ConnectTimeout  time.Duration // TCP connect and TLS handshake
+	ResponseTimeout time.Duration // waiting for response headers
+}
+
+// DefaultConfig returns the configuration used before settings are loaded
+func DefaultConfig() Config {
+	return Config{
+		ProxyMode:       ProxyModeSystem,
+		UserAgent:       DefaultUserAgent,
+		ConnectTimeout:  10 * time.Second,
+		ResponseTimeout: 30 * time.Second,
+	}
+}
+
+// Factory hands out clients sharing one transport. Reconfiguring swaps the
+// transport, so clients created earlier pick up the new settings.
+type Factory struct {
+	mu        sync.RWMutex
+	cfg       Config
+	transport *http.Transport
+}
+
+// NewFactory creates a factory with cfg
+func NewFactory(cfg Config) (*Factory, error) {
+	f := &Factory{}
+	if err := f.Configure(cfg); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Configure validates cfg and applies it to all clients of the factory
+func (f *Factory) Configure(cfg Config) error {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	old := f.transport
+	f.cfg = cfg
+	f.transport = transport
+	f.mu.Unlock()
+
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Config returns the active configuration
+func (f *Factory) Config() Config {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.cfg
+}
+
+// Client returns a client with the given overall timeout (0 = none)
+func (f *Factory) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: f, Timeout: timeout}
+}
+
+// RoundTrip implements http.RoundTripper using the current transport
+func (f *Factory) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.RLock()
+	transport, ua := f.transport, f.cfg.UserAgent
+	f.mu.RUnlock()
+
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", ua)
+	}
+	return transport.RoundTrip(req)
+}
+
+func newTransport(cfg Config) (*http.Transport, error) {
+	proxy, err := proxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		pool, err := LoadCABundle(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = DefaultConfig().ConnectTimeout
+	}
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: cfg.ResponseTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}, nil
+}
+
+// proxyFunc builds the transport proxy selector for cfg
+func proxyFunc(cfg Config) (func(*http.Request) (*url.URL, error), error) {
+	noProxy := strings.Join(cfg.NoProxy, ",")
+
+	var pc *httpproxy.Config
+	switch cfg.ProxyMode {
+	case ProxyModeNone:
+		return nil, nil
+	case ProxyModeManual:
+		if err := ValidateProxyURL(cfg.ProxyURL); err != nil {
+			return nil, err
+		}
+		pc = &httpproxy.Config{HTTPProxy: cfg.ProxyURL, HTTPSProxy: cfg.ProxyURL, NoProxy: noProxy}
+	case ProxyModeSystem, "":
+		pc = httpproxy.FromEnvironment()
+		if noProxy != "" {
+			if pc.NoProxy != "" {
+				noProxy = pc.NoProxy + "," + noProxy
+			}
+			pc.NoProxy = noProxy
+		}
+	default:
+		return nil, fmt.Errorf("unknown proxy mode %q", cfg.ProxyMode)
+	}
+
+	fn := pc.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return fn(req.URL)
+	}, nil
+}
+
+// ValidateProxyURL checks that raw is a usable proxy URL
+func ValidateProxyURL(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		return fmt.Errorf("proxy URL is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy URL has no host")
+	}
+	return nil
+}
+
+// LoadCABundle returns the system roots extended with the certificates in path
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultFactory *Factory
+)
+
+// Default returns the process-wide factory
+func Default() *Factory {
+	defaultOnce.Do(func() {
+		f, err := NewFactory(DefaultConfig())
+		if err != nil {
+			panic(err) // the default config is always valid
+		}
+		defaultFactory = f
+	})
+	return defaultFactory
+}
+
+// New returns a client of the process-wide factory with the given overall timeout (0 = none)
+func New(timeout time.Duration) *http.Client {
+	return Default().Client(timeout)
+}
+
+// Configure applies cfg to the process-wide factory
+func Configure(cfg Config) error {
+	return Default().Configure(cfg)
+}
@@ -0,0 +1,59 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileProvider stores keys as raw bytes in owner-only files inside a directory.
+// The account key keeps its legacy location (<dir>/.key).
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider creates a file based provider rooted at dir
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Name() string { return "file" }
+
+func (p *FileProvider) path(name string) string {
+	if name == AccountKeyName {
+		return filepath.Join(p.dir, ".key")
+	}
+	return filepath.Join(p.dir, "."+name+".key")
+}
+
+func (p *FileProvider) Get(name string) ([]byte, error) {
+	data, err := os.ReadFile(p.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (p *FileProvider) Set(name string, key []byte) error {
+	path := p.path(name)
+	tmp := path + ".tmp"
+	// Remove stale temp files first (prevent symlink attacks)
+	os.Remove(tmp)
+	if err := os.WriteFile(tmp, key, 0600); err != nil {
+		return fmt.Errorf("write key: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write key: %w", err)
+	}
+	return nil
+}
+
+func (p *FileProvider) Delete(name string) error {
+	err := os.Remove(p.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// KeySize is the size of the account encryption key (AES-256)
+const KeySize = 32
+
+// AccountKeyName is the name of the account encryption key in a provider
+const AccountKeyName = "account-encryption-key"
+
+// AccountKeyNameFor returns the provider name of an account key version ("" = original key)
+func AccountKeyNameFor(keyID string) string {
+	if keyID == "" {
+		return AccountKeyName
+	}
+	return AccountKeyName + "." + keyID
+}
+
+var (
+	// ErrNotFound is returned when a provider has no key with the given name
+	ErrNotFound = errors.New("key not found")
+	// ErrUnavailable is returned when the backing store cannot be used (no keyring daemon, etc.)
+	ErrUnavailable = errors.New("key store unavailable")
+	// ErrKeyUnreadable is returned when primary may hold the key but cannot be read.
+	// A new key would orphan everything encrypted with the stored one, so none is made.
+	ErrKeyUnreadable = errors.New("stored key cannot be read")
+)
+
+// Provider stores secret keys by name
+type Provider interface {
+	// Name identifies the provider in logs
+	Name() string
+	// Get returns the key stored under name, or ErrNotFound
+	Get(name string) ([]byte, error)
+	// Set stores key under name, replacing any previous value
+	Set(name string, key []byte) error
+	// Delete removes the key stored under name (missing keys are not an error)
+	Delete(name string) error
+}
+
+// LoadOrCreateKey returns the key stored under name.
+//
+// The key is kept in primary (usually the OS keyring) when it is available. A key found
+// only in fallback (the legacy .key file) is moved into primary and removed from
+// fallback. When primary is nil the key lives in fallback.
+//
+// A new key is only generated when neither store has one. If primary fails with
+// anything but ErrNotFound, or holds a malformed key, and fallback has no key
+// either, ErrKeyUnreadable is returned instead.
+func LoadOrCreateKey(primary, fallback Provider, name string) ([]byte, Provider, error) {
+	return loadKey(primary, fallback, name, true)
+}
+
+// LoadKey is LoadOrCreateKey for a key that stored data already references: it never
+// generates a key and returns ErrKeyUnreadable when neither store has one. A keyring
+// may report a dismissed unlock prompt as a missing key, so a miss proves nothing.
+func LoadKey(primary, fallback Provider, name string) ([]byte, Provider, error) {
+	return loadKey(primary, fallback, name, false)
+}
+
+func loadKey(primary, fallback Provider, name string, create bool) ([]byte, Provider, error) {
+	var primaryErr error
+	if primary != nil {
+		key, err := primary.Get(name)
+		switch {
+		case err == nil && len(key) == KeySize:
+			return key, primary, nil
+		case err == nil:
+			primaryErr = fmt.Errorf("invalid key in %s (%d bytes)", primary.Name(), len(key))
+		case errors.Is(err, ErrNotFound):
+		default:
+			primaryErr = fmt.Errorf("%s: %w", primary.Name(), err)
+		}
+		if primaryErr != nil {
+			log.Printf("[Keystore] %s not usable, trying %s: %v", primary.Name(), fallback.Name(), primaryErr)
+			primary = nil
+		}
+	}
+
+	legacy, err := fallback.Get(name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, nil, fmt.Errorf("read %s: %w", fallback.Name(), err)
+	}
+	if err == nil && len(legacy) != KeySize {
+		log.Printf("[Keystore] Ignoring invalid key in %s (%d bytes)", fallback.Name(), len(legacy))
+		legacy = nil
+	}
+
+	if legacy != nil {
+		if primary == nil {
+			return legacy, fallback, nil
+		}
+		if err := migrate(primary, fallback, name, legacy); err != nil {
+			log.Printf("[Keystore] Keeping key in %s: %v", fallback.Name(), err)
+			return legacy, fallback, nil
+		}
+		log.Printf("[Keystore] Moved key from %s to %s", fallback.Name(), primary.Name())
+		return legacy, primary, nil
+	}
+
+	if primaryErr != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %v", ErrKeyUnreadable, name, primaryErr)
+	}
+	if !create {
+		return nil, nil, fmt.Errorf("%w: %s not found", ErrKeyUnreadable, name)
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	if primary != nil {
+		if err := primary.Set(name, key); err == nil {
+			log.Printf("[Keystore] Generated new key in %s", primary.Name())
+			return key, primary, nil
+		} else {
+			log.Printf("[Keystore] Failed to store key in %s, using %s: %v", primary.Name(), fallback.Name(), err)
+		}
+	}
+	if err := fallback.Set(name, key); err != nil {
+		return nil, nil, fmt.Errorf("store key in %s: %w", fallback.Name(), err)
+	}
+	log.Printf("[Keystore] Generated new key in %s", fallback.Name())
+	return key, fallback, nil
+}
+
+// migrate copies key into dst, verifies it reads back intact and removes it from src
+func migrate(dst, src Provider, name string, key []byte) error {
+	if err := dst.Set(name, key); err != nil {
+		return err
+	}
+	got, err := dst.Get(name)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	if string(got) != string(key) {
+		dst.Delete(name)
+		return errors.New("verify: stored key does not match")
+	}
+	if err := src.Delete(name); err != nil {
+		log.Printf("[Keystore] Failed to remove key from %s: %v", src.Name(), err)
+	}
+	return nil
+}
+
+// GenerateKey returns a new random key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	return key, nil
+}
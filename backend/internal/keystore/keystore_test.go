@@ -0,0 +1,170 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestLoadOrCreateKeyFirstRun(t *testing.T) {
+	primary, fallback := NewMemoryProvider(), NewFileProvider(t.TempDir())
+	key, got, err := LoadOrCreateKey(primary, fallback, AccountKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != primary || len(key) != KeySize {
+		t.Fatalf("stored in %s, %d bytes", got.Name(), len(key))
+	}
+	if _, err := fallback.Get(AccountKeyName); !errors.Is(err, ErrNotFound) {
+		t.Errorf("fallback should stay empty, got %v", err)
+	}
+
+	again, got, err := LoadOrCreateKey(primary, fallback, AccountKeyName)
+	if err != nil || got != primary || !bytes.Equal(again, key) {
+		t.Errorf("second load: %v from %v", err, got)
+	}
+}
+
+func TestLoadOrCreateKeyMigratesLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	primary, fallback := NewMemoryProvider(), NewFileProvider(dir)
+	legacy := mustKey(t)
+	if err := fallback.Set(AccountKeyName, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	key, got, err := LoadOrCreateKey(primary, fallback, AccountKeyName)
+	if err != nil || got != primary || !bytes.Equal(key, legacy) {
+		t.Fatalf("got %v from %v", err, got)
+	}
+	if stored, _ := primary.Get(AccountKeyName); !bytes.Equal(stored, legacy) {
+		t.Error("key was not copied into the keyring")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".key")); !os.IsNotExist(err) {
+		t.Errorf("legacy file should be removed, got %v", err)
+	}
+}
+
+// corruptingProvider stores a different key than it is given
+type corruptingProvider struct{ *MemoryProvider }
+
+func (p corruptingProvider) Set(name string, key []byte) error {
+	bad := append([]byte(nil), key...)
+	bad[0] ^= 0xff
+	return p.MemoryProvider.Set(name, bad)
+}
+
+func TestLoadOrCreateKeyFailedMigrationKeepsFile(t *testing.T) {
+	primary, fallback := corruptingProvider{NewMemoryProvider()}, NewFileProvider(t.TempDir())
+	legacy := mustKey(t)
+	fallback.Set(AccountKeyName, legacy)
+
+	key, got, err := LoadOrCreateKey(primary, fallback, AccountKeyName)
+	if err != nil || got != fallback || !bytes.Equal(key, legacy) {
+		t.Fatalf("got %v from %v", err, got)
+	}
+	if stored, err := fallback.Get(AccountKeyName); err != nil || !bytes.Equal(stored, legacy) {
+		t.Errorf("legacy file lost: %v", err)
+	}
+	if _, err := primary.Get(AccountKeyName); !errors.Is(err, ErrNotFound) {
+		t.Errorf("mismatched copy should be removed, got %v", err)
+	}
+}
+
+func TestLoadOrCreateKeyKeyringUnavailable(t *testing.T) {
+	primary, fallback := NewMemoryProvider(), NewFileProvider(t.TempDir())
+	primary.Unavailable = true
+
+	// With a file key the launcher keeps working from the file
+	legacy := mustKey(t)
+	fallback.Set(AccountKeyName, legacy)
+	key, got, err := LoadOrCreateKey(primary, fallback, AccountKeyName)
+	if err != nil || got != fallback || !bytes.Equal(key, legacy) {
+		t.Fatalf("got %v from %v", err, got)
+	}
+	if _, err := fallback.Get(AccountKeyName); err != nil {
+		t.Errorf("file key must stay while the keyring is down: %v", err)
+	}
+}
+
+func TestLoadOrCreateKeyNeverReplacesUnreadableKey(t *testing.T) {
+	dir := t.TempDir()
+	primary, fallback := NewMemoryProvider(), NewFileProvider(dir)
+
+	// The key was migrated into the keyring, which then stops answering
+	stored := mustKey(t)
+	primary.Set(AccountKeyName, stored)
+	primary.Unavailable = true
+	if _, _, err := LoadOrCreateKey(primary, fallback, AccountKeyName); !errors.Is(err, ErrKeyUnreadable) {
+		t.Fatalf("expected ErrKeyUnreadable, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".key")); !os.IsNotExist(err) {
+		t.Errorf("no replacement key may be written, got %v", err)
+	}
+
+	// Once the keyring is back, the original key is still there
+	primary.Unavailable = false
+	key, _, err := LoadOrCreateKey(primary, fallback, AccountKeyName)
+	if err != nil || !bytes.Equal(key, stored) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestLoadOrCreateKeyNeverReplacesMalformedKey(t *testing.T) {
+	primary, fallback := NewMemoryProvider(), NewFileProvider(t.TempDir())
+	primary.Set(AccountKeyName, []byte("short"))
+	if _, _, err := LoadOrCreateKey(primary, fallback, AccountKeyName); !errors.Is(err, ErrKeyUnreadable) {
+		t.Fatalf("expected ErrKeyUnreadable, got %v", err)
+	}
+	if stored, _ := primary.Get(AccountKeyName); string(stored) != "short" {
+		t.Error("malformed key was overwritten")
+	}
+}
+
+func TestLoadOrCreateKeyWithoutKeyring(t *testing.T) {
+	fallback := NewFileProvider(t.TempDir())
+	key, got, err := LoadOrCreateKey(nil, fallback, AccountKeyNameFor("v2"))
+	if err != nil || got != fallback {
+		t.Fatalf("got %v from %v", err, got)
+	}
+	if stored, err := fallback.Get(AccountKeyNameFor("v2")); err != nil || !bytes.Equal(stored, key) {
+		t.Errorf("file key: %v", err)
+	}
+	if _, err := fallback.Get(AccountKeyName); !errors.Is(err, ErrNotFound) {
+		t.Errorf("versioned key must not touch the original key file, got %v", err)
+	}
+}
+
+func TestLoadKeyNeverGenerates(t *testing.T) {
+	dir := t.TempDir()
+	primary, fallback := NewMemoryProvider(), NewFileProvider(dir)
+
+	// A locked keyring can answer "not found" for a key that is there
+	if _, _, err := LoadKey(primary, fallback, AccountKeyName); !errors.Is(err, ErrKeyUnreadable) {
+		t.Fatalf("expected ErrKeyUnreadable, got %v", err)
+	}
+	if _, err := primary.Get(AccountKeyName); !errors.Is(err, ErrNotFound) {
+		t.Errorf("no key may be stored in the keyring, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".key")); !os.IsNotExist(err) {
+		t.Errorf("no key file may be written, got %v", err)
+	}
+
+	stored := mustKey(t)
+	fallback.Set(AccountKeyName, stored)
+	key, got, err := LoadKey(primary, fallback, AccountKeyName)
+	if err != nil || got != primary || !bytes.Equal(key, stored) {
+		t.Errorf("existing key: got %v from %v", err, got)
+	}
+}
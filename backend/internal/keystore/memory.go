@@ -0,0 +1,51 @@
+package keystore
+
+import "sync"
+
+// MemoryProvider keeps keys in memory. It stands in for the OS keyring in tests
+// and can simulate an unavailable keyring.
+type MemoryProvider struct {
+	mu          sync.Mutex
+	keys        map[string][]byte
+	Unavailable bool
+}
+
+// NewMemoryProvider creates an empty in-memory provider
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{keys: make(map[string][]byte)}
+}
+
+func (p *MemoryProvider) Name() string { return "memory" }
+
+func (p *MemoryProvider) Get(name string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Unavailable {
+		return nil, ErrUnavailable
+	}
+	key, ok := p.keys[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), key...), nil
+}
+
+func (p *MemoryProvider) Set(name string, key []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Unavailable {
+		return ErrUnavailable
+	}
+	p.keys[name] = append([]byte(nil), key...)
+	return nil
+}
+
+func (p *MemoryProvider) Delete(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Unavailable {
+		return ErrUnavailable
+	}
+	delete(p.keys, name)
+	return nil
+}
@@ -0,0 +1,88 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const secretServiceApp = "hyenimc"
+
+// SecretServiceProvider stores keys in the freedesktop Secret Service (GNOME Keyring,
+// KWallet, ...) through libsecret's secret-tool
+type SecretServiceProvider struct {
+	tool string
+}
+
+// NewSystemProvider returns the OS keyring provider, or nil if none is installed
+func NewSystemProvider() Provider {
+	tool, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil
+	}
+	return &SecretServiceProvider{tool: tool}
+}
+
+func (p *SecretServiceProvider) Name() string { return "secret-service" }
+
+func (p *SecretServiceProvider) Get(name string) ([]byte, error) {
+	out, err := p.run(nil, "lookup", "application", secretServiceApp, "key", name)
+	if err != nil {
+		return nil, err
+	}
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return nil, ErrNotFound
+	}
+	key, err := hex.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+	return key, nil
+}
+
+func (p *SecretServiceProvider) Set(name string, key []byte) error {
+	_, err := p.run([]byte(hex.EncodeToString(key)), "store", "--label=HyeniMC "+name,
+		"application", secretServiceApp, "key", name)
+	return err
+}
+
+func (p *SecretServiceProvider) Delete(name string) error {
+	_, err := p.run(nil, "clear", "application", secretServiceApp, "key", name)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// run executes secret-tool. A failure without any stderr output means the item does not exist.
+func (p *SecretServiceProvider) run(stdin []byte, args ...string) ([]byte, error) {
+	// An unlock prompt may block; do not hang startup forever
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.tool, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+	msg := strings.TrimSpace(stderr.String())
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && msg == "" && ctx.Err() == nil {
+		return nil, ErrNotFound
+	}
+	if msg == "" {
+		msg = err.Error()
+	}
+	return nil, fmt.Errorf("%w: secret-tool %s: %s", ErrUnavailable, args[0], msg)
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package keystore
+
+// NewSystemProvider returns the OS keyring provider, or nil if none is supported
+func NewSystemProvider() Provider {
+	return nil
+}
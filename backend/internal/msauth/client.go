@@ -0,0 +1,500 @@
+package msauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hyenimc/backend/internal/download"
+	"hyenimc/backend/internal/httpclient"
+)
+
+// Scope requested from Microsoft identity platform
+const Scope = "XboxLive.signin offline_access"
+
+// Endpoints holds every URL used by the login chain (overridable for local fakes)
+type Endpoints struct {
+	DeviceCodeURL     string
+	TokenURL          string
+	XboxUserAuthURL   string
+	XSTSAuthorizeURL  string
+	MinecraftLoginURL string
+	EntitlementsURL   string
+	ProfileURL        string
+}
+
+// DefaultEndpoints returns the production Microsoft/Xbox/Minecraft endpoints
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		DeviceCodeURL:     "https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode",
+		TokenURL:          "https://login.microsoftonline.com/consumers/oauth2/v2.0/token",
+		XboxUserAuthURL:   "https://user.auth.xboxlive.com/user/authenticate",
+		XSTSAuthorizeURL:  "https://xsts.auth.xboxlive.com/xsts/authorize",
+		MinecraftLoginURL: "https://api.minecraftservices.com/authentication/login_with_xbox",
+		EntitlementsURL:   "https://api.minecraftservices.com/entitlements/mcstore",
+		ProfileURL:        "https://api.minecraftservices.com/minecraft/profile",
+	}
+}
+
+var (
+	ErrMissingClientID       = errors.New("azure client id is not configured")
+	ErrAuthorizationDeclined = errors.New("user declined the sign-in request")
+	ErrDeviceCodeExpired     = errors.New("device code expired before sign-in completed")
+	ErrNoXboxAccount         = errors.New("microsoft account has no Xbox profile")
+	ErrXboxUnavailable       = errors.New("xbox live is not available in this country")
+	ErrChildAccount          = errors.New("child account must be added to a family by an adult")
+	ErrNoEntitlement         = errors.New("account does not own Minecraft")
+	ErrNoProfile             = errors.New("account has no Minecraft profile yet")
+	ErrRefreshTokenInvalid   = errors.New("refresh token is invalid or expired")
+)
+
+// OAuthError is an error response from the Microsoft token endpoints
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("oauth error %s: %s", e.Code, e.Description)
+}
+
+// DeviceCode is the response of the device authorization request
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+	Message         string `json:"message"`
+}
+
+// MSToken is a Microsoft OAuth token pair
+type MSToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Skin is an entry in the Minecraft profile skins list
+type Skin struct {
+	ID      string `json:"id"`
+	State   string `json:"state"`
+	URL     string `json:"url"`
+	Variant string `json:"variant"`
+}
+
+// Cape is an entry in the Minecraft profile capes list
+type Cape struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	URL   string `json:"url"`
+	Alias string `json:"alias"`
+}
+
+// Profile is the Minecraft Java profile of the signed-in account
+type Profile struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Skins []Skin `json:"skins"`
+	Capes []Cape `json:"capes"`
+}
+
+// ActiveSkinURL returns the URL of the active skin, if any
+func (p *Profile) ActiveSkinURL() string {
+	for _, s := range p.Skins {
+		if s.State == "ACTIVE" {
+			return s.URL
+		}
+	}
+	return ""
+}
+
+// Session is the result of a complete login chain
+type Session struct {
+	AccessToken  string // Minecraft access token
+	RefreshToken string // Microsoft refresh token
+	ExpiresAt    int64  // Minecraft token expiry (unix milliseconds, same as the TS side)
+	Profile      *Profile
+}
+
+// Client talks to the Microsoft, Xbox Live and Minecraft services
+type Client struct {
+	clientID   string
+	endpoints  Endpoints
+	httpClient *http.Client
+}
+
+// NewClient creates a new auth client
+func NewClient(clientID string, endpoints Endpoints) *Client {
+	return &Client{
+		clientID:   clientID,
+		endpoints:  endpoints,
+		httpClient: httpclient.New(30 * time.Second),
+	}
+}
+
+// WithClientID returns a copy of the client using another Azure application id
+func (c *Client) WithClientID(clientID string) *Client {
+	cp := *c
+	cp.clientID = clientID
+	return &cp
+}
+
+// RequestDeviceCode starts the device authorization flow
+func (c *Client) RequestDeviceCode(ctx context.Context) (*DeviceCode, error) {
+	if c.clientID == "" {
+		return nil, ErrMissingClientID
+	}
+	form := url.Values{"client_id": {c.clientID}, "scope": {Scope}}
+	var dc DeviceCode
+	if err := c.postForm(ctx, c.endpoints.DeviceCodeURL, form, &dc); err != nil {
+		return nil, fmt.Errorf("device code: %w", err)
+	}
+	if dc.Interval <= 0 {
+		dc.Interval = 5
+	}
+	return &dc, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user finishes signing in
+func (c *Client) PollDeviceToken(ctx context.Context, dc *DeviceCode) (*MSToken, error) {
+	interval := time.Duration(dc.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"client_id":   {c.clientID},
+		"device_code": {dc.DeviceCode},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		if dc.ExpiresIn > 0 && time.Now().After(deadline) {
+			return nil, ErrDeviceCodeExpired
+		}
+
+		var tok MSToken
+		err := c.postForm(ctx, c.endpoints.TokenURL, form, &tok)
+		if err == nil {
+			return &tok, nil
+		}
+		var oe *OAuthError
+		if !errors.As(err, &oe) {
+			return nil, fmt.Errorf("poll token: %w", err)
+		}
+		switch oe.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "authorization_declined":
+			return nil, ErrAuthorizationDeclined
+		case "expired_token", "bad_verification_code":
+			return nil, ErrDeviceCodeExpired
+		default:
+			return nil, fmt.Errorf("poll token: %w", err)
+		}
+	}
+}
+
+// RefreshMSToken exchanges a refresh token for a new token pair
+func (c *Client) RefreshMSToken(ctx context.Context, refreshToken string) (*MSToken, error) {
+	if c.clientID == "" {
+		return nil, ErrMissingClientID
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {c.clientID},
+		"refresh_token": {refreshToken},
+		"scope":         {Scope},
+	}
+	var tok MSToken
+	if err := c.postForm(ctx, c.endpoints.TokenURL, form, &tok); err != nil {
+		var oe *OAuthError
+		if errors.As(err, &oe) && oe.Code == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %s", ErrRefreshTokenInvalid, oe.Description)
+		}
+		return nil, fmt.Errorf("refresh token: %w", err)
+	}
+	if tok.RefreshToken == "" {
+		// Microsoft may omit the refresh token when it was not rotated
+		tok.RefreshToken = refreshToken
+	}
+	return &tok, nil
+}
+
+type xboxResponse struct {
+	Token         string `json:"Token"`
+	DisplayClaims struct {
+		Xui []struct {
+			Uhs string `json:"uhs"`
+		} `json:"xui"`
+	} `json:"DisplayClaims"`
+}
+
+type xboxError struct {
+	XErr     int64  `json:"XErr"`
+	Message  string `json:"Message"`
+	Redirect string `json:"Redirect"`
+}
+
+// XboxLiveAuth authenticates a Microsoft access token with Xbox Live
+func (c *Client) XboxLiveAuth(ctx context.Context, msAccessToken string) (token, uhs string, err error) {
+	body := map[string]any{
+		"Properties": map[string]any{
+			"AuthMethod": "RPS",
+			"SiteName":   "user.auth.xboxlive.com",
+			"RpsTicket":  "d=" + msAccessToken,
+		},
+		"RelyingParty": "http://auth.xboxlive.com",
+		"TokenType":    "JWT",
+	}
+	var res xboxResponse
+	if err := c.postJSON(ctx, c.endpoints.XboxUserAuthURL, "", body, &res); err != nil {
+		return "", "", fmt.Errorf("xbox live: %w", err)
+	}
+	if len(res.DisplayClaims.Xui) > 0 {
+		uhs = res.DisplayClaims.Xui[0].Uhs
+	}
+	return res.Token, uhs, nil
+}
+
+// XSTSAuthorize exchanges an Xbox Live token for an XSTS token for Minecraft services
+func (c *Client) XSTSAuthorize(ctx context.Context, xblToken string) (token, uhs string, err error) {
+	body := map[string]any{
+		"Properties": map[string]any{
+			"SandboxId":  "RETAIL",
+			"UserTokens": []string{xblToken},
+		},
+		"RelyingParty": "rp://api.minecraftservices.com/",
+		"TokenType":    "JWT",
+	}
+	var res xboxResponse
+	if err := c.postJSON(ctx, c.endpoints.XSTSAuthorizeURL, "", body, &res); err != nil {
+		return "", "", fmt.Errorf("xsts: %w", err)
+	}
+	if len(res.DisplayClaims.Xui) == 0 {
+		return "", "", fmt.Errorf("xsts: response has no user hash")
+	}
+	return res.Token, res.DisplayClaims.Xui[0].Uhs, nil
+}
+
+// LoginWithXbox logs in to Minecraft services using an XSTS token
+func (c *Client) LoginWithXbox(ctx context.Context, uhs, xstsToken string) (accessToken string, expiresIn int, err error) {
+	body := map[string]any{"identityToken": fmt.Sprintf("XBL3.0 x=%s;%s", uhs, xstsToken)}
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := c.postJSON(ctx, c.endpoints.MinecraftLoginURL, "", body, &res); err != nil {
+		return "", 0, fmt.Errorf("minecraft login: %w", err)
+	}
+	return res.AccessToken, res.ExpiresIn, nil
+}
+
+// HasEntitlement reports whether the account owns Minecraft (including Game Pass)
+func (c *Client) HasEntitlement(ctx context.Context, mcAccessToken string) (bool, error) {
+	var res struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	}
+	if err := c.getJSON(ctx, c.endpoints.EntitlementsURL, mcAccessToken, &res); err != nil {
+		return false, fmt.Errorf("entitlements: %w", err)
+	}
+	for _, it := range res.Items {
+		if it.Name == "product_minecraft" || it.Name == "game_minecraft" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetProfile fetches the Minecraft Java profile
+func (c *Client) GetProfile(ctx context.Context, mcAccessToken string) (*Profile, error) {
+	var p Profile
+	if err := c.getJSON(ctx, c.endpoints.ProfileURL, mcAccessToken, &p); err != nil {
+		var se *download.HTTPStatusError
+		if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+			return nil, ErrNoProfile
+		}
+		return nil, fmt.Errorf("profile: %w", err)
+	}
+	return &p, nil
+}
+
+// Stage names reported by Login
+const (
+	StageXboxLive    = "xbox_live"
+	StageXSTS        = "xsts"
+	StageMinecraft   = "minecraft"
+	StageEntitlement = "entitlement"
+	StageProfile     = "profile"
+)
+
+// Login runs the Xbox Live -> XSTS -> Minecraft chain for a Microsoft token.
+// onStage (optional) is called before each step.
+func (c *Client) Login(ctx context.Context, ms *MSToken, onStage func(stage string)) (*Session, error) {
+	stage := func(s string) {
+		if onStage != nil {
+			onStage(s)
+		}
+	}
+	stage(StageXboxLive)
+	xbl, _, err := c.XboxLiveAuth(ctx, ms.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	stage(StageXSTS)
+	xsts, uhs, err := c.XSTSAuthorize(ctx, xbl)
+	if err != nil {
+		return nil, err
+	}
+	stage(StageMinecraft)
+	mcToken, expiresIn, err := c.LoginWithXbox(ctx, uhs, xsts)
+	if err != nil {
+		return nil, err
+	}
+	stage(StageEntitlement)
+	owned, err := c.HasEntitlement(ctx, mcToken)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, ErrNoEntitlement
+	}
+	stage(StageProfile)
+	profile, err := c.GetProfile(ctx, mcToken)
+	if err != nil {
+		return nil, err
+	}
+	if expiresIn <= 0 {
+		expiresIn = ms.ExpiresIn
+	}
+	return &Session{
+		AccessToken:  mcToken,
+		RefreshToken: ms.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(expiresIn) * time.Second).UnixMilli(),
+		Profile:      profile,
+	}, nil
+}
+
+// Refresh renews a session from a stored Microsoft refresh token (no entitlement check)
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Session, error) {
+	ms, err := c.RefreshMSToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	xbl, _, err := c.XboxLiveAuth(ctx, ms.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	xsts, uhs, err := c.XSTSAuthorize(ctx, xbl)
+	if err != nil {
+		return nil, err
+	}
+	mcToken, expiresIn, err := c.LoginWithXbox(ctx, uhs, xsts)
+	if err != nil {
+		return nil, err
+	}
+	if expiresIn <= 0 {
+		expiresIn = ms.ExpiresIn
+	}
+	return &Session{
+		AccessToken:  mcToken,
+		RefreshToken: ms.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(expiresIn) * time.Second).UnixMilli(),
+	}, nil
+}
+
+func (c *Client) postForm(ctx context.Context, endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	return c.do(req, out)
+}
+
+func (c *Client) postJSON(ctx context.Context, endpoint, bearer string, body, out any) error {
+	return c.send(ctx, http.MethodPost, endpoint, bearer, body, out)
+}
+
+func (c *Client) getJSON(ctx context.Context, endpoint, bearer string, out any) error {
+	return c.send(ctx, http.MethodGet, endpoint, bearer, nil, out)
+}
+
+// send performs a JSON request; body may be nil
+func (c *Client) send(ctx context.Context, method, endpoint, bearer string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return c.do(req, out)
+}
+
+// do executes a request and decodes the JSON body, mapping service specific errors
+func (c *Client) do(req *http.Request, out any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return download.Classify(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return download.Classify(err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if out == nil || len(body) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		return nil
+	}
+
+	// OAuth endpoints answer 400 with {"error": "..."}
+	var oe OAuthError
+	if json.Unmarshal(body, &oe) == nil && oe.Code != "" {
+		return &oe
+	}
+	// XSTS answers 401 with {"XErr": ...}
+	var xe xboxError
+	if json.Unmarshal(body, &xe) == nil && xe.XErr != 0 {
+		switch xe.XErr {
+		case 2148916233:
+			return ErrNoXboxAccount
+		case 2148916235:
+			return ErrXboxUnavailable
+		case 2148916236, 2148916237, 2148916238:
+			return ErrChildAccount
+		}
+		return fmt.Errorf("xbox error %d: %s", xe.XErr, xe.Message)
+	}
+	return download.NewHTTPStatusError(resp)
+}
@@ -0,0 +1,375 @@
+package msauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hyenimc/backend/internal/download"
+)
+
+// fakeServices stands in for the Microsoft, Xbox Live and Minecraft services.
+// Each route answers with the handler registered for "METHOD /path".
+type fakeServices struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	handlers map[string]http.HandlerFunc
+	calls    []string
+}
+
+// newFakeServices starts the fake and returns a client whose endpoints all point at it
+func newFakeServices(t *testing.T) (*fakeServices, *Client) {
+	t.Helper()
+	f := &fakeServices{t: t, handlers: map[string]http.HandlerFunc{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, NewClient("client-id", Endpoints{
+		DeviceCodeURL:     srv.URL + "/devicecode",
+		TokenURL:          srv.URL + "/token",
+		XboxUserAuthURL:   srv.URL + "/user/authenticate",
+		XSTSAuthorizeURL:  srv.URL + "/xsts/authorize",
+		MinecraftLoginURL: srv.URL + "/authentication/login_with_xbox",
+		EntitlementsURL:   srv.URL + "/entitlements/mcstore",
+		ProfileURL:        srv.URL + "/minecraft/profile",
+	})
+}
+
+func (f *fakeServices) handle(route string, h http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[route] = h
+}
+
+func (f *fakeServices) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + r.URL.Path
+	f.mu.Lock()
+	h, ok := f.handlers[route]
+	f.calls = append(f.calls, route)
+	f.mu.Unlock()
+	if !ok {
+		f.t.Errorf("unexpected request %s", route)
+		http.NotFound(w, r)
+		return
+	}
+	h(w, r)
+}
+
+func (f *fakeServices) called(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c == route {
+			n++
+		}
+	}
+	return n
+}
+
+// reply writes v as a JSON response with the given status
+func reply(status int, v any) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func oauthError(code string) http.HandlerFunc {
+	return reply(http.StatusBadRequest, map[string]string{"error": code, "error_description": code + " description"})
+}
+
+func xboxToken(token, uhs string) map[string]any {
+	return map[string]any{
+		"Token":         token,
+		"DisplayClaims": map[string]any{"xui": []map[string]string{{"uhs": uhs}}},
+	}
+}
+
+// readJSON decodes a JSON request body
+func readJSON(t *testing.T, r *http.Request) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		t.Errorf("decode %s body: %v", r.URL.Path, err)
+	}
+	return body
+}
+
+// loginChain registers a successful Xbox Live -> XSTS -> Minecraft -> profile chain
+func (f *fakeServices) loginChain(t *testing.T, msAccess string) {
+	f.handle("POST /user/authenticate", func(w http.ResponseWriter, r *http.Request) {
+		props, _ := readJSON(t, r)["Properties"].(map[string]any)
+		if props["RpsTicket"] != "d="+msAccess {
+			t.Errorf("RpsTicket %v", props["RpsTicket"])
+		}
+		reply(http.StatusOK, xboxToken("xbl-token", "uhs-1"))(w, r)
+	})
+	f.handle("POST /xsts/authorize", func(w http.ResponseWriter, r *http.Request) {
+		props, _ := readJSON(t, r)["Properties"].(map[string]any)
+		if tokens, _ := props["UserTokens"].([]any); len(tokens) != 1 || tokens[0] != "xbl-token" {
+			t.Errorf("UserTokens %v", props["UserTokens"])
+		}
+		reply(http.StatusOK, xboxToken("xsts-token", "uhs-2"))(w, r)
+	})
+	f.handle("POST /authentication/login_with_xbox", func(w http.ResponseWriter, r *http.Request) {
+		if id := readJSON(t, r)["identityToken"]; id != "XBL3.0 x=uhs-2;xsts-token" {
+			t.Errorf("identityToken %v", id)
+		}
+		reply(http.StatusOK, map[string]any{"access_token": "mc-token", "expires_in": 86400})(w, r)
+	})
+	f.handle("GET /entitlements/mcstore", func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer mc-token" {
+			t.Errorf("entitlements authorization %q", auth)
+		}
+		reply(http.StatusOK, map[string]any{"items": []map[string]string{{"name": "product_minecraft"}, {"name": "game_minecraft"}}})(w, r)
+	})
+	f.handle("GET /minecraft/profile", reply(http.StatusOK, map[string]any{
+		"id":    "0123456789abcdef0123456789abcdef",
+		"name":  "Steve",
+		"skins": []map[string]string{{"id": "s1", "state": "ACTIVE", "url": "https://textures.example/steve"}},
+	}))
+}
+
+func TestDeviceCodeLogin(t *testing.T) {
+	f, c := newFakeServices(t)
+	ctx := context.Background()
+
+	f.handle("POST /devicecode", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("client_id") != "client-id" || r.PostForm.Get("scope") != Scope {
+			t.Errorf("device code form %v", r.PostForm)
+		}
+		reply(http.StatusOK, map[string]any{
+			"device_code":      "dev-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": "https://microsoft.com/link",
+			"expires_in":       900,
+		})(w, r)
+	})
+	var polls int
+	f.handle("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("device_code") != "dev-code" || r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" {
+			t.Errorf("token form %v", r.PostForm)
+		}
+		if polls++; polls < 3 {
+			oauthError("authorization_pending")(w, r)
+			return
+		}
+		reply(http.StatusOK, map[string]any{"access_token": "ms-access", "refresh_token": "ms-refresh", "expires_in": 3600})(w, r)
+	})
+	f.loginChain(t, "ms-access")
+
+	dc, err := c.RequestDeviceCode(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dc.UserCode != "ABCD-EFGH" || dc.VerificationURI != "https://microsoft.com/link" || dc.Interval != 5 {
+		t.Fatalf("device code %+v", dc)
+	}
+	dc.Interval = 0 // poll without waiting
+
+	ms, err := c.PollDeviceToken(ctx, dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polls != 3 || ms.AccessToken != "ms-access" {
+		t.Fatalf("%d polls, token %+v", polls, ms)
+	}
+
+	var stages []string
+	session, err := c.Login(ctx, ms, func(s string) { stages = append(stages, s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.AccessToken != "mc-token" || session.RefreshToken != "ms-refresh" {
+		t.Errorf("session %+v", session)
+	}
+	if session.Profile.Name != "Steve" || session.Profile.ActiveSkinURL() != "https://textures.example/steve" {
+		t.Errorf("profile %+v", session.Profile)
+	}
+	if left := time.Until(time.UnixMilli(session.ExpiresAt)); left < 23*time.Hour || left > 24*time.Hour {
+		t.Errorf("expires in %v", left)
+	}
+	want := []string{StageXboxLive, StageXSTS, StageMinecraft, StageEntitlement, StageProfile}
+	if strings.Join(stages, ",") != strings.Join(want, ",") {
+		t.Errorf("stages %v", stages)
+	}
+}
+
+func TestRequestDeviceCodeWithoutClientID(t *testing.T) {
+	_, c := newFakeServices(t)
+	if _, err := c.WithClientID("").RequestDeviceCode(context.Background()); !errors.Is(err, ErrMissingClientID) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestPollDeviceTokenErrors(t *testing.T) {
+	for code, want := range map[string]error{
+		"authorization_declined": ErrAuthorizationDeclined,
+		"expired_token":          ErrDeviceCodeExpired,
+		"bad_verification_code":  ErrDeviceCodeExpired,
+	} {
+		f, c := newFakeServices(t)
+		f.handle("POST /token", oauthError(code))
+		if _, err := c.PollDeviceToken(context.Background(), &DeviceCode{DeviceCode: "dev-code"}); !errors.Is(err, want) {
+			t.Errorf("%s: got %v", code, err)
+		}
+	}
+
+	f, c := newFakeServices(t)
+	f.handle("POST /token", oauthError("invalid_client"))
+	_, err := c.PollDeviceToken(context.Background(), &DeviceCode{DeviceCode: "dev-code"})
+	var oe *OAuthError
+	if !errors.As(err, &oe) || oe.Code != "invalid_client" {
+		t.Errorf("invalid_client: got %v", err)
+	}
+}
+
+func TestPollDeviceTokenStopsOnCancel(t *testing.T) {
+	f, c := newFakeServices(t)
+	f.handle("POST /token", oauthError("authorization_pending"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.PollDeviceToken(ctx, &DeviceCode{DeviceCode: "dev-code"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestPollDeviceTokenExpires(t *testing.T) {
+	f, c := newFakeServices(t)
+	f.handle("POST /token", oauthError("authorization_pending"))
+	_, err := c.PollDeviceToken(context.Background(), &DeviceCode{DeviceCode: "dev-code", ExpiresIn: 1})
+	if !errors.Is(err, ErrDeviceCodeExpired) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	f, c := newFakeServices(t)
+	f.handle("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "old-refresh" {
+			t.Errorf("refresh form %v", r.PostForm)
+		}
+		reply(http.StatusOK, map[string]any{"access_token": "ms-access", "refresh_token": "new-refresh", "expires_in": 3600})(w, r)
+	})
+	f.loginChain(t, "ms-access")
+
+	session, err := c.Refresh(context.Background(), "old-refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.AccessToken != "mc-token" || session.RefreshToken != "new-refresh" || session.Profile != nil {
+		t.Errorf("session %+v", session)
+	}
+	// Refresh skips the entitlement and profile lookups
+	if f.called("GET /entitlements/mcstore")+f.called("GET /minecraft/profile") != 0 {
+		t.Errorf("calls %v", f.calls)
+	}
+}
+
+func TestRefreshMSTokenKeepsUnrotatedToken(t *testing.T) {
+	f, c := newFakeServices(t)
+	f.handle("POST /token", reply(http.StatusOK, map[string]any{"access_token": "ms-access", "expires_in": 3600}))
+	tok, err := c.RefreshMSToken(context.Background(), "old-refresh")
+	if err != nil || tok.RefreshToken != "old-refresh" {
+		t.Errorf("got %+v, %v", tok, err)
+	}
+}
+
+func TestRefreshMSTokenInvalidGrant(t *testing.T) {
+	f, c := newFakeServices(t)
+	f.handle("POST /token", oauthError("invalid_grant"))
+	if _, err := c.Refresh(context.Background(), "revoked"); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Errorf("got %v", err)
+	}
+	if n := f.called("POST /user/authenticate"); n != 0 {
+		t.Errorf("xbox live called %d times after a failed refresh", n)
+	}
+}
+
+func TestXSTSErrors(t *testing.T) {
+	for xerr, want := range map[int64]error{
+		2148916233: ErrNoXboxAccount,
+		2148916235: ErrXboxUnavailable,
+		2148916236: ErrChildAccount,
+		2148916238: ErrChildAccount,
+	} {
+		f, c := newFakeServices(t)
+		f.handle("POST /xsts/authorize", reply(http.StatusUnauthorized, map[string]any{"XErr": xerr, "Message": "", "Redirect": "https://start.ui.xboxlive.com"}))
+		if _, _, err := c.XSTSAuthorize(context.Background(), "xbl-token"); !errors.Is(err, want) {
+			t.Errorf("XErr %d: got %v", xerr, err)
+		}
+	}
+
+	f, c := newFakeServices(t)
+	f.handle("POST /xsts/authorize", reply(http.StatusUnauthorized, map[string]any{"XErr": 2148916999, "Message": "other"}))
+	if _, _, err := c.XSTSAuthorize(context.Background(), "xbl-token"); err == nil || !strings.Contains(err.Error(), "xbox error 2148916999") {
+		t.Errorf("unknown XErr: got %v", err)
+	}
+
+	f, c = newFakeServices(t)
+	f.handle("POST /xsts/authorize", reply(http.StatusOK, map[string]any{"Token": "xsts-token"}))
+	if _, _, err := c.XSTSAuthorize(context.Background(), "xbl-token"); err == nil {
+		t.Error("expected an error for a response without a user hash")
+	}
+}
+
+func TestLoginErrors(t *testing.T) {
+	ms := &MSToken{AccessToken: "ms-access", RefreshToken: "ms-refresh", ExpiresIn: 3600}
+
+	f, c := newFakeServices(t)
+	f.loginChain(t, "ms-access")
+	f.handle("GET /entitlements/mcstore", reply(http.StatusOK, map[string]any{"items": []any{}}))
+	if _, err := c.Login(context.Background(), ms, nil); !errors.Is(err, ErrNoEntitlement) {
+		t.Errorf("no entitlement: got %v", err)
+	}
+
+	f, c = newFakeServices(t)
+	f.loginChain(t, "ms-access")
+	f.handle("GET /minecraft/profile", reply(http.StatusNotFound, map[string]string{"path": "/minecraft/profile", "errorMessage": "NOT_FOUND"}))
+	if _, err := c.Login(context.Background(), ms, nil); !errors.Is(err, ErrNoProfile) {
+		t.Errorf("no profile: got %v", err)
+	}
+
+	f, c = newFakeServices(t)
+	f.loginChain(t, "ms-access")
+	f.handle("POST /authentication/login_with_xbox", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, "slow down")
+	})
+	_, err := c.Login(context.Background(), ms, nil)
+	var se *download.HTTPStatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusTooManyRequests || !strings.HasPrefix(err.Error(), "minecraft login: ") {
+		t.Errorf("minecraft login 429: got %v", err)
+	}
+}
+
+func TestMalformedResponse(t *testing.T) {
+	f, c := newFakeServices(t)
+	f.handle("POST /devicecode", func(w http.ResponseWriter, _ *http.Request) { io.WriteString(w, "<html>") })
+	if _, err := c.RequestDeviceCode(context.Background()); err == nil || !strings.Contains(err.Error(), "decode response") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestUnreachableEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	endpoint := srv.URL + "/devicecode"
+	srv.Close()
+	c := NewClient("client-id", Endpoints{DeviceCodeURL: endpoint})
+	_, err := c.RequestDeviceCode(context.Background())
+	if download.KindOf(err) != download.KindConnRefused || !download.IsRetryable(err) {
+		t.Errorf("got %v (%v)", err, download.KindOf(err))
+	}
+}
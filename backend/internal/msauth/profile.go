@@ -0,0 +1,84 @@
+package msauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+)
+
+// Skin variants accepted by the Minecraft services API
+const (
+	SkinVariantClassic = "classic"
+	SkinVariantSlim    = "slim"
+)
+
+// UploadSkin replaces the active skin with a PNG image and returns the updated profile
+func (c *Client) UploadSkin(ctx context.Context, mcAccessToken, variant string, png []byte) (*Profile, error) {
+	if variant != SkinVariantClassic && variant != SkinVariantSlim {
+		return nil, fmt.Errorf("invalid skin variant: %q", variant)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("variant", variant); err != nil {
+		return nil, err
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="skin.png"`)
+	h.Set("Content-Type", "image/png")
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(png); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoints.ProfileURL+"/skins", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+mcAccessToken)
+
+	var p Profile
+	if err := c.do(req, &p); err != nil {
+		return nil, fmt.Errorf("upload skin: %w", err)
+	}
+	return &p, nil
+}
+
+// ResetSkin resets the skin to the default and returns the updated profile
+func (c *Client) ResetSkin(ctx context.Context, mcAccessToken string) (*Profile, error) {
+	var p Profile
+	if err := c.send(ctx, http.MethodDelete, c.endpoints.ProfileURL+"/skins/active", mcAccessToken, nil, &p); err != nil {
+		return nil, fmt.Errorf("reset skin: %w", err)
+	}
+	return &p, nil
+}
+
+// ShowCape makes an owned cape active and returns the updated profile
+func (c *Client) ShowCape(ctx context.Context, mcAccessToken, capeID string) (*Profile, error) {
+	var p Profile
+	body := map[string]string{"capeId": capeID}
+	if err := c.send(ctx, http.MethodPut, c.endpoints.ProfileURL+"/capes/active", mcAccessToken, body, &p); err != nil {
+		return nil, fmt.Errorf("show cape: %w", err)
+	}
+	return &p, nil
+}
+
+// HideCape hides the active cape and returns the updated profile
+func (c *Client) HideCape(ctx context.Context, mcAccessToken string) (*Profile, error) {
+	var p Profile
+	if err := c.send(ctx, http.MethodDelete, c.endpoints.ProfileURL+"/capes/active", mcAccessToken, nil, &p); err != nil {
+		return nil, fmt.Errorf("hide cape: %w", err)
+	}
+	return &p, nil
+}
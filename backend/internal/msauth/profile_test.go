@@ -0,0 +1,99 @@
+package msauth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"hyenimc/backend/internal/download"
+)
+
+var updatedProfile = map[string]any{
+	"id":    "0123456789abcdef0123456789abcdef",
+	"name":  "Steve",
+	"skins": []map[string]string{{"id": "s2", "state": "ACTIVE", "url": "https://textures.example/new", "variant": "SLIM"}},
+	"capes": []map[string]string{{"id": "c1", "state": "ACTIVE", "url": "https://textures.example/cape", "alias": "Migrator"}},
+}
+
+func TestUploadSkin(t *testing.T) {
+	f, c := newFakeServices(t)
+	png := []byte("\x89PNG fake image")
+	f.handle("POST /minecraft/profile/skins", func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer mc-token" {
+			t.Errorf("authorization %q", auth)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		if v := r.FormValue("variant"); v != SkinVariantSlim {
+			t.Errorf("variant %q", v)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		if string(data) != string(png) || header.Header.Get("Content-Type") != "image/png" {
+			t.Errorf("file %q (%s)", data, header.Header.Get("Content-Type"))
+		}
+		reply(http.StatusOK, updatedProfile)(w, r)
+	})
+
+	p, err := c.UploadSkin(context.Background(), "mc-token", SkinVariantSlim, png)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ActiveSkinURL() != "https://textures.example/new" || p.Skins[0].Variant != "SLIM" {
+		t.Errorf("profile %+v", p)
+	}
+}
+
+func TestUploadSkinRejectsUnknownVariant(t *testing.T) {
+	f, c := newFakeServices(t)
+	if _, err := c.UploadSkin(context.Background(), "mc-token", "wide", []byte("png")); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("requests sent: %v", f.calls)
+	}
+}
+
+func TestSkinAndCapeChanges(t *testing.T) {
+	f, c := newFakeServices(t)
+	ctx := context.Background()
+	f.handle("DELETE /minecraft/profile/skins/active", reply(http.StatusOK, updatedProfile))
+	f.handle("DELETE /minecraft/profile/capes/active", reply(http.StatusOK, map[string]any{"id": "0123", "name": "Steve"}))
+	f.handle("PUT /minecraft/profile/capes/active", func(w http.ResponseWriter, r *http.Request) {
+		if id := readJSON(t, r)["capeId"]; id != "c1" {
+			t.Errorf("capeId %v", id)
+		}
+		reply(http.StatusOK, updatedProfile)(w, r)
+	})
+
+	if p, err := c.ResetSkin(ctx, "mc-token"); err != nil || p.Name != "Steve" {
+		t.Errorf("reset skin: %+v, %v", p, err)
+	}
+	if p, err := c.ShowCape(ctx, "mc-token", "c1"); err != nil || len(p.Capes) != 1 || p.Capes[0].State != "ACTIVE" {
+		t.Errorf("show cape: %+v, %v", p, err)
+	}
+	if p, err := c.HideCape(ctx, "mc-token"); err != nil || len(p.Capes) != 0 {
+		t.Errorf("hide cape: %+v, %v", p, err)
+	}
+}
+
+func TestProfileChangeErrors(t *testing.T) {
+	f, c := newFakeServices(t)
+	f.handle("PUT /minecraft/profile/capes/active", reply(http.StatusBadRequest, map[string]string{"errorMessage": "Invalid cape"}))
+	f.handle("DELETE /minecraft/profile/skins/active", reply(http.StatusUnauthorized, map[string]string{"errorMessage": "Unauthorized"}))
+
+	var se *download.HTTPStatusError
+	if _, err := c.ShowCape(context.Background(), "mc-token", "not-owned"); !errors.As(err, &se) || se.StatusCode != http.StatusBadRequest {
+		t.Errorf("show cape: got %v", err)
+	}
+	if _, err := c.ResetSkin(context.Background(), "expired"); !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized {
+		t.Errorf("reset skin: got %v", err)
+	}
+}
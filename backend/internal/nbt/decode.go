@@ -0,0 +1,325 @@
+package nbt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Limits protecting against corrupt or hostile files
+const (
+	maxDepth    = 512
+	maxArrayLen = 1 << 26 // elements per array or list
+
+	// MaxDecodedSize bounds the decoded data a single Decode accepts, so a small
+	// compressed file cannot expand into gigabytes. Compounds and lists count
+	// containerCost bytes each on top of their encoded size.
+	MaxDecodedSize = 64 << 20
+	containerCost  = 64
+)
+
+var (
+	// ErrTooDeep is returned for data nested deeper than the NBT spec allows
+	ErrTooDeep = errors.New("nbt: nesting too deep")
+	// ErrTooLarge is returned for data exceeding MaxDecodedSize
+	ErrTooLarge = errors.New("nbt: data too large")
+)
+
+// Decode reads a named root tag from uncompressed, gzip or zlib compressed data.
+// The root must be a compound, as in every file Minecraft writes.
+func Decode(r io.Reader) (string, Compound, error) {
+	name, root, _, err := DecodeCompression(r)
+	return name, root, err
+}
+
+// DecodeCompression is Decode that also reports the detected compression, so a
+// file can be written back the way it was read
+func DecodeCompression(r io.Reader) (string, Compound, Compression, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+	compression := detectCompression(magic)
+
+	var src io.Reader = br
+	switch compression {
+	case Gzip:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("nbt: %w", err)
+		}
+		defer gz.Close()
+		src = bufio.NewReader(gz)
+	case Zlib:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("nbt: %w", err)
+		}
+		defer zr.Close()
+		src = bufio.NewReader(zr)
+	}
+
+	name, root, err := decodeRoot(src)
+	return name, root, compression, err
+}
+
+// detectCompression recognizes the gzip magic and zlib headers. An uncompressed
+// file starts with TAG_Compound (0x0a), which neither can start with.
+func detectCompression(magic []byte) Compression {
+	if len(magic) < 2 {
+		return None
+	}
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		return Gzip
+	}
+	if magic[0]&0x0f == 8 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0 {
+		return Zlib
+	}
+	return None
+}
+
+// ReadFile decodes the NBT file at path
+func ReadFile(path string) (string, Compound, error) {
+	name, root, _, err := ReadFileCompression(path)
+	return name, root, err
+}
+
+// ReadFileCompression decodes the NBT file at path and reports its compression
+func ReadFileCompression(path string) (string, Compound, Compression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	defer f.Close()
+	return DecodeCompression(f)
+}
+
+func decodeRoot(r io.Reader) (string, Compound, error) {
+	d := &decoder{r: r, budget: MaxDecodedSize}
+	t, err := d.u8()
+	if err != nil {
+		return "", nil, fmt.Errorf("nbt: %w", err)
+	}
+	if t != TagCompound {
+		return "", nil, fmt.Errorf("nbt: root is %s, expected TAG_Compound", TagName(t))
+	}
+	name, err := d.str()
+	if err != nil {
+		return "", nil, fmt.Errorf("nbt: %w", err)
+	}
+	v, err := d.payload(t, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("nbt: %w", err)
+	}
+	return name, v.(Compound), nil
+}
+
+type decoder struct {
+	r      io.Reader
+	buf    [8]byte
+	budget int64 // remaining MaxDecodedSize
+}
+
+// charge takes n bytes from the decode budget
+func (d *decoder) charge(n int64) error {
+	d.budget -= n
+	if d.budget < 0 {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if err := d.charge(int64(n)); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(d.r, d.buf[:n]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return d.buf[:n], nil
+}
+
+func (d *decoder) u8() (byte, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) u16() (uint16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (d *decoder) u32() (uint32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (d *decoder) u64() (uint64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// str reads a length-prefixed modified UTF-8 string
+func (d *decoder) str() (string, error) {
+	n, err := d.u16()
+	if err != nil {
+		return "", err
+	}
+	if err := d.charge(int64(n)); err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", io.ErrUnexpectedEOF
+	}
+	return decodeMUTF8(b), nil
+}
+
+func (d *decoder) length() (int, error) {
+	n, err := d.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int32(n) < 0 || n > maxArrayLen {
+		return 0, fmt.Errorf("invalid length %d", int32(n))
+	}
+	return int(n), nil
+}
+
+func (d *decoder) payload(t byte, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, ErrTooDeep
+	}
+	switch t {
+	case TagByte:
+		v, err := d.u8()
+		return int8(v), err
+	case TagShort:
+		v, err := d.u16()
+		return int16(v), err
+	case TagInt:
+		v, err := d.u32()
+		return int32(v), err
+	case TagLong:
+		v, err := d.u64()
+		return int64(v), err
+	case TagFloat:
+		v, err := d.u32()
+		return math.Float32frombits(v), err
+	case TagDouble:
+		v, err := d.u64()
+		return math.Float64frombits(v), err
+	case TagString:
+		return d.str()
+	case TagByteArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.charge(int64(n)); err != nil {
+			return nil, err
+		}
+		// Grow with the data actually read rather than trusting the length
+		buf := bytes.NewBuffer(make([]byte, 0, min(n, 4096)))
+		if m, err := io.CopyN(buf, d.r, int64(n)); err != nil || m != int64(n) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return buf.Bytes(), nil
+	case TagIntArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]int32, 0, min(n, 4096))
+		for i := 0; i < n; i++ {
+			v, err := d.u32()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, int32(v))
+		}
+		return arr, nil
+	case TagLongArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]int64, 0, min(n, 4096))
+		for i := 0; i < n; i++ {
+			v, err := d.u64()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, int64(v))
+		}
+		return arr, nil
+	case TagList:
+		et, err := d.u8()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.charge(containerCost); err != nil {
+			return nil, err
+		}
+		if et == TagEnd && n > 0 {
+			return nil, fmt.Errorf("non-empty list of TAG_End")
+		}
+		list := List{Type: et, Items: make([]any, 0, min(n, 4096))}
+		for i := 0; i < n; i++ {
+			v, err := d.payload(et, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, v)
+		}
+		return list, nil
+	case TagCompound:
+		if err := d.charge(containerCost); err != nil {
+			return nil, err
+		}
+		c := make(Compound)
+		for {
+			ct, err := d.u8()
+			if err != nil {
+				return nil, err
+			}
+			if ct == TagEnd {
+				return c, nil
+			}
+			name, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.payload(ct, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			c[name] = v
+		}
+	}
+	return nil, fmt.Errorf("unknown tag type %d", t)
+}
@@ -0,0 +1,254 @@
+package nbt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Compression of an NBT file
+type Compression int
+
+const (
+	None Compression = iota // servers.dat
+	Gzip                    // level.dat, player data
+	Zlib                    // region chunks
+)
+
+// String returns the name of the compression
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zlib:
+		return "zlib"
+	}
+	return "none"
+}
+
+// Encode writes root as a named compound tag with the given compression.
+// Compound keys are written in sorted order so equal values encode identically.
+func Encode(w io.Writer, name string, root Compound, compression Compression) error {
+	var cw io.WriteCloser
+	switch compression {
+	case None:
+	case Gzip:
+		cw = gzip.NewWriter(w)
+	case Zlib:
+		cw = zlib.NewWriter(w)
+	default:
+		return fmt.Errorf("nbt: unknown compression %d", compression)
+	}
+
+	target := w
+	if cw != nil {
+		target = cw
+	}
+	bw := bufio.NewWriter(target)
+	e := &encoder{w: bw}
+	e.u8(TagCompound)
+	e.str(name)
+	e.payload(TagCompound, root, 0)
+	if e.err == nil {
+		e.err = bw.Flush()
+	}
+	if cw != nil {
+		if err := cw.Close(); err != nil && e.err == nil {
+			e.err = err
+		}
+	}
+	if e.err != nil {
+		return fmt.Errorf("nbt: %w", e.err)
+	}
+	return nil
+}
+
+// WriteFile encodes root to path. The file is replaced atomically, so the game
+// never reads a half-written file.
+func WriteFile(path, name string, root Compound, compression Compression) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Encode(tmp, name, root, compression); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// TypeOf returns the tag type a Go value encodes as, or TagEnd if it has none
+func TypeOf(v any) byte {
+	switch v.(type) {
+	case int8:
+		return TagByte
+	case int16:
+		return TagShort
+	case int32:
+		return TagInt
+	case int64:
+		return TagLong
+	case float32:
+		return TagFloat
+	case float64:
+		return TagDouble
+	case []byte:
+		return TagByteArray
+	case string:
+		return TagString
+	case List:
+		return TagList
+	case Compound:
+		return TagCompound
+	case []int32:
+		return TagIntArray
+	case []int64:
+		return TagLongArray
+	}
+	return TagEnd
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) u8(v byte) {
+	e.buf[0] = v
+	e.write(e.buf[:1])
+}
+
+func (e *encoder) u16(v uint16) {
+	binary.BigEndian.PutUint16(e.buf[:2], v)
+	e.write(e.buf[:2])
+}
+
+func (e *encoder) u32(v uint32) {
+	binary.BigEndian.PutUint32(e.buf[:4], v)
+	e.write(e.buf[:4])
+}
+
+func (e *encoder) u64(v uint64) {
+	binary.BigEndian.PutUint64(e.buf[:8], v)
+	e.write(e.buf[:8])
+}
+
+func (e *encoder) str(s string) {
+	b := encodeMUTF8(s)
+	if len(b) > math.MaxUint16 {
+		e.fail(fmt.Errorf("string of %d bytes exceeds the TAG_String limit", len(b)))
+		return
+	}
+	e.u16(uint16(len(b)))
+	e.write(b)
+}
+
+func (e *encoder) length(n int) {
+	if n > math.MaxInt32 {
+		e.fail(fmt.Errorf("length %d exceeds the NBT limit", n))
+		return
+	}
+	e.u32(uint32(n))
+}
+
+func (e *encoder) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *encoder) payload(t byte, v any, depth int) {
+	if e.err != nil {
+		return
+	}
+	if depth > maxDepth {
+		e.fail(ErrTooDeep)
+		return
+	}
+	switch t {
+	case TagByte:
+		e.u8(byte(v.(int8)))
+	case TagShort:
+		e.u16(uint16(v.(int16)))
+	case TagInt:
+		e.u32(uint32(v.(int32)))
+	case TagLong:
+		e.u64(uint64(v.(int64)))
+	case TagFloat:
+		e.u32(math.Float32bits(v.(float32)))
+	case TagDouble:
+		e.u64(math.Float64bits(v.(float64)))
+	case TagString:
+		e.str(v.(string))
+	case TagByteArray:
+		b := v.([]byte)
+		e.length(len(b))
+		e.write(b)
+	case TagIntArray:
+		arr := v.([]int32)
+		e.length(len(arr))
+		for _, x := range arr {
+			e.u32(uint32(x))
+		}
+	case TagLongArray:
+		arr := v.([]int64)
+		e.length(len(arr))
+		for _, x := range arr {
+			e.u64(uint64(x))
+		}
+	case TagList:
+		list := v.(List)
+		et := list.Type
+		if et == TagEnd && len(list.Items) > 0 {
+			et = TypeOf(list.Items[0])
+		}
+		e.u8(et)
+		e.length(len(list.Items))
+		for i, item := range list.Items {
+			if TypeOf(item) != et {
+				e.fail(fmt.Errorf("list item %d is %T, expected %s", i, item, TagName(et)))
+				return
+			}
+			e.payload(et, item, depth+1)
+		}
+	case TagCompound:
+		c := v.(Compound)
+		keys := make([]string, 0, len(c))
+		for k := range c {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ct := TypeOf(c[k])
+			if ct == TagEnd {
+				e.fail(fmt.Errorf("%s: unsupported value type %T", k, c[k]))
+				return
+			}
+			e.u8(ct)
+			e.str(k)
+			e.payload(ct, c[k], depth+1)
+		}
+		e.u8(TagEnd)
+	default:
+		e.fail(fmt.Errorf("unknown tag type %d", t))
+	}
+}
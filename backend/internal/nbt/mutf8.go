@@ -0,0 +1,98 @@
+package nbt
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Java writes NBT strings in modified UTF-8: NUL is encoded as two bytes and
+// characters outside the BMP as two three-byte surrogates instead of one
+// four-byte sequence. Everything else matches UTF-8.
+
+// decodeMUTF8 converts modified UTF-8 to a Go string. Malformed sequences and
+// unpaired surrogates become U+FFFD.
+func decodeMUTF8(b []byte) string {
+	ascii := true
+	for _, c := range b {
+		if c == 0 || c >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return string(b)
+	}
+
+	runes := make([]rune, 0, len(b))
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			runes = append(runes, rune(c))
+			i++
+		case c&0xe0 == 0xc0 && i+1 < len(b) && b[i+1]&0xc0 == 0x80:
+			runes = append(runes, rune(c&0x1f)<<6|rune(b[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0 && i+2 < len(b) && b[i+1]&0xc0 == 0x80 && b[i+2]&0xc0 == 0x80:
+			runes = append(runes, rune(c&0x0f)<<12|rune(b[i+1]&0x3f)<<6|rune(b[i+2]&0x3f))
+			i += 3
+		default:
+			runes = append(runes, utf8.RuneError)
+			i++
+		}
+	}
+
+	// Join surrogate pairs; leftovers are invalid in a Go string
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if utf16.IsSurrogate(r) {
+			if i+1 < len(runes) {
+				if joined := utf16.DecodeRune(r, runes[i+1]); joined != utf8.RuneError {
+					out = append(out, joined)
+					i++
+					continue
+				}
+			}
+			r = utf8.RuneError
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// encodeMUTF8 converts a Go string to modified UTF-8. Invalid UTF-8 is written as U+FFFD.
+func encodeMUTF8(s string) []byte {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0 || s[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return []byte(s)
+	}
+
+	b := make([]byte, 0, len(s)+8)
+	put := func(r rune) {
+		switch {
+		case r != 0 && r < 0x80:
+			b = append(b, byte(r))
+		case r < 0x800: // includes NUL as 0xc0 0x80
+			b = append(b, 0xc0|byte(r>>6), 0x80|byte(r&0x3f))
+		default:
+			b = append(b, 0xe0|byte(r>>12), 0x80|byte(r>>6&0x3f), 0x80|byte(r&0x3f))
+		}
+	}
+	for _, r := range s {
+		if r > 0xffff {
+			hi, lo := utf16.EncodeRune(r)
+			put(hi)
+			put(lo)
+			continue
+		}
+		put(r)
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+// Package nbt reads and writes Minecraft Java Edition NBT (Named Binary Tag) data,
+// the big-endian format of level.dat, servers.dat and player data.
+package nbt
+
+import "fmt"
+
+// Tag types
+const (
+	TagEnd       byte = 0
+	TagByte      byte = 1
+	TagShort     byte = 2
+	TagInt       byte = 3
+	TagLong      byte = 4
+	TagFloat     byte = 5
+	TagDouble    byte = 6
+	TagByteArray byte = 7
+	TagString    byte = 8
+	TagList      byte = 9
+	TagCompound  byte = 10
+	TagIntArray  byte = 11
+	TagLongArray byte = 12
+)
+
+// Decoded values use these Go types:
+//
+//	TAG_Byte       int8
+//	TAG_Short      int16
+//	TAG_Int        int32
+//	TAG_Long       int64
+//	TAG_Float      float32
+//	TAG_Double     float64
+//	TAG_Byte_Array []byte
+//	TAG_String     string
+//	TAG_List       List
+//	TAG_Compound   Compound
+//	TAG_Int_Array  []int32
+//	TAG_Long_Array []int64
+
+// Compound is a TAG_Compound
+type Compound map[string]any
+
+// List is a TAG_List. Type is kept so empty lists keep their element type.
+type List struct {
+	Type  byte
+	Items []any
+}
+
+// Compound returns the compound child key, or nil
+func (c Compound) Compound(key string) Compound {
+	v, _ := c[key].(Compound)
+	return v
+}
+
+// List returns the list child key
+func (c Compound) List(key string) List {
+	v, _ := c[key].(List)
+	return v
+}
+
+// String returns the string child key, or ""
+func (c Compound) String(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+// Int returns an integer child of any width as int64, or 0
+func (c Compound) Int(key string) int64 {
+	switch v := c[key].(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
+
+// Bool returns a TAG_Byte child as a boolean
+func (c Compound) Bool(key string) bool {
+	return c.Int(key) != 0
+}
+
+// TagName returns the display name of a tag type
+func TagName(t byte) string {
+	names := [...]string{"TAG_End", "TAG_Byte", "TAG_Short", "TAG_Int", "TAG_Long", "TAG_Float", "TAG_Double",
+		"TAG_Byte_Array", "TAG_String", "TAG_List", "TAG_Compound", "TAG_Int_Array", "TAG_Long_Array"}
+	if int(t) < len(names) {
+		return names[t]
+	}
+	return fmt.Sprintf("TAG_Unknown(%d)", t)
+}
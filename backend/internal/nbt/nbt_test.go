@@ -0,0 +1,162 @@
+package nbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sample() Compound {
+	return Compound{
+		"byte":   int8(-7),
+		"short":  int16(-300),
+		"int":    int32(1 << 30),
+		"long":   int64(-1 << 60),
+		"float":  float32(3.25),
+		"double": math.Pi,
+		"bytes":  []byte{0, 1, 0xff},
+		"string": "HyeniWorld",
+		"ints":   []int32{1, -2, 3},
+		"longs":  []int64{math.MaxInt64, math.MinInt64},
+		"list":   List{Type: TagString, Items: []any{"a", "b"}},
+		"empty":  List{Type: TagEnd, Items: []any{}},
+		"nested": Compound{
+			"servers": List{Type: TagCompound, Items: []any{
+				Compound{"name": "Hyeni", "ip": "play.example.com", "hidden": int8(0)},
+			}},
+			"lists": List{Type: TagList, Items: []any{
+				List{Type: TagInt, Items: []any{int32(1)}},
+			}},
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, c := range []Compression{None, Gzip, Zlib} {
+		t.Run(c.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, "root", sample(), c); err != nil {
+				t.Fatal(err)
+			}
+			name, root, detected, err := DecodeCompression(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != "root" || detected != c {
+				t.Fatalf("got name %q compression %s", name, detected)
+			}
+			if !reflect.DeepEqual(root, sample()) {
+				t.Fatalf("round trip mismatch:\n got %#v\nwant %#v", root, sample())
+			}
+		})
+	}
+}
+
+func TestModifiedUTF8(t *testing.T) {
+	for _, s := range []string{"", "plain", "nul\x00inside", "한국어", "emoji 🎮", strings.Repeat("é", 100)} {
+		enc := encodeMUTF8(s)
+		if bytes.IndexByte(enc, 0) >= 0 {
+			t.Errorf("%q: encoded form contains NUL", s)
+		}
+		if got := decodeMUTF8(enc); got != s {
+			t.Errorf("%q: decoded as %q", s, got)
+		}
+	}
+	// Supplementary characters are two three-byte surrogates
+	if got := encodeMUTF8("🎮"); len(got) != 6 {
+		t.Errorf("supplementary character encoded in %d bytes, want 6", len(got))
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var valid bytes.Buffer
+	if err := Encode(&valid, "", sample(), None); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string][]byte{
+		"empty":          {},
+		"non-compound":   {TagInt, 0, 0, 0, 0, 0, 1},
+		"truncated":      valid.Bytes()[:valid.Len()/2],
+		"negative array": {TagCompound, 0, 0, TagIntArray, 0, 1, 'a', 0xff, 0xff, 0xff, 0xff, TagEnd},
+		"unknown tag":    {TagCompound, 0, 0, 42, 0, 1, 'a', TagEnd},
+		"list of end":    {TagCompound, 0, 0, TagList, 0, 1, 'a', TagEnd, 0, 0, 0, 1, TagEnd},
+	}
+	for name, data := range cases {
+		if _, _, err := Decode(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	deep := []byte{TagCompound, 0, 0}
+	for i := 0; i < maxDepth+2; i++ {
+		deep = append(deep, TagCompound, 0, 0)
+	}
+	if _, _, err := Decode(bytes.NewReader(deep)); err == nil {
+		t.Error("deep nesting: expected an error")
+	}
+}
+
+func TestDecodeSizeLimit(t *testing.T) {
+	// A list of 2^24 empty compounds is 16 MiB of zeros, a few KiB compressed
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte{TagCompound, 0, 0, TagList, 0, 1, 'a', TagCompound, 0x01, 0, 0, 0})
+	gz.Write(make([]byte, 1<<24))
+	gz.Close()
+
+	if _, _, err := Decode(&buf); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	cases := map[string]Compound{
+		"unsupported": {"x": 1},
+		"mixed list":  {"x": List{Type: TagInt, Items: []any{int32(1), "a"}}},
+		"long string": {"x": strings.Repeat("a", math.MaxUint16+1)},
+	}
+	for name, root := range cases {
+		if err := Encode(&bytes.Buffer{}, "", root, None); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// FuzzDecode checks that decoding never panics and that anything decoded
+// re-encodes to bytes that decode to the same encoding again
+func FuzzDecode(f *testing.F) {
+	for _, c := range []Compression{None, Gzip, Zlib} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, "seed", sample(), c); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+	f.Add([]byte{TagCompound, 0, 0, TagEnd})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		name, root, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		var first bytes.Buffer
+		if err := Encode(&first, name, root, None); err != nil {
+			return // e.g. a string that grew past the limit when malformed bytes became U+FFFD
+		}
+		name2, root2, err := Decode(bytes.NewReader(first.Bytes()))
+		if err != nil {
+			t.Fatalf("re-decode failed: %v", err)
+		}
+		var second bytes.Buffer
+		if err := Encode(&second, name2, root2, None); err != nil {
+			t.Fatalf("re-encode failed: %v", err)
+		}
+		if !bytes.Equal(first.Bytes(), second.Bytes()) {
+			t.Fatal("encoding is not stable across a round trip")
+		}
+	})
+}
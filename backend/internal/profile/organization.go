@@ -0,0 +1,383 @@
+package profile
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"hyenimc/backend/internal/domain"
+)
+
+// Group is a user-defined, ordered group of profiles
+type Group struct {
+	ID           string
+	Name         string
+	SortOrder    int
+	ProfileCount int
+	CreatedAt    time.Time
+}
+
+// TagCount is a tag and how many profiles carry it
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// loadOrganization fills Tags, GroupID and GroupOrder of profiles
+func (r *Repository) loadOrganization(profiles ...*domain.Profile) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+	byID := make(map[string]*domain.Profile, len(profiles))
+	for _, p := range profiles {
+		byID[p.ID] = p
+		p.Tags, p.GroupID, p.GroupOrder = nil, "", 0
+	}
+
+	// A single profile (Get) reads only its own rows
+	where, args := "", []any{}
+	if len(profiles) == 1 {
+		where, args = " WHERE profile_id = ?", []any{profiles[0].ID}
+	}
+
+	rows, err := r.db.Query("SELECT profile_id, tag FROM profile_tags"+where+" ORDER BY profile_id, tag COLLATE NOCASE", args...)
+	if err != nil {
+		return fmt.Errorf("failed to load profile tags: %w", err)
+	}
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan profile tag: %w", err)
+		}
+		if p := byID[id]; p != nil {
+			p.Tags = append(p.Tags, tag)
+		}
+	}
+	rows.Close()
+
+	rows, err = r.db.Query("SELECT profile_id, group_id, sort_order FROM profile_group_members"+where, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load profile groups: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, groupID string
+		var order int
+		if err := rows.Scan(&id, &groupID, &order); err != nil {
+			return fmt.Errorf("failed to scan profile group: %w", err)
+		}
+		if p := byID[id]; p != nil {
+			p.GroupID, p.GroupOrder = groupID, order
+		}
+	}
+	return rows.Err()
+}
+
+// saveOrganization writes the tags and group of p, used when a profile row is
+// recreated from a snapshot. A group that no longer exists is dropped.
+func saveOrganization(db execer, p *domain.Profile) error {
+	if _, err := db.Exec("DELETE FROM profile_tags WHERE profile_id = ?", p.ID); err != nil {
+		return fmt.Errorf("failed to clear profile tags: %w", err)
+	}
+	for _, tag := range p.Tags {
+		if _, err := db.Exec("INSERT OR IGNORE INTO profile_tags (profile_id, tag) VALUES (?, ?)", p.ID, tag); err != nil {
+			return fmt.Errorf("failed to save profile tag: %w", err)
+		}
+	}
+	if _, err := db.Exec("DELETE FROM profile_group_members WHERE profile_id = ?", p.ID); err != nil {
+		return fmt.Errorf("failed to clear profile group: %w", err)
+	}
+	if p.GroupID != "" {
+		if _, err := db.Exec(`
+			INSERT INTO profile_group_members (profile_id, group_id, sort_order)
+			SELECT ?, id, ? FROM profile_groups WHERE id = ?
+		`, p.ID, p.GroupOrder, p.GroupID); err != nil {
+			return fmt.Errorf("failed to save profile group: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetTags replaces the tags of a profile
+func (r *Repository) SetTags(profileID string, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM profile_tags WHERE profile_id = ?", profileID); err != nil {
+		return fmt.Errorf("failed to clear profile tags: %w", err)
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO profile_tags (profile_id, tag) VALUES (?, ?)", profileID, tag); err != nil {
+			return fmt.Errorf("failed to save profile tag: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTags returns every tag in use with its profile count, alphabetically
+func (r *Repository) ListTags() ([]TagCount, error) {
+	rows, err := r.db.Query(`
+		SELECT MIN(tag), COUNT(*) FROM profile_tags
+		GROUP BY tag COLLATE NOCASE
+		ORDER BY tag COLLATE NOCASE
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var t TagCount
+		if err := rows.Scan(&t.Tag, &t.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// CreateGroup adds a group after the existing ones
+func (r *Repository) CreateGroup(g *Group) error {
+	g.CreatedAt = time.Now()
+	if _, err := r.db.Exec(`
+		INSERT INTO profile_groups (id, name, sort_order, created_at)
+		VALUES (?, ?, (SELECT COALESCE(MAX(sort_order) + 1, 0) FROM profile_groups), ?)
+	`, g.ID, g.Name, g.CreatedAt.Unix()); err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+	return r.db.QueryRow("SELECT sort_order FROM profile_groups WHERE id = ?", g.ID).Scan(&g.SortOrder)
+}
+
+// GetGroup retrieves a group by ID
+func (r *Repository) GetGroup(id string) (*Group, error) {
+	row := r.db.QueryRow(`
+		SELECT g.id, g.name, g.sort_order, g.created_at,
+			(SELECT COUNT(*) FROM profile_group_members m WHERE m.group_id = g.id)
+		FROM profile_groups g WHERE g.id = ?
+	`, id)
+	g, err := scanGroup(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("group not found: %s", id)
+	}
+	return g, err
+}
+
+// ListGroups returns all groups in display order
+func (r *Repository) ListGroups() ([]*Group, error) {
+	rows, err := r.db.Query(`
+		SELECT g.id, g.name, g.sort_order, g.created_at,
+			(SELECT COUNT(*) FROM profile_group_members m WHERE m.group_id = g.id)
+		FROM profile_groups g
+		ORDER BY g.sort_order, g.created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []*Group{}
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// RenameGroup changes the name of a group
+func (r *Repository) RenameGroup(id, name string) error {
+	result, err := r.db.Exec("UPDATE profile_groups SET name = ? WHERE id = ?", name, id)
+	if err != nil {
+		return fmt.Errorf("failed to rename group: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("group not found: %s", id)
+	}
+	return nil
+}
+
+// DeleteGroup removes a group; its profiles become ungrouped
+func (r *Repository) DeleteGroup(id string) error {
+	result, err := r.db.Exec("DELETE FROM profile_groups WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("group not found: %s", id)
+	}
+	return nil
+}
+
+// ReorderGroups sets the group order to ids. Groups not listed keep their
+// relative order after the listed ones.
+func (r *Repository) ReorderGroups(ids []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM profile_groups ORDER BY sort_order, created_at")
+	if err != nil {
+		return fmt.Errorf("failed to list groups: %w", err)
+	}
+	existing, err := scanIDs(rows)
+	if err != nil {
+		return err
+	}
+	order, err := mergeOrder(existing, ids, "group")
+	if err != nil {
+		return err
+	}
+	for i, id := range order {
+		if _, err := tx.Exec("UPDATE profile_groups SET sort_order = ? WHERE id = ?", i, id); err != nil {
+			return fmt.Errorf("failed to reorder groups: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// AssignGroup moves a profile to the end of a group; an empty groupID ungroups it
+func (r *Repository) AssignGroup(profileID, groupID string) error {
+	if groupID == "" {
+		if _, err := r.db.Exec("DELETE FROM profile_group_members WHERE profile_id = ?", profileID); err != nil {
+			return fmt.Errorf("failed to ungroup profile: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow("SELECT group_id FROM profile_group_members WHERE profile_id = ?", profileID).Scan(&current)
+	if err == nil && current == groupID {
+		return nil // already there; keep its position
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read profile group: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO profile_group_members (profile_id, group_id, sort_order)
+		SELECT ?, id, (SELECT COALESCE(MAX(sort_order) + 1, 0) FROM profile_group_members WHERE group_id = ?)
+		FROM profile_groups WHERE id = ?
+		ON CONFLICT(profile_id) DO UPDATE SET group_id = excluded.group_id, sort_order = excluded.sort_order
+	`, profileID, groupID, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to assign group: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+	return tx.Commit()
+}
+
+// ReorderGroupProfiles sets the order of profiles inside a group. Members not
+// listed keep their relative order after the listed ones.
+func (r *Repository) ReorderGroupProfiles(groupID string, profileIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT profile_id FROM profile_group_members WHERE group_id = ? ORDER BY sort_order", groupID)
+	if err != nil {
+		return fmt.Errorf("failed to list group members: %w", err)
+	}
+	existing, err := scanIDs(rows)
+	if err != nil {
+		return err
+	}
+	order, err := mergeOrder(existing, profileIDs, "profile in group")
+	if err != nil {
+		return err
+	}
+	for i, id := range order {
+		if _, err := tx.Exec("UPDATE profile_group_members SET sort_order = ? WHERE profile_id = ?", i, id); err != nil {
+			return fmt.Errorf("failed to reorder group: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func scanGroup(row rowScanner) (*Group, error) {
+	var g Group
+	var createdAt int64
+	if err := row.Scan(&g.ID, &g.Name, &g.SortOrder, &createdAt, &g.ProfileCount); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan group: %w", err)
+	}
+	g.CreatedAt = time.Unix(createdAt, 0)
+	return &g, nil
+}
+
+func scanIDs(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// mergeOrder puts wanted first, followed by the rest of existing in their
+// current order. Every wanted ID must be in existing.
+func mergeOrder(existing, wanted []string, kind string) ([]string, error) {
+	known := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+	order := make([]string, 0, len(existing))
+	seen := make(map[string]bool, len(wanted))
+	for _, id := range wanted {
+		if !known[id] {
+			return nil, fmt.Errorf("unknown %s: %s", kind, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			order = append(order, id)
+		}
+	}
+	for _, id := range existing {
+		if !seen[id] {
+			order = append(order, id)
+		}
+	}
+	return order, nil
+}
+
+// NormalizeTags trims tags, drops empty ones and removes case-insensitive
+// duplicates, keeping the first spelling
+func NormalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, tag)
+	}
+	return out
+}
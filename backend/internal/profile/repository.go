@@ -3,12 +3,16 @@ package profile
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"hyenimc/backend/internal/domain"
 )
 
+// ErrNotFound is returned when no profile has the requested id
+var ErrNotFound = errors.New("profile not found")
+
 // Repository handles profile persistence in SQLite
 type Repository struct {
 	db *sql.DB
@@ -19,8 +23,17 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// execer is satisfied by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 // Save inserts or updates a profile
 func (r *Repository) Save(profile *domain.Profile) error {
+	return save(r.db, profile)
+}
+
+func save(db execer, profile *domain.Profile) error {
 	jvmArgs, _ := json.Marshal(profile.JvmArgs)
 	gameArgs, _ := json.Marshal(profile.GameArgs)
 	
@@ -61,7 +74,7 @@ func (r *Repository) Save(profile *domain.Profile) error {
 		serverAddr = sql.NullString{String: profile.ServerAddress, Valid: true}
 	}
 	
-	_, err := r.db.Exec(`
+	_, err := db.Exec(`
 		INSERT INTO profiles (
 			id, name, description, icon, game_version, loader_type, loader_version,
 			game_directory, java_path, memory_min, memory_max, resolution_width,
@@ -139,7 +152,7 @@ func (r *Repository) Get(id string) (*domain.Profile, error) {
 	)
 	
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("profile not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get profile: %w", err)
@@ -177,6 +190,10 @@ func (r *Repository) Get(id string) (*domain.Profile, error) {
 		profile.LastPlayed = time.Unix(lastPlayed.Int64, 0)
 	}
 	
+	if err := r.loadOrganization(&profile); err != nil {
+		return nil, err
+	}
+	
 	return &profile, nil
 }
 
@@ -255,12 +272,23 @@ func (r *Repository) List() ([]*domain.Profile, error) {
 		profiles = append(profiles, &profile)
 	}
 	
+	if err := r.loadOrganization(profiles...); err != nil {
+		return nil, err
+	}
+	
 	return profiles, nil
 }
 
-// Delete removes a profile
+// Delete removes a profile permanently, with its snapshots (which do not cascade,
+// so they survive the trash)
 func (r *Repository) Delete(id string) error {
-	result, err := r.db.Exec("DELETE FROM profiles WHERE id = ?", id)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM profiles WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete profile: %w", err)
 	}
@@ -270,10 +298,13 @@ func (r *Repository) Delete(id string) error {
 		return err
 	}
 	if rows == 0 {
-		return fmt.Errorf("profile not found: %s", id)
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if _, err := tx.Exec("DELETE FROM profile_snapshots WHERE profile_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete snapshots: %w", err)
 	}
 	
-	return nil
+	return tx.Commit()
 }
 
 // Update updates an existing profile
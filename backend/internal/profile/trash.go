@@ -0,0 +1,154 @@
+package profile
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hyenimc/backend/internal/domain"
+)
+
+// TrashEntry is a deleted profile waiting for restore or purge
+type TrashEntry struct {
+	ID           string
+	ProfileID    string
+	Name         string
+	Profile      *domain.Profile // snapshot of the profile row at deletion
+	OriginalPath string          // game directory before deletion
+	TrashPath    string          // where the instance directory was moved, empty if it was left in place
+	SizeBytes    int64
+	DeletedAt    time.Time
+}
+
+// MoveToTrash records entry and deletes its profile in one transaction
+func (r *Repository) MoveToTrash(entry *TrashEntry) error {
+	data, err := json.Marshal(entry.Profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO profile_trash (id, profile_id, name, profile_json, original_path, trash_path, size_bytes, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ID, entry.ProfileID, entry.Name, string(data), entry.OriginalPath, entry.TrashPath,
+		entry.SizeBytes, entry.DeletedAt.Unix()); err != nil {
+		return fmt.Errorf("failed to save trash entry: %w", err)
+	}
+
+	result, err := tx.Exec("DELETE FROM profiles WHERE id = ?", entry.ProfileID)
+	if err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, entry.ProfileID)
+	}
+
+	return tx.Commit()
+}
+
+// GetTrash retrieves a trash entry by ID
+func (r *Repository) GetTrash(id string) (*TrashEntry, error) {
+	row := r.db.QueryRow(`
+		SELECT id, profile_id, name, profile_json, original_path, trash_path, size_bytes, deleted_at
+		FROM profile_trash WHERE id = ?
+	`, id)
+	entry, err := scanTrash(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("trash entry not found: %s", id)
+	}
+	return entry, err
+}
+
+// ListTrash returns all trash entries, most recently deleted first
+func (r *Repository) ListTrash() ([]*TrashEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, profile_id, name, profile_json, original_path, trash_path, size_bytes, deleted_at
+		FROM profile_trash ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*TrashEntry
+	for rows.Next() {
+		entry, err := scanTrash(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteTrash removes a trash entry together with the snapshots of its profile
+func (r *Repository) DeleteTrash(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Snapshots outlive the profile row while it is in the trash; they go with the entry
+	if _, err := tx.Exec(`
+		DELETE FROM profile_snapshots
+		WHERE profile_id = (SELECT profile_id FROM profile_trash WHERE id = ?)
+		AND profile_id NOT IN (SELECT id FROM profiles)
+	`, id); err != nil {
+		return fmt.Errorf("failed to delete snapshots of trash entry: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM profile_trash WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete trash entry: %w", err)
+	}
+	return tx.Commit()
+}
+
+// RestoreFromTrash saves the profile of entry back and removes the entry in one transaction
+func (r *Repository) RestoreFromTrash(entry *TrashEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM profile_trash WHERE id = ?", entry.ID); err != nil {
+		return fmt.Errorf("failed to delete trash entry: %w", err)
+	}
+	if err := save(tx, entry.Profile); err != nil {
+		return err
+	}
+	if err := saveOrganization(tx, entry.Profile); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTrash(row rowScanner) (*TrashEntry, error) {
+	var entry TrashEntry
+	var data string
+	var deletedAt int64
+	if err := row.Scan(&entry.ID, &entry.ProfileID, &entry.Name, &data, &entry.OriginalPath,
+		&entry.TrashPath, &entry.SizeBytes, &deletedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan trash entry: %w", err)
+	}
+	entry.Profile = &domain.Profile{}
+	if err := json.Unmarshal([]byte(data), entry.Profile); err != nil {
+		return nil, fmt.Errorf("invalid profile snapshot in trash entry %s: %w", entry.ID, err)
+	}
+	entry.DeletedAt = time.Unix(deletedAt, 0)
+	return &entry, nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/keystore"
+)
+
+// KeyRotationResult summarizes an encryption key rotation
+type KeyRotationResult struct {
+	KeyID       string
+	Reencrypted int
+	Skipped     int // rows encrypted with a key other than the previous one
+}
+
+// RotateEncryptionKey generates a new account encryption key and re-encrypts every
+// stored account with it in one transaction. The previous key is deleted from the key
+// store only after the transaction commits with no row still referencing it; if any
+// row under the previous key cannot be re-encrypted, the rotation is aborted.
+func (s *AccountService) RotateEncryptionKey(ctx context.Context) (*KeyRotationResult, error) {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	if s.encryptionKey == nil {
+		return nil, ErrKeyringUnavailable
+	}
+	if s.keyStore == nil {
+		return nil, fmt.Errorf("no key store configured")
+	}
+
+	oldKey, oldID := s.encryptionKey, s.keyID
+	newID := fmt.Sprintf("k%d", time.Now().UnixNano())
+	newKey, err := keystore.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// Persist the new key first so a crash after commit never leaves rows without their key
+	newName := keystore.AccountKeyNameFor(newID)
+	if err := s.keyStore.Set(newName, newKey); err != nil {
+		return nil, fmt.Errorf("failed to store new key: %w", err)
+	}
+	if got, err := s.keyStore.Get(newName); err != nil || !bytes.Equal(got, newKey) {
+		s.keyStore.Delete(newName)
+		return nil, fmt.Errorf("failed to verify new key in %s: %v", s.keyStore.Name(), err)
+	}
+
+	rows, err := s.repo.ListEncrypted()
+	if err != nil {
+		s.keyStore.Delete(newName)
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	result := &KeyRotationResult{KeyID: newID}
+	var updated []*domain.Account
+	for _, acc := range rows {
+		if err := ctx.Err(); err != nil {
+			s.keyStore.Delete(newName)
+			return nil, err
+		}
+		if acc.KeyID != oldID {
+			log.Printf("[Account] Key rotation skipping account %s: encrypted with unknown key %q", acc.ID, acc.KeyID)
+			result.Skipped++
+			continue
+		}
+		plaintext, err := decryptWithKey(oldKey, acc.EncryptedData, acc.IV, acc.AuthTag)
+		if err != nil {
+			// Retiring the key would make this row unreadable for good
+			s.keyStore.Delete(newName)
+			return nil, fmt.Errorf("aborting key rotation: account %s cannot be decrypted: %w", acc.ID, err)
+		}
+		encrypted, iv, authTag, err := encryptWithKey(newKey, plaintext)
+		if err != nil {
+			s.keyStore.Delete(newName)
+			return nil, fmt.Errorf("failed to encrypt account %s: %w", acc.ID, err)
+		}
+		updated = append(updated, &domain.Account{ID: acc.ID, EncryptedData: encrypted, IV: iv, AuthTag: authTag})
+	}
+
+	if err := s.repo.RotateKey(oldID, newID, updated); err != nil {
+		s.keyStore.Delete(newName)
+		return nil, fmt.Errorf("failed to re-encrypt accounts: %w", err)
+	}
+
+	s.encryptionKey, s.keyID = newKey, newID
+	if err := s.keyStore.Delete(keystore.AccountKeyNameFor(oldID)); err != nil {
+		log.Printf("[Account] Failed to delete previous key from %s: %v", s.keyStore.Name(), err)
+	}
+	for i := range oldKey {
+		oldKey[i] = 0
+	}
+
+	result.Reencrypted = len(updated)
+	log.Printf("[Account] Rotated encryption key to %s (%d accounts re-encrypted, %d skipped)", newID, result.Reencrypted, result.Skipped)
+	return result, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hyenimc/backend/internal/account"
+	"hyenimc/backend/internal/db"
+	"hyenimc/backend/internal/keystore"
+)
+
+// openTestDB returns a migrated database in a temporary directory
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := db.Open(filepath.Join(t.TempDir(), "hyenimc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// newTestAccountService returns an account service whose key lives in an in-memory store
+func newTestAccountService(t *testing.T) (*AccountService, *sql.DB, *keystore.MemoryProvider) {
+	t.Helper()
+	conn := openTestDB(t)
+	store := keystore.NewMemoryProvider()
+	key, err := keystore.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set(keystore.AccountKeyName, key)
+	svc := NewAccountService(account.NewRepository(conn), key, "", store, "device", nil, t.TempDir())
+	return svc, conn, store
+}
+
+func addTestAccount(t *testing.T, svc *AccountService, uuid, refreshToken string) string {
+	t.Helper()
+	id, err := svc.SaveMicrosoftAccount(context.Background(), "player", uuid, "access", refreshToken, time.Now().Add(time.Hour).UnixMilli(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRotateEncryptionKey(t *testing.T) {
+	svc, conn, store := newTestAccountService(t)
+	ctx := context.Background()
+	first := addTestAccount(t, svc, "00000000000000000000000000000001", "refresh-1")
+	second := addTestAccount(t, svc, "00000000000000000000000000000002", "refresh-2")
+	// A row from another installation, encrypted with a key we never had
+	conn.Exec(`UPDATE accounts SET key_id = 'foreign' WHERE id = ?`, second)
+
+	result, err := svc.RotateEncryptionKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reencrypted != 1 || result.Skipped != 1 {
+		t.Errorf("result %+v", result)
+	}
+	tokens, err := svc.GetAccountTokens(ctx, first)
+	if err != nil || tokens.RefreshToken != "refresh-1" {
+		t.Fatalf("tokens after rotation: %v %v", tokens, err)
+	}
+	if _, err := store.Get(keystore.AccountKeyName); !errors.Is(err, keystore.ErrNotFound) {
+		t.Errorf("previous key should be retired, got %v", err)
+	}
+	if _, err := store.Get(keystore.AccountKeyNameFor(result.KeyID)); err != nil {
+		t.Errorf("new key missing: %v", err)
+	}
+}
+
+func TestRotateEncryptionKeyAbortsOnUnreadableRow(t *testing.T) {
+	svc, conn, store := newTestAccountService(t)
+	ctx := context.Background()
+	good := addTestAccount(t, svc, "00000000000000000000000000000001", "refresh-1")
+	bad := addTestAccount(t, svc, "00000000000000000000000000000002", "refresh-2")
+	conn.Exec(`UPDATE accounts SET auth_tag = ? WHERE id = ?`, "00000000000000000000000000000000", bad)
+
+	if _, err := svc.RotateEncryptionKey(ctx); err == nil {
+		t.Fatal("expected the rotation to be aborted")
+	}
+
+	// Nothing changed: same key, same rows
+	if _, err := store.Get(keystore.AccountKeyName); err != nil {
+		t.Fatalf("previous key was retired: %v", err)
+	}
+	var moved int
+	conn.QueryRow(`SELECT COUNT(*) FROM accounts WHERE key_id != ''`).Scan(&moved)
+	if moved != 0 {
+		t.Errorf("%d rows were moved to the new key", moved)
+	}
+	if active, _ := svc.repo.ActiveKeyID(); active != "" {
+		t.Errorf("active key changed to %q", active)
+	}
+	if tokens, err := svc.GetAccountTokens(ctx, good); err != nil || tokens.RefreshToken != "refresh-1" {
+		t.Errorf("good account unreadable after aborted rotation: %v", err)
+	}
+}
+
+func TestRotateKeyRefusesWhileRowsUseOldKey(t *testing.T) {
+	svc, conn, _ := newTestAccountService(t)
+	id := addTestAccount(t, svc, "00000000000000000000000000000001", "refresh-1")
+
+	// A row saved under the old key but left out of the re-encrypted set
+	if err := svc.repo.RotateKey("", "k2", nil); err == nil {
+		t.Fatal("expected an error while a row still uses the old key")
+	}
+	var keyID string
+	conn.QueryRow(`SELECT key_id FROM accounts WHERE id = ?`, id).Scan(&keyID)
+	if keyID != "" {
+		t.Errorf("row moved to %q", keyID)
+	}
+}
+
+func TestAccountServiceWithoutKey(t *testing.T) {
+	svc, conn, _ := newTestAccountService(t)
+	ctx := context.Background()
+	id := addTestAccount(t, svc, "00000000000000000000000000000001", "refresh")
+
+	// The keyring was locked at startup: the same rows, no key
+	locked := NewAccountService(account.NewRepository(conn), nil, "", nil, "device", nil, t.TempDir())
+	if _, err := locked.GetAccountTokens(ctx, id); !errors.Is(err, ErrKeyringUnavailable) {
+		t.Errorf("read tokens: %v", err)
+	}
+	if err := locked.UpdateAccountTokens(ctx, id, "access", "refresh", 0); !errors.Is(err, ErrKeyringUnavailable) {
+		t.Errorf("write tokens: %v", err)
+	}
+	if _, err := locked.RotateEncryptionKey(ctx); !errors.Is(err, ErrKeyringUnavailable) {
+		t.Errorf("rotate: %v", err)
+	}
+	if h, err := locked.GetAccountAuthHealth(ctx, id); err != nil || h.Status != AuthStatusExpired {
+		t.Errorf("health %+v, %v", h, err)
+	}
+
+	// Everything that does not touch tokens keeps working
+	if accounts, err := locked.GetAllAccounts(ctx); err != nil || len(accounts) != 1 {
+		t.Errorf("list: %d, %v", len(accounts), err)
+	}
+	if _, err := locked.AddOfflineAccount(ctx, "Steve"); err != nil {
+		t.Errorf("offline account: %v", err)
+	}
+
+	// The stored tokens are untouched for when the key is back
+	if tokens, err := svc.GetAccountTokens(ctx, id); err != nil || tokens.RefreshToken != "refresh" {
+		t.Errorf("tokens %v, %v", tokens, err)
+	}
+}
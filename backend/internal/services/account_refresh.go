@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/msauth"
+)
+
+// Auth health states reported per Microsoft account
+const (
+	AuthStatusValid        = "valid"         // token is not close to expiry
+	AuthStatusRefreshed    = "refreshed"     // token was refreshed by the scheduler
+	AuthStatusExpired      = "expired"       // token expired and the last refresh failed transiently
+	AuthStatusNeedsRelogin = "needs_relogin" // refresh token rejected, user must sign in again
+)
+
+// Default token refresh schedule (Minecraft access tokens live for 24 hours)
+const (
+	TokenRefreshInterval = 15 * time.Minute
+	TokenRefreshWindow   = 2 * time.Hour
+)
+
+// AuthHealth is the last known authentication state of an account
+type AuthHealth struct {
+	AccountID   string
+	Status      string
+	ExpiresAt   int64 // unix ms
+	CheckedAt   time.Time
+	RefreshedAt time.Time
+	Error       string
+}
+
+// StartTokenRefresher scans Microsoft accounts every interval and refreshes tokens
+// expiring within window. It stops when ctx is cancelled and does not run without
+// an encryption key.
+func (s *AccountService) StartTokenRefresher(ctx context.Context, interval, window time.Duration) {
+	if s.encryptionKey == nil {
+		log.Printf("[Account] Token refresh disabled: %v", ErrKeyringUnavailable)
+		return
+	}
+	go func() {
+		s.RefreshExpiringTokens(ctx, window)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RefreshExpiringTokens(ctx, window)
+			}
+		}
+	}()
+}
+
+// RefreshExpiringTokens refreshes every Microsoft account whose token expires within window
+func (s *AccountService) RefreshExpiringTokens(ctx context.Context, window time.Duration) {
+	accounts, err := s.GetAllAccounts(ctx)
+	if err != nil {
+		log.Printf("[Account] Token refresh scan failed: %v", err)
+		return
+	}
+	for _, acc := range accounts {
+		if ctx.Err() != nil {
+			return
+		}
+		if acc.Type != "microsoft" {
+			continue
+		}
+		h := s.refreshAccount(ctx, acc, window)
+		if h.Status == AuthStatusRefreshed {
+			log.Printf("[Account] Refreshed tokens for %s", acc.Name)
+		} else if h.Error != "" {
+			log.Printf("[Account] Token refresh for %s: %s (%s)", acc.Name, h.Status, h.Error)
+		}
+	}
+}
+
+// RefreshAccount refreshes a single account. Without force, the token is only
+// refreshed when it expires within window.
+func (s *AccountService) RefreshAccount(ctx context.Context, accountID string, force bool, window time.Duration) (*AuthHealth, error) {
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Type != "microsoft" {
+		return nil, fmt.Errorf("not a Microsoft account")
+	}
+	if force {
+		window = -1
+	}
+	return s.refreshAccount(ctx, acc, window), nil
+}
+
+// GetAuthHealth returns the auth health of every Microsoft account on this device
+func (s *AccountService) GetAuthHealth(ctx context.Context) ([]*AuthHealth, error) {
+	accounts, err := s.GetAllAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []*AuthHealth
+	for _, acc := range accounts {
+		if acc.Type != "microsoft" {
+			continue
+		}
+		result = append(result, s.accountHealth(ctx, acc))
+	}
+	return result, nil
+}
+
+// GetAccountAuthHealth returns the auth health of one Microsoft account
+func (s *AccountService) GetAccountAuthHealth(ctx context.Context, accountID string) (*AuthHealth, error) {
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Type != "microsoft" {
+		return nil, fmt.Errorf("not a Microsoft account")
+	}
+	return s.accountHealth(ctx, acc), nil
+}
+
+// accountHealth returns the recorded health, or derives it from the stored tokens
+func (s *AccountService) accountHealth(ctx context.Context, acc *domain.Account) *AuthHealth {
+	s.healthMu.Lock()
+	h, ok := s.health[acc.ID]
+	s.healthMu.Unlock()
+	if ok {
+		cp := *h
+		return &cp
+	}
+
+	h = &AuthHealth{AccountID: acc.ID, CheckedAt: time.Now()}
+	tokens, err := s.GetAccountTokens(ctx, acc.ID)
+	switch {
+	case errors.Is(err, ErrKeyringUnavailable):
+		// Signing in again would not help; the stored tokens are fine
+		h.Status = AuthStatusExpired
+		h.Error = err.Error()
+	case err != nil:
+		h.Status = AuthStatusNeedsRelogin
+		h.Error = err.Error()
+	case tokens.ExpiresAt <= time.Now().UnixMilli():
+		h.Status = AuthStatusExpired
+		h.ExpiresAt = tokens.ExpiresAt
+	default:
+		h.Status = AuthStatusValid
+		h.ExpiresAt = tokens.ExpiresAt
+	}
+	return h
+}
+
+// refreshAccount refreshes acc if its token expires within window (negative window forces)
+// and records the resulting health
+func (s *AccountService) refreshAccount(ctx context.Context, acc *domain.Account, window time.Duration) *AuthHealth {
+	// Microsoft rotates refresh tokens, so never run two refreshes at once
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	now := time.Now()
+	h := &AuthHealth{AccountID: acc.ID, CheckedAt: now}
+	s.healthMu.Lock()
+	if prev, ok := s.health[acc.ID]; ok {
+		h.RefreshedAt = prev.RefreshedAt
+	}
+	s.healthMu.Unlock()
+	defer func() {
+		s.healthMu.Lock()
+		s.health[acc.ID] = h
+		s.healthMu.Unlock()
+	}()
+
+	tokens, err := s.GetAccountTokens(ctx, acc.ID)
+	if err != nil {
+		h.Status = AuthStatusNeedsRelogin
+		if errors.Is(err, ErrKeyringUnavailable) {
+			h.Status = AuthStatusExpired
+		}
+		h.Error = err.Error()
+		return h
+	}
+	h.ExpiresAt = tokens.ExpiresAt
+
+	if window >= 0 && tokens.ExpiresAt > now.Add(window).UnixMilli() {
+		h.Status = AuthStatusValid
+		return h
+	}
+	if tokens.RefreshToken == "" {
+		h.Status = AuthStatusNeedsRelogin
+		h.Error = "no refresh token stored"
+		return h
+	}
+
+	if s.msAuth == nil {
+		h.Status = AuthStatusExpired
+		h.Error = msauth.ErrMissingClientID.Error()
+		if tokens.ExpiresAt > now.UnixMilli() {
+			h.Status = AuthStatusValid
+		}
+		return h
+	}
+
+	session, err := s.msAuth.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		h.Error = err.Error()
+		if needsRelogin(err) {
+			h.Status = AuthStatusNeedsRelogin
+		} else if tokens.ExpiresAt > time.Now().UnixMilli() {
+			h.Status = AuthStatusValid
+		} else {
+			h.Status = AuthStatusExpired
+		}
+		return h
+	}
+
+	if err := s.updateTokens(ctx, acc.ID, session.AccessToken, session.RefreshToken, session.ExpiresAt, false); err != nil {
+		h.Status = AuthStatusExpired
+		h.Error = fmt.Sprintf("failed to store refreshed tokens: %v", err)
+		return h
+	}
+	h.Status = AuthStatusRefreshed
+	h.ExpiresAt = session.ExpiresAt
+	h.RefreshedAt = time.Now()
+	return h
+}
+
+// needsRelogin reports whether a refresh failure can only be fixed by signing in again
+func needsRelogin(err error) bool {
+	return errors.Is(err, msauth.ErrRefreshTokenInvalid) ||
+		errors.Is(err, msauth.ErrNoXboxAccount) ||
+		errors.Is(err, msauth.ErrXboxUnavailable) ||
+		errors.Is(err, msauth.ErrChildAccount)
+}
@@ -8,30 +8,115 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
 	"hyenimc/backend/internal/account"
 	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/keystore"
+	"hyenimc/backend/internal/msauth"
+	"hyenimc/backend/internal/yggdrasil"
 )
 
+// ErrKeyringUnavailable is returned for token reads and writes when the encryption
+// key could not be loaded at startup (typically a locked OS keyring)
+var ErrKeyringUnavailable = errors.New("keyring unavailable: unlock the system keyring and restart the launcher to use online accounts")
+
 // AccountService implements account management business logic
 type AccountService struct {
 	repo          *account.Repository
+	ygg           *yggdrasil.Client
 	encryptionKey []byte
+	keyID         string
+	keyStore      keystore.Provider
 	deviceID      string
+	msAuth        *msauth.Client
+
+	// textureCacheDir holds downloaded skin/cape textures for offline avatars
+	textureCacheDir string
+
+	// keyMu guards encryptionKey/keyID; writers hold it for encrypt+save so a
+	// rotation never races with a token update
+	keyMu sync.RWMutex
+
+	refreshMu sync.Mutex
+	healthMu  sync.Mutex
+	health    map[string]*AuthHealth
 }
 
-// NewAccountService creates a new account service
-func NewAccountService(repo *account.Repository, encryptionKey []byte, deviceID string) *AccountService {
+// NewAccountService creates a new account service.
+// keyID is the version of encryptionKey and keyStore the provider holding it.
+// A nil encryptionKey runs the service without token access (see ErrKeyringUnavailable).
+func NewAccountService(repo *account.Repository, encryptionKey []byte, keyID string, keyStore keystore.Provider, deviceID string, msAuth *msauth.Client, textureCacheDir string) *AccountService {
 	return &AccountService{
-		repo:          repo,
-		encryptionKey: encryptionKey,
-		deviceID:      deviceID,
+		repo:            repo,
+		encryptionKey:   encryptionKey,
+		keyID:           keyID,
+		keyStore:        keyStore,
+		deviceID:        deviceID,
+		msAuth:          msAuth,
+		textureCacheDir: textureCacheDir,
+		ygg:             yggdrasil.NewClient(),
+		health:          make(map[string]*AuthHealth),
+	}
+}
+
+// LoginWithDeviceCode runs the Microsoft device code flow and saves the resulting account.
+// onDeviceCode is called once the user code is available; onStage before each login step.
+// clientID overrides the configured Azure application id when non-empty.
+func (s *AccountService) LoginWithDeviceCode(
+	ctx context.Context,
+	clientID string,
+	onDeviceCode func(dc *msauth.DeviceCode),
+	onStage func(stage string),
+) (string, *msauth.Session, error) {
+	if s.msAuth == nil {
+		return "", nil, msauth.ErrMissingClientID
+	}
+	client := s.msAuth
+	if clientID != "" {
+		client = client.WithClientID(clientID)
+	}
+
+	dc, err := client.RequestDeviceCode(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if onDeviceCode != nil {
+		onDeviceCode(dc)
 	}
+
+	msToken, err := client.PollDeviceToken(ctx, dc)
+	if err != nil {
+		return "", nil, err
+	}
+
+	session, err := client.Login(ctx, msToken, onStage)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if onStage != nil {
+		onStage("saving")
+	}
+	accountID, err := s.SaveMicrosoftAccount(
+		ctx,
+		session.Profile.Name,
+		session.Profile.ID,
+		session.AccessToken,
+		session.RefreshToken,
+		session.ExpiresAt,
+		session.Profile.ActiveSkinURL(),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	return accountID, session, nil
 }
 
 // SaveMicrosoftAccount saves a Microsoft account with encrypted tokens
@@ -60,6 +145,9 @@ func (s *AccountService) SaveMicrosoftAccount(
 		return "", fmt.Errorf("failed to marshal tokens: %w", err)
 	}
 
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
 	encrypted, iv, authTag, err := s.encrypt(string(tokensJSON))
 	if err != nil {
 		return "", fmt.Errorf("failed to encrypt tokens: %w", err)
@@ -74,6 +162,7 @@ func (s *AccountService) SaveMicrosoftAccount(
 		EncryptedData: encrypted,
 		IV:            iv,
 		AuthTag:       authTag,
+		KeyID:         s.keyID,
 		SkinURL:       skinURL,
 		LastUsed:      now.Unix(),
 		DeviceID:      s.deviceID,
@@ -84,6 +173,7 @@ func (s *AccountService) SaveMicrosoftAccount(
 	if err := s.repo.Save(acc); err != nil {
 		return "", fmt.Errorf("failed to save account: %w", err)
 	}
+	s.clearHealth(accountID)
 
 	return accountID, nil
 }
@@ -139,11 +229,17 @@ func (s *AccountService) GetAccountTokens(ctx context.Context, accountID string)
 		return nil, err
 	}
 
-	if acc.Type != "microsoft" || acc.EncryptedData == "" {
-		return nil, fmt.Errorf("not a Microsoft account or no tokens available")
+	if (acc.Type != "microsoft" && acc.Type != "yggdrasil") || acc.EncryptedData == "" {
+		return nil, fmt.Errorf("not an online account or no tokens available")
 	}
 
+	s.keyMu.RLock()
+	if acc.KeyID != s.keyID {
+		s.keyMu.RUnlock()
+		return nil, fmt.Errorf("tokens are encrypted with an unknown key (%q)", acc.KeyID)
+	}
 	decrypted, err := s.decrypt(acc.EncryptedData, acc.IV, acc.AuthTag)
+	s.keyMu.RUnlock()
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt tokens: %w", err)
 	}
@@ -163,6 +259,23 @@ func (s *AccountService) UpdateAccountTokens(
 	accessToken string,
 	refreshToken string,
 	expiresAt int64,
+) error {
+	if err := s.updateTokens(ctx, accountID, accessToken, refreshToken, expiresAt, true); err != nil {
+		return err
+	}
+	s.clearHealth(accountID)
+	return nil
+}
+
+// updateTokens re-encrypts and stores tokens; touch also bumps last_used
+// (background refreshes must not reorder the account list)
+func (s *AccountService) updateTokens(
+	ctx context.Context,
+	accountID string,
+	accessToken string,
+	refreshToken string,
+	expiresAt int64,
+	touch bool,
 ) error {
 	acc, err := s.GetAccount(ctx, accountID)
 	if err != nil {
@@ -179,12 +292,19 @@ func (s *AccountService) UpdateAccountTokens(
 		RefreshToken: refreshToken,
 		ExpiresAt:    expiresAt,
 	}
+	return s.storeTokens(acc, tokens, touch)
+}
 
+// storeTokens encrypts tokens with the active key and saves them on acc
+func (s *AccountService) storeTokens(acc *domain.Account, tokens domain.DecryptedTokens, touch bool) error {
 	tokensJSON, err := json.Marshal(tokens)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tokens: %w", err)
 	}
 
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
 	encrypted, iv, authTag, err := s.encrypt(string(tokensJSON))
 	if err != nil {
 		return fmt.Errorf("failed to encrypt tokens: %w", err)
@@ -193,7 +313,10 @@ func (s *AccountService) UpdateAccountTokens(
 	acc.EncryptedData = encrypted
 	acc.IV = iv
 	acc.AuthTag = authTag
-	acc.LastUsed = time.Now().Unix()
+	acc.KeyID = s.keyID
+	if touch {
+		acc.LastUsed = time.Now().Unix()
+	}
 	acc.UpdatedAt = time.Now()
 
 	return s.repo.Save(acc)
@@ -216,12 +339,38 @@ func (s *AccountService) RemoveAccount(ctx context.Context, accountID string) er
 		return fmt.Errorf("account not accessible from this device")
 	}
 
-	return s.repo.Delete(accountID)
+	if err := s.repo.Delete(accountID); err != nil {
+		return err
+	}
+	s.clearHealth(accountID)
+	return nil
+}
+
+// clearHealth drops recorded auth health so it is derived again from the stored tokens
+func (s *AccountService) clearHealth(accountID string) {
+	s.healthMu.Lock()
+	delete(s.health, accountID)
+	s.healthMu.Unlock()
 }
 
-// encrypt encrypts data using AES-256-GCM
+// encrypt encrypts data using AES-256-GCM with the active key
 func (s *AccountService) encrypt(plaintext string) (encrypted, iv, authTag string, err error) {
-	block, err := aes.NewCipher(s.encryptionKey)
+	if s.encryptionKey == nil {
+		return "", "", "", ErrKeyringUnavailable
+	}
+	return encryptWithKey(s.encryptionKey, plaintext)
+}
+
+// decrypt decrypts data using AES-256-GCM with the active key
+func (s *AccountService) decrypt(encryptedHex, ivHex, authTagHex string) (string, error) {
+	if s.encryptionKey == nil {
+		return "", ErrKeyringUnavailable
+	}
+	return decryptWithKey(s.encryptionKey, encryptedHex, ivHex, authTagHex)
+}
+
+func encryptWithKey(key []byte, plaintext string) (encrypted, iv, authTag string, err error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", "", "", err
 	}
@@ -249,8 +398,7 @@ func (s *AccountService) encrypt(plaintext string) (encrypted, iv, authTag strin
 		nil
 }
 
-// decrypt decrypts data using AES-256-GCM
-func (s *AccountService) decrypt(encryptedHex, ivHex, authTagHex string) (string, error) {
+func decryptWithKey(key []byte, encryptedHex, ivHex, authTagHex string) (string, error) {
 	encrypted, err := hex.DecodeString(encryptedHex)
 	if err != nil {
 		return "", err
@@ -266,7 +414,7 @@ func (s *AccountService) decrypt(encryptedHex, ivHex, authTagHex string) (string
 		return "", err
 	}
 
-	block, err := aes.NewCipher(s.encryptionKey)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"hyenimc/backend/internal/download"
+	"hyenimc/backend/internal/httpclient"
+	"hyenimc/backend/internal/msauth"
+)
+
+// maxSkinSize is the upload limit of the Minecraft services API
+const maxSkinSize = 24 * 1024
+
+var textureHashPattern = regexp.MustCompile(`^[0-9a-f]{32,64}$`)
+
+var textureClient = httpclient.New(30 * time.Second)
+
+// GetMinecraftProfile fetches the full Minecraft profile (skins and capes) of an account
+func (s *AccountService) GetMinecraftProfile(ctx context.Context, accountID string) (*msauth.Profile, error) {
+	token, err := s.accessToken(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.msAuth.GetProfile(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	s.syncProfileTextures(ctx, accountID, profile)
+	return profile, nil
+}
+
+// UploadSkin uploads a PNG skin file as the active skin (variant: classic or slim)
+func (s *AccountService) UploadSkin(ctx context.Context, accountID, filePath, variant string) (*msauth.Profile, error) {
+	if variant == "" {
+		variant = msauth.SkinVariantClassic
+	}
+	data, err := readSkinFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.accessToken(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.msAuth.UploadSkin(ctx, token, variant, data)
+	if err != nil {
+		return nil, err
+	}
+	s.syncProfileTextures(ctx, accountID, profile)
+	return profile, nil
+}
+
+// ResetSkin restores the default skin
+func (s *AccountService) ResetSkin(ctx context.Context, accountID string) (*msauth.Profile, error) {
+	token, err := s.accessToken(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.msAuth.ResetSkin(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	s.syncProfileTextures(ctx, accountID, profile)
+	return profile, nil
+}
+
+// SetActiveCape shows an owned cape, or hides the active cape when capeID is empty
+func (s *AccountService) SetActiveCape(ctx context.Context, accountID, capeID string) (*msauth.Profile, error) {
+	token, err := s.accessToken(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	var profile *msauth.Profile
+	if capeID == "" {
+		profile, err = s.msAuth.HideCape(ctx, token)
+	} else {
+		profile, err = s.msAuth.ShowCape(ctx, token, capeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.syncProfileTextures(ctx, accountID, profile)
+	return profile, nil
+}
+
+// GetCachedSkin returns the local path of the account's skin texture, downloading it
+// if it is not cached yet. Works offline once the texture has been cached.
+func (s *AccountService) GetCachedSkin(ctx context.Context, accountID string) (localPath, url string, err error) {
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return "", "", err
+	}
+	if acc.SkinURL == "" {
+		return "", "", nil
+	}
+	if p := s.CachedTexturePath(acc.SkinURL); p != "" {
+		return p, acc.SkinURL, nil
+	}
+	p, err := s.CacheTexture(ctx, acc.SkinURL)
+	if err != nil {
+		return "", acc.SkinURL, err
+	}
+	return p, acc.SkinURL, nil
+}
+
+// CachedTexturePath returns the cached file for a texture URL, or "" if it is not cached
+func (s *AccountService) CachedTexturePath(url string) string {
+	p := s.texturePath(url)
+	if p == "" {
+		return ""
+	}
+	if _, err := os.Stat(p); err != nil {
+		return ""
+	}
+	return p
+}
+
+// CacheTexture downloads a skin or cape texture into the local cache
+func (s *AccountService) CacheTexture(ctx context.Context, url string) (string, error) {
+	dest := s.texturePath(url)
+	if dest == "" {
+		return "", fmt.Errorf("texture cache not configured")
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return dest, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := textureClient.Do(req)
+	if err != nil {
+		return "", download.Classify(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", download.NewHTTPStatusError(resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", download.Classify(err)
+	}
+	if _, err := png.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("texture is not a PNG image: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return dest, nil
+}
+
+// texturePath maps a texture URL to its cache file. textures.minecraft.net URLs end in
+// the texture hash, which is reused as the file name.
+func (s *AccountService) texturePath(url string) string {
+	if s.textureCacheDir == "" || url == "" {
+		return ""
+	}
+	name := path.Base(url)
+	if !textureHashPattern.MatchString(name) {
+		sum := sha1.Sum([]byte(url))
+		name = hex.EncodeToString(sum[:])
+	}
+	return filepath.Join(s.textureCacheDir, name+".png")
+}
+
+// accessToken returns a usable Minecraft access token, refreshing it first if needed
+func (s *AccountService) accessToken(ctx context.Context, accountID string) (string, error) {
+	if s.msAuth == nil {
+		return "", msauth.ErrMissingClientID
+	}
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+	if acc.Type != "microsoft" {
+		return "", fmt.Errorf("not a Microsoft account")
+	}
+
+	health := s.refreshAccount(ctx, acc, time.Minute)
+	if health.Status == AuthStatusNeedsRelogin || health.Status == AuthStatusExpired {
+		return "", fmt.Errorf("session expired, sign in again: %s", health.Error)
+	}
+	tokens, err := s.GetAccountTokens(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+	return tokens.AccessToken, nil
+}
+
+// syncProfileTextures stores the active skin URL and caches active textures (best effort)
+func (s *AccountService) syncProfileTextures(ctx context.Context, accountID string, profile *msauth.Profile) {
+	skinURL := profile.ActiveSkinURL()
+	if err := s.repo.UpdateSkinURL(accountID, skinURL); err != nil {
+		log.Printf("[Account] Failed to update skin URL for %s: %v", accountID, err)
+	}
+
+	urls := []string{skinURL}
+	for _, c := range profile.Capes {
+		if c.State == "ACTIVE" {
+			urls = append(urls, c.URL)
+		}
+	}
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		if _, err := s.CacheTexture(ctx, u); err != nil {
+			log.Printf("[Account] Failed to cache texture %s: %v", u, err)
+		}
+	}
+}
+
+// readSkinFile reads and validates a skin PNG (64x64 or legacy 64x32)
+func readSkinFile(filePath string) ([]byte, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skin: %w", err)
+	}
+	if info.Size() > maxSkinSize {
+		return nil, fmt.Errorf("skin file too large (%d bytes, max %d)", info.Size(), maxSkinSize)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skin: %w", err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("skin is not a PNG image: %w", err)
+	}
+	if cfg.Width != 64 || (cfg.Height != 64 && cfg.Height != 32) {
+		return nil, fmt.Errorf("invalid skin size %dx%d (expected 64x64 or 64x32)", cfg.Width, cfg.Height)
+	}
+	return data, nil
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hyenimc/backend/internal/msauth"
+)
+
+const (
+	testSkinHash = "0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	testCapeHash = "f9e8d7c6b5a4938271605f4e3d2c1b0af9e8d7c6b5a4938271605f4e3d2c1b0a"
+)
+
+func pngImage(w, h int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h)))
+	return buf.Bytes()
+}
+
+// profileAPI stands in for the Minecraft services profile endpoints and the texture server
+type profileAPI struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	requests []string
+	variant  string
+	capeID   string
+}
+
+func (a *profileAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests = append(a.requests, r.Method+" "+r.URL.Path)
+
+	if strings.HasPrefix(r.URL.Path, "/texture/") {
+		w.Write(pngImage(64, 64))
+		return
+	}
+	if r.URL.Path == "/token" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant", "error_description": "revoked"}`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer access" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /minecraft/profile":
+	case "POST /minecraft/profile/skins":
+		a.variant = r.FormValue("variant")
+	case "DELETE /minecraft/profile/skins/active":
+		a.variant = ""
+	case "PUT /minecraft/profile/capes/active":
+		var body struct{ CapeID string }
+		json.NewDecoder(r.Body).Decode(&body)
+		a.capeID = body.CapeID
+	case "DELETE /minecraft/profile/capes/active":
+		a.capeID = ""
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	p := msauth.Profile{ID: "00000000000000000000000000000001", Name: "player"}
+	if a.variant != "" {
+		p.Skins = []msauth.Skin{{ID: "s1", State: "ACTIVE", URL: a.srv.URL + "/texture/" + testSkinHash, Variant: strings.ToUpper(a.variant)}}
+	}
+	cape := msauth.Cape{ID: "c1", State: "INACTIVE", URL: a.srv.URL + "/texture/" + testCapeHash, Alias: "Migrator"}
+	if a.capeID == cape.ID {
+		cape.State = "ACTIVE"
+	}
+	p.Capes = []msauth.Cape{cape}
+	json.NewEncoder(w).Encode(p)
+}
+
+func (a *profileAPI) sent(route string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, r := range a.requests {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestSkinService returns an account service talking to a local profile API
+func newTestSkinService(t *testing.T) (*AccountService, *profileAPI, string) {
+	t.Helper()
+	svc, _, _ := newTestAccountService(t)
+	api := &profileAPI{variant: "classic"}
+	api.srv = httptest.NewServer(api)
+	t.Cleanup(api.srv.Close)
+
+	endpoints := msauth.DefaultEndpoints()
+	endpoints.TokenURL = api.srv.URL + "/token"
+	endpoints.ProfileURL = api.srv.URL + "/minecraft/profile"
+	svc.msAuth = msauth.NewClient("client-id", endpoints)
+	svc.textureCacheDir = t.TempDir()
+	id := addTestAccount(t, svc, "00000000000000000000000000000001", "refresh")
+	return svc, api, id
+}
+
+func TestGetMinecraftProfileCachesTextures(t *testing.T) {
+	svc, api, id := newTestSkinService(t)
+	ctx := context.Background()
+	api.capeID = "c1"
+
+	profile, err := svc.GetMinecraftProfile(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skinURL := api.srv.URL + "/texture/" + testSkinHash
+	if profile.ActiveSkinURL() != skinURL {
+		t.Errorf("profile %+v", profile)
+	}
+	if acc, _ := svc.GetAccount(ctx, id); acc.SkinURL != skinURL {
+		t.Errorf("stored skin URL %q", acc.SkinURL)
+	}
+	for _, hash := range []string{testSkinHash, testCapeHash} {
+		if _, err := os.Stat(filepath.Join(svc.textureCacheDir, hash+".png")); err != nil {
+			t.Errorf("texture %s not cached: %v", hash[:8], err)
+		}
+	}
+
+	// The cached skin keeps working without the network
+	api.srv.Close()
+	local, url, err := svc.GetCachedSkin(ctx, id)
+	if err != nil || url != skinURL || local != filepath.Join(svc.textureCacheDir, testSkinHash+".png") {
+		t.Errorf("cached skin %q %q %v", local, url, err)
+	}
+}
+
+func TestUploadSkin(t *testing.T) {
+	svc, api, id := newTestSkinService(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		os.WriteFile(p, data, 0644)
+		return p
+	}
+
+	for name, data := range map[string][]byte{
+		"not-png.png": []byte("GIF89a"),
+		"wrong.png":   pngImage(32, 32),
+		"large.png":   bytes.Repeat([]byte{0}, maxSkinSize+1),
+	} {
+		if _, err := svc.UploadSkin(ctx, id, write(name, data), ""); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := svc.UploadSkin(ctx, id, filepath.Join(dir, "missing.png"), ""); err == nil {
+		t.Error("missing file: expected an error")
+	}
+	if api.sent("POST /minecraft/profile/skins") {
+		t.Fatal("invalid skins were uploaded")
+	}
+
+	profile, err := svc.UploadSkin(ctx, id, write("legacy.png", pngImage(64, 32)), msauth.SkinVariantSlim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.variant != msauth.SkinVariantSlim || profile.Skins[0].Variant != "SLIM" {
+		t.Errorf("variant %q, profile %+v", api.variant, profile)
+	}
+}
+
+func TestResetSkinAndCapes(t *testing.T) {
+	svc, api, id := newTestSkinService(t)
+	ctx := context.Background()
+
+	profile, err := svc.SetActiveCape(ctx, id, "c1")
+	if err != nil || profile.Capes[0].State != "ACTIVE" {
+		t.Fatalf("show cape: %+v, %v", profile, err)
+	}
+	profile, err = svc.SetActiveCape(ctx, id, "")
+	if err != nil || profile.Capes[0].State != "INACTIVE" || !api.sent("DELETE /minecraft/profile/capes/active") {
+		t.Fatalf("hide cape: %+v, %v", profile, err)
+	}
+
+	if _, err := svc.ResetSkin(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if acc, _ := svc.GetAccount(ctx, id); acc.SkinURL != "" {
+		t.Errorf("skin URL %q after reset", acc.SkinURL)
+	}
+	if local, url, err := svc.GetCachedSkin(ctx, id); local != "" || url != "" || err != nil {
+		t.Errorf("cached skin after reset: %q %q %v", local, url, err)
+	}
+}
+
+func TestSkinRequestsNeedAValidSession(t *testing.T) {
+	svc, api, id := newTestSkinService(t)
+	ctx := context.Background()
+	expired := time.Now().Add(-time.Hour).UnixMilli()
+	if _, err := svc.SaveMicrosoftAccount(ctx, "player", "00000000000000000000000000000001", "stale", "revoked", expired, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token endpoint rejects the refresh token, so the stale token is never sent
+	if _, err := svc.ResetSkin(ctx, id); err == nil || !strings.Contains(err.Error(), "sign in again") {
+		t.Errorf("got %v", err)
+	}
+	if !api.sent("POST /token") || api.sent("DELETE /minecraft/profile/skins/active") {
+		t.Errorf("requests %v", api.requests)
+	}
+}
+
+func TestTexturePath(t *testing.T) {
+	svc := &AccountService{textureCacheDir: "cache"}
+	if p := svc.texturePath("http://textures.minecraft.net/texture/" + testSkinHash); p != filepath.Join("cache", testSkinHash+".png") {
+		t.Errorf("hash URL: %s", p)
+	}
+	p := svc.texturePath("https://example.com/skins/../../steve.png")
+	if filepath.Dir(p) != "cache" || len(filepath.Base(p)) != 40+len(".png") {
+		t.Errorf("other URL: %s", p)
+	}
+	if p := (&AccountService{}).texturePath("http://textures.minecraft.net/texture/" + testSkinHash); p != "" {
+		t.Errorf("without a cache directory: %s", p)
+	}
+}
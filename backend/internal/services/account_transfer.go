@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+
+	"hyenimc/backend/internal/domain"
+)
+
+const (
+	accountBundleFormat  = "hyenimc-accounts"
+	accountBundleVersion = 1
+	minPassphraseLength  = 8
+
+	// Limits on the key derivation parameters of an imported bundle. The bundle is
+	// untrusted input, so its costs must not be able to stall or exhaust the machine.
+	minBundleSaltLength = 16
+	maxBundleKDFTime    = 16
+	maxBundleKDFMemory  = 256 * 1024 // KiB
+	maxBundleKDFThreads = 16
+)
+
+// ErrWrongPassphrase is returned when an account bundle cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted bundle")
+
+// accountBundle is the on-disk export format. Data is the AES-256-GCM sealed JSON of
+// bundledAccounts, keyed by Argon2id(passphrase, salt).
+type accountBundle struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	KDF       bundleKDF `json:"kdf"`
+	Nonce     []byte    `json:"nonce"`
+	Data      []byte    `json:"data"`
+	CreatedAt int64     `json:"created_at"`
+}
+
+type bundleKDF struct {
+	Name    string `json:"name"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+type bundledAccount struct {
+	ID         string                  `json:"id"`
+	Name       string                  `json:"name"`
+	UUID       string                  `json:"uuid"`
+	Type       string                  `json:"type"`
+	SkinURL    string                  `json:"skin_url,omitempty"`
+	AuthServer string                  `json:"auth_server,omitempty"`
+	LastUsed   int64                   `json:"last_used"`
+	CreatedAt  int64                   `json:"created_at"`
+	Tokens     *domain.DecryptedTokens `json:"tokens,omitempty"`
+}
+
+// ImportResult summarizes an account import
+type ImportResult struct {
+	Imported   []string // account ids
+	Skipped    []string // already present and not overwritten
+	Unreadable int      // online accounts without tokens in the bundle
+}
+
+// ExportAccounts writes a passphrase encrypted bundle of this device's accounts to
+// filePath. Tokens are decrypted with the local key and protected only by the passphrase.
+// An empty accountIDs exports every account.
+func (s *AccountService) ExportAccounts(ctx context.Context, filePath, passphrase string, accountIDs []string) (int, error) {
+	if len(passphrase) < minPassphraseLength {
+		return 0, fmt.Errorf("passphrase must be at least %d characters", minPassphraseLength)
+	}
+
+	accounts, err := s.GetAllAccounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	wanted := make(map[string]bool, len(accountIDs))
+	for _, id := range accountIDs {
+		wanted[id] = true
+	}
+
+	var bundled []bundledAccount
+	for _, acc := range accounts {
+		if len(wanted) > 0 && !wanted[acc.ID] {
+			continue
+		}
+		b := bundledAccount{
+			ID:         acc.ID,
+			Name:       acc.Name,
+			UUID:       acc.UUID,
+			Type:       acc.Type,
+			SkinURL:    acc.SkinURL,
+			AuthServer: acc.AuthServer,
+			LastUsed:   acc.LastUsed,
+			CreatedAt:  acc.CreatedAt.Unix(),
+		}
+		if acc.Type != "offline" {
+			tokens, err := s.GetAccountTokens(ctx, acc.ID)
+			if err != nil {
+				return 0, fmt.Errorf("failed to read tokens of %s: %w", acc.Name, err)
+			}
+			b.Tokens = tokens
+		}
+		bundled = append(bundled, b)
+	}
+	if len(bundled) == 0 {
+		return 0, fmt.Errorf("no accounts to export")
+	}
+
+	plaintext, err := json.Marshal(bundled)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal accounts: %w", err)
+	}
+	data, err := sealAccountBundle(plaintext, passphrase)
+	if err != nil {
+		return 0, err
+	}
+
+	// Remove an existing file first so a symlink cannot redirect the write
+	os.Remove(filePath)
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return 0, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	log.Printf("[Account] Exported %d accounts", len(bundled))
+	return len(bundled), nil
+}
+
+// ImportAccounts reads a bundle written by ExportAccounts and stores its accounts for
+// this device, re-encrypted with the local key. Existing accounts are kept unless overwrite.
+func (s *AccountService) ImportAccounts(ctx context.Context, filePath, passphrase string, overwrite bool) (*ImportResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	plaintext, err := openAccountBundle(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var bundled []bundledAccount
+	if err := json.Unmarshal(plaintext, &bundled); err != nil {
+		return nil, fmt.Errorf("invalid bundle contents: %w", err)
+	}
+
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
+	result := &ImportResult{}
+	for _, b := range bundled {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		// Rows synced from other devices count as existing too
+		if _, err := s.repo.Get(b.ID); err == nil && !overwrite {
+			result.Skipped = append(result.Skipped, b.ID)
+			continue
+		}
+
+		now := time.Now()
+		acc := &domain.Account{
+			ID:         b.ID,
+			Name:       b.Name,
+			UUID:       b.UUID,
+			Type:       b.Type,
+			SkinURL:    b.SkinURL,
+			AuthServer: b.AuthServer,
+			LastUsed:   b.LastUsed,
+			DeviceID:   s.deviceID,
+			CreatedAt:  time.Unix(b.CreatedAt, 0),
+			UpdatedAt:  now,
+		}
+		switch b.Type {
+		case "offline":
+		case "microsoft", "yggdrasil":
+			if b.Tokens == nil {
+				result.Unreadable++
+				continue
+			}
+			tokensJSON, err := json.Marshal(b.Tokens)
+			if err != nil {
+				return result, fmt.Errorf("failed to marshal tokens: %w", err)
+			}
+			acc.EncryptedData, acc.IV, acc.AuthTag, err = s.encrypt(string(tokensJSON))
+			if err != nil {
+				return result, fmt.Errorf("failed to encrypt tokens: %w", err)
+			}
+			acc.KeyID = s.keyID
+		default:
+			result.Unreadable++
+			continue
+		}
+
+		if err := s.repo.Save(acc); err != nil {
+			return result, fmt.Errorf("failed to save account %s: %w", b.Name, err)
+		}
+		s.clearHealth(acc.ID)
+		result.Imported = append(result.Imported, acc.ID)
+	}
+
+	log.Printf("[Account] Imported %d accounts (%d skipped)", len(result.Imported), len(result.Skipped))
+	return result, nil
+}
+
+func sealAccountBundle(plaintext []byte, passphrase string) ([]byte, error) {
+	bundle := accountBundle{
+		Format:    accountBundleFormat,
+		Version:   accountBundleVersion,
+		KDF:       bundleKDF{Name: "argon2id", Salt: make([]byte, 16), Time: 3, Memory: 64 * 1024, Threads: 4},
+		CreatedAt: time.Now().Unix(),
+	}
+	if _, err := io.ReadFull(rand.Reader, bundle.KDF.Salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := bundleCipher(passphrase, bundle.KDF)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, bundle.Nonce); err != nil {
+		return nil, err
+	}
+	bundle.Data = gcm.Seal(nil, bundle.Nonce, plaintext, bundleAAD(bundle))
+
+	return json.MarshalIndent(bundle, "", "  ")
+}
+
+func openAccountBundle(data []byte, passphrase string) ([]byte, error) {
+	var bundle accountBundle
+	if err := json.Unmarshal(data, &bundle); err != nil || bundle.Format != accountBundleFormat {
+		return nil, fmt.Errorf("not an account bundle")
+	}
+	if bundle.Version != accountBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	if err := validateBundleKDF(bundle.KDF); err != nil {
+		return nil, err
+	}
+
+	gcm, err := bundleCipher(passphrase, bundle.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := gcm.Open(nil, bundle.Nonce, bundle.Data, bundleAAD(bundle))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// validateBundleKDF rejects parameters Argon2 cannot use or that cost far more than an export ever writes
+func validateBundleKDF(kdf bundleKDF) error {
+	switch {
+	case kdf.Name != "argon2id":
+		return fmt.Errorf("unsupported bundle key derivation %q", kdf.Name)
+	case len(kdf.Salt) < minBundleSaltLength:
+		return fmt.Errorf("bundle salt is too short (%d bytes)", len(kdf.Salt))
+	case kdf.Time == 0 || kdf.Time > maxBundleKDFTime:
+		return fmt.Errorf("unsupported bundle time cost %d", kdf.Time)
+	case kdf.Threads == 0 || kdf.Threads > maxBundleKDFThreads:
+		return fmt.Errorf("unsupported bundle parallelism %d", kdf.Threads)
+	case kdf.Memory < 8*uint32(kdf.Threads) || kdf.Memory > maxBundleKDFMemory:
+		return fmt.Errorf("unsupported bundle memory cost %d KiB", kdf.Memory)
+	}
+	return nil
+}
+
+func bundleCipher(passphrase string, kdf bundleKDF) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// bundleAAD binds the header to the ciphertext so it cannot be swapped
+func bundleAAD(b accountBundle) []byte {
+	return []byte(fmt.Sprintf("%s/%d/%d", b.Format, b.Version, b.CreatedAt))
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImportAccounts(t *testing.T) {
+	ctx := context.Background()
+	src, _, _ := newTestAccountService(t)
+	id := addTestAccount(t, src, "00000000000000000000000000000001", "refresh-1")
+	path := filepath.Join(t.TempDir(), "accounts.hmc")
+	if n, err := src.ExportAccounts(ctx, path, "correct horse", nil); err != nil || n != 1 {
+		t.Fatalf("export: %d, %v", n, err)
+	}
+
+	dst, _, _ := newTestAccountService(t)
+	if _, err := dst.ImportAccounts(ctx, path, "wrong horse", false); err != ErrWrongPassphrase {
+		t.Errorf("wrong passphrase: got %v", err)
+	}
+	result, err := dst.ImportAccounts(ctx, path, "correct horse", false)
+	if err != nil || len(result.Imported) != 1 {
+		t.Fatalf("import: %+v, %v", result, err)
+	}
+	if tokens, err := dst.GetAccountTokens(ctx, id); err != nil || tokens.RefreshToken != "refresh-1" {
+		t.Errorf("imported tokens: %v", err)
+	}
+}
+
+func TestImportAccountsHonoursOverwrite(t *testing.T) {
+	ctx := context.Background()
+	src, _, _ := newTestAccountService(t)
+	id := addTestAccount(t, src, "00000000000000000000000000000001", "refresh-new")
+	path := filepath.Join(t.TempDir(), "accounts.hmc")
+	if _, err := src.ExportAccounts(ctx, path, "correct horse", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, conn, _ := newTestAccountService(t)
+	addTestAccount(t, dst, "00000000000000000000000000000001", "refresh-old")
+	for _, device := range []string{"device", "other-device"} {
+		conn.Exec(`UPDATE accounts SET id = ?, device_id = ?`, id, device)
+
+		result, err := dst.ImportAccounts(ctx, path, "correct horse", false)
+		if err != nil || len(result.Imported) != 0 || len(result.Skipped) != 1 {
+			t.Fatalf("%s: %+v, %v", device, result, err)
+		}
+		var stored string
+		conn.QueryRow(`SELECT device_id FROM accounts WHERE id = ?`, id).Scan(&stored)
+		if stored != device {
+			t.Errorf("%s: row taken over by %q without overwrite", device, stored)
+		}
+	}
+
+	result, err := dst.ImportAccounts(ctx, path, "correct horse", true)
+	if err != nil || len(result.Imported) != 1 {
+		t.Fatalf("overwrite: %+v, %v", result, err)
+	}
+	if tokens, err := dst.GetAccountTokens(ctx, id); err != nil || tokens.RefreshToken != "refresh-new" {
+		t.Errorf("overwritten tokens: %v", err)
+	}
+}
+
+func TestOpenAccountBundleRejectsKDFParameters(t *testing.T) {
+	valid := bundleKDF{Name: "argon2id", Salt: make([]byte, 16), Time: 3, Memory: 64 * 1024, Threads: 4}
+	for name, change := range map[string]func(k *bundleKDF){
+		"algorithm":    func(k *bundleKDF) { k.Name = "scrypt" },
+		"no salt":      func(k *bundleKDF) { k.Salt = nil },
+		"short salt":   func(k *bundleKDF) { k.Salt = k.Salt[:8] },
+		"no memory":    func(k *bundleKDF) { k.Memory = 0 },
+		"tiny memory":  func(k *bundleKDF) { k.Memory = 16 },
+		"huge memory":  func(k *bundleKDF) { k.Memory = 4 << 20 },
+		"no passes":    func(k *bundleKDF) { k.Time = 0 },
+		"many passes":  func(k *bundleKDF) { k.Time = 1 << 20 },
+		"no threads":   func(k *bundleKDF) { k.Threads = 0 },
+		"many threads": func(k *bundleKDF) { k.Threads = 255 },
+	} {
+		kdf := valid
+		kdf.Salt = append([]byte(nil), valid.Salt...)
+		change(&kdf)
+		data, _ := json.Marshal(accountBundle{Format: accountBundleFormat, Version: accountBundleVersion, KDF: kdf, Nonce: make([]byte, 12)})
+		if _, err := openAccountBundle(data, "correct horse"); err == nil || err == ErrWrongPassphrase {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	data, _ := json.Marshal(accountBundle{Format: accountBundleFormat, Version: accountBundleVersion, KDF: valid, Nonce: make([]byte, 12)})
+	if _, err := openAccountBundle(data, "correct horse"); err != ErrWrongPassphrase {
+		t.Errorf("valid parameters: got %v", err)
+	}
+	if _, err := openAccountBundle([]byte(`{"format": "other"}`), "correct horse"); err == nil || !strings.Contains(err.Error(), "not an account bundle") {
+		t.Errorf("format: got %v", err)
+	}
+}
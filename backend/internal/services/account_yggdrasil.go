@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/yggdrasil"
+)
+
+// LaunchAuth is the authentication data the game launch needs for an account
+type LaunchAuth struct {
+	Name        string
+	UUID        string
+	AccessToken string
+	UserType    string // msa | mojang | legacy
+
+	// Set for yggdrasil accounts: authlib-injector must be loaded as a javaagent
+	AuthlibInjectorAPIRoot    string
+	AuthlibInjectorPrefetched string // base64 server metadata
+}
+
+// AddYggdrasilAccount signs in to a Yggdrasil compatible server (authlib-injector) and
+// saves the account. profileName selects a profile when the user owns several.
+func (s *AccountService) AddYggdrasilAccount(ctx context.Context, serverURL, username, password, profileName string) (string, error) {
+	apiRoot, err := s.ygg.ResolveAPIRoot(ctx, serverURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach auth server: %w", err)
+	}
+
+	clientToken := strings.ReplaceAll(uuid.New().String(), "-", "")
+	session, err := s.ygg.Authenticate(ctx, apiRoot, username, password, clientToken)
+	if err != nil {
+		return "", err
+	}
+
+	profile := session.SelectedProfile
+	if profile == nil || (profileName != "" && !strings.EqualFold(profile.Name, profileName)) {
+		profile, err = pickYggdrasilProfile(session.AvailableProfiles, profileName)
+		if err != nil {
+			return "", err
+		}
+		// Bind the token to the chosen profile
+		session, err = s.ygg.Refresh(ctx, apiRoot, session.AccessToken, session.ClientToken, profile)
+		if err != nil {
+			return "", err
+		}
+	}
+	if session.ClientToken == "" {
+		session.ClientToken = clientToken
+	}
+
+	// Same server + profile always maps to the same account row
+	accountID := uuid.NewSHA1(uuid.NameSpaceURL, []byte(apiRoot+"#"+profile.ID)).String()
+	tokens := domain.DecryptedTokens{
+		AccessToken: session.AccessToken,
+		ClientToken: session.ClientToken,
+	}
+	tokensJSON, err := json.Marshal(tokens)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
+	encrypted, iv, authTag, err := s.encrypt(string(tokensJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt tokens: %w", err)
+	}
+
+	now := time.Now()
+	acc := &domain.Account{
+		ID:            accountID,
+		Name:          profile.Name,
+		UUID:          formatUUID(profile.ID),
+		Type:          "yggdrasil",
+		EncryptedData: encrypted,
+		IV:            iv,
+		AuthTag:       authTag,
+		KeyID:         s.keyID,
+		AuthServer:    apiRoot,
+		LastUsed:      now.Unix(),
+		DeviceID:      s.deviceID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if existing, err := s.repo.Get(accountID); err == nil {
+		acc.CreatedAt = existing.CreatedAt
+	}
+
+	if err := s.repo.Save(acc); err != nil {
+		return "", fmt.Errorf("failed to save account: %w", err)
+	}
+	return accountID, nil
+}
+
+// RefreshYggdrasilAccount validates the stored token and refreshes it if the server rejects it
+func (s *AccountService) RefreshYggdrasilAccount(ctx context.Context, accountID string) (*domain.DecryptedTokens, error) {
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Type != "yggdrasil" {
+		return nil, fmt.Errorf("not a Yggdrasil account")
+	}
+	tokens, err := s.GetAccountTokens(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := s.ygg.Validate(ctx, acc.AuthServer, tokens.AccessToken, tokens.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	if valid {
+		return tokens, nil
+	}
+
+	session, err := s.ygg.Refresh(ctx, acc.AuthServer, tokens.AccessToken, tokens.ClientToken, nil)
+	if err != nil {
+		return nil, err
+	}
+	refreshed := domain.DecryptedTokens{
+		AccessToken: session.AccessToken,
+		ClientToken: tokens.ClientToken,
+	}
+	if session.ClientToken != "" {
+		refreshed.ClientToken = session.ClientToken
+	}
+	if err := s.storeTokens(acc, refreshed, false); err != nil {
+		return nil, err
+	}
+	log.Printf("[Account] Refreshed Yggdrasil token for %s (%s)", acc.Name, acc.AuthServer)
+	return &refreshed, nil
+}
+
+// GetLaunchAuth returns validated launch credentials for any account type
+func (s *AccountService) GetLaunchAuth(ctx context.Context, accountID string) (*LaunchAuth, error) {
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	auth := &LaunchAuth{Name: acc.Name, UUID: acc.UUID}
+
+	switch acc.Type {
+	case "offline":
+		auth.AccessToken = "null"
+		auth.UserType = "legacy"
+	case "microsoft":
+		token, err := s.accessToken(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		auth.AccessToken = token
+		auth.UserType = "msa"
+	case "yggdrasil":
+		tokens, err := s.RefreshYggdrasilAccount(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		auth.AccessToken = tokens.AccessToken
+		auth.UserType = "mojang"
+		auth.AuthlibInjectorAPIRoot = acc.AuthServer
+		// Prefetching is optional; authlib-injector fetches the metadata itself otherwise
+		if prefetched, err := s.ygg.PrefetchedMetadata(ctx, acc.AuthServer); err == nil {
+			auth.AuthlibInjectorPrefetched = prefetched
+		} else {
+			log.Printf("[Account] Failed to prefetch metadata from %s: %v", acc.AuthServer, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported account type: %s", acc.Type)
+	}
+	return auth, nil
+}
+
+func pickYggdrasilProfile(profiles []yggdrasil.Profile, name string) (*yggdrasil.Profile, error) {
+	if len(profiles) == 0 {
+		return nil, yggdrasil.ErrNoProfile
+	}
+	if name == "" {
+		if len(profiles) == 1 {
+			return &profiles[0], nil
+		}
+		names := make([]string, 0, len(profiles))
+		for _, p := range profiles {
+			names = append(names, p.Name)
+		}
+		return nil, fmt.Errorf("account has several profiles, choose one of: %s", strings.Join(names, ", "))
+	}
+	for i := range profiles {
+		if strings.EqualFold(profiles[i].Name, name) {
+			return &profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("profile %q not found on this account", name)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"hyenimc/backend/internal/yggdrasil"
+)
+
+// yggdrasilServer is an authlib-injector server at /api/yggdrasil with two profiles
+type yggdrasilServer struct {
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	valid   map[string]bool // tokens /validate accepts
+	revoked bool            // /refresh rejects every token
+	issued  int
+}
+
+var yggdrasilProfiles = []yggdrasil.Profile{
+	{ID: "0123456789abcdef0123456789abcdef", Name: "Steve"},
+	{ID: "fedcba9876543210fedcba9876543210", Name: "Alex"},
+}
+
+func newYggdrasilServer(t *testing.T) *yggdrasilServer {
+	t.Helper()
+	y := &yggdrasilServer{valid: map[string]bool{}}
+	y.srv = httptest.NewServer(y)
+	t.Cleanup(y.srv.Close)
+	return y
+}
+
+func (y *yggdrasilServer) apiRoot() string { return y.srv.URL + "/api/yggdrasil" }
+
+func (y *yggdrasilServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	var req struct {
+		Password, AccessToken, ClientToken string
+		SelectedProfile                    *yggdrasil.Profile
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	forbidden := func() {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": "ForbiddenOperationException", "errorMessage": "Invalid token."}`))
+	}
+	issue := func() string {
+		y.issued++
+		token := fmt.Sprintf("token-%d", y.issued)
+		y.valid[token] = true
+		return token
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /":
+		w.Header().Set("X-Authlib-Injector-API-Location", "/api/yggdrasil/")
+	case "GET /api/yggdrasil/":
+		w.Write([]byte(`{"meta": {"serverName": "Test"}}`))
+	case "POST /api/yggdrasil/authserver/authenticate":
+		if req.Password != "secret" {
+			forbidden()
+			return
+		}
+		json.NewEncoder(w).Encode(yggdrasil.Session{AccessToken: issue(), ClientToken: req.ClientToken, AvailableProfiles: yggdrasilProfiles})
+	case "POST /api/yggdrasil/authserver/refresh":
+		if y.revoked || req.AccessToken == "" {
+			forbidden()
+			return
+		}
+		delete(y.valid, req.AccessToken)
+		json.NewEncoder(w).Encode(yggdrasil.Session{AccessToken: issue(), ClientToken: req.ClientToken, SelectedProfile: req.SelectedProfile})
+	case "POST /api/yggdrasil/authserver/validate":
+		if !y.valid[req.AccessToken] {
+			forbidden()
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// expire makes every issued token fail validation while refresh keeps working
+func (y *yggdrasilServer) expire() {
+	y.mu.Lock()
+	y.valid = map[string]bool{}
+	y.mu.Unlock()
+}
+
+func TestAddYggdrasilAccount(t *testing.T) {
+	svc, _, _ := newTestAccountService(t)
+	y := newYggdrasilServer(t)
+	ctx := context.Background()
+
+	if _, err := svc.AddYggdrasilAccount(ctx, y.srv.URL, "player", "wrong", ""); !errors.Is(err, yggdrasil.ErrInvalidCredentials) {
+		t.Errorf("wrong password: %v", err)
+	}
+	if _, err := svc.AddYggdrasilAccount(ctx, y.srv.URL, "player", "secret", ""); err == nil || !strings.Contains(err.Error(), "Steve, Alex") {
+		t.Errorf("several profiles: %v", err)
+	}
+
+	id, err := svc.AddYggdrasilAccount(ctx, y.srv.URL, "player", "secret", "alex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, err := svc.GetAccount(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Type != "yggdrasil" || acc.Name != "Alex" || acc.AuthServer != y.apiRoot() || acc.UUID != "fedcba98-7654-3210-fedc-ba9876543210" {
+		t.Errorf("account %+v", acc)
+	}
+	// The token handed out at login was bound to Alex with a refresh
+	tokens, err := svc.GetAccountTokens(ctx, id)
+	if err != nil || tokens.AccessToken != "token-3" || tokens.ClientToken == "" {
+		t.Errorf("tokens %+v, %v", tokens, err)
+	}
+
+	// Signing in again updates the same account
+	again, err := svc.AddYggdrasilAccount(ctx, y.apiRoot(), "player", "secret", "Alex")
+	if err != nil || again != id {
+		t.Errorf("second login: %s, %v", again, err)
+	}
+}
+
+func TestYggdrasilLaunchAuth(t *testing.T) {
+	svc, _, _ := newTestAccountService(t)
+	y := newYggdrasilServer(t)
+	ctx := context.Background()
+	id, err := svc.AddYggdrasilAccount(ctx, y.srv.URL, "player", "secret", "Steve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := svc.GetAccountTokens(ctx, id)
+
+	auth, err := svc.GetLaunchAuth(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefetched, _ := base64.StdEncoding.DecodeString(auth.AuthlibInjectorPrefetched)
+	if auth.AccessToken != stored.AccessToken || auth.UserType != "mojang" || auth.AuthlibInjectorAPIRoot != y.apiRoot() || !strings.Contains(string(prefetched), "Test") {
+		t.Errorf("launch auth %+v", auth)
+	}
+
+	// A token the server no longer accepts is refreshed and stored
+	y.expire()
+	auth, err = svc.GetLaunchAuth(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens, _ := svc.GetAccountTokens(ctx, id); auth.AccessToken == stored.AccessToken || tokens.AccessToken != auth.AccessToken || tokens.ClientToken != stored.ClientToken {
+		t.Errorf("refreshed %q, stored %+v", auth.AccessToken, tokens)
+	}
+
+	// Once refresh is refused too, the user has to sign in again
+	y.expire()
+	y.revoked = true
+	if _, err := svc.GetLaunchAuth(ctx, id); !errors.Is(err, yggdrasil.ErrInvalidToken) {
+		t.Errorf("revoked: %v", err)
+	}
+}
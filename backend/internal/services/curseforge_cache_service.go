@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"hyenimc/backend/internal/cache"
+	"hyenimc/backend/internal/httpclient"
 )
 
 const (
@@ -39,9 +40,7 @@ type CurseForgeCacheService struct {
 func NewCurseForgeCacheService(cacheRepo *cache.APICacheRepository, apiKey string) *CurseForgeCacheService {
 	return &CurseForgeCacheService{
 		cacheRepo: cacheRepo,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: httpclient.New(30 * time.Second),
 		apiKey: apiKey,
 	}
 }
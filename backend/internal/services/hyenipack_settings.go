@@ -0,0 +1,57 @@
+package services
+
+import (
+	"path/filepath"
+
+	"hyenimc/backend/internal/settings"
+)
+
+// HyeniPackMetadata is the hyeniPack block the launcher writes into
+// .hyenimc-metadata.json when a HyeniPack is installed
+type HyeniPackMetadata struct {
+	Name     string             `json:"name"`
+	Version  string             `json:"version"`
+	Author   string             `json:"author"`
+	Settings *HyeniPackSettings `json:"settings,omitempty"`
+}
+
+// HyeniPackSettings mirrors ModpackSettings of the HyeniPack manifest
+type HyeniPackSettings struct {
+	Memory *struct {
+		Recommended int32 `json:"recommended"`
+		Minimum     int32 `json:"minimum"`
+	} `json:"memory,omitempty"`
+	Java *struct {
+		MinimumVersion     int32 `json:"minimumVersion"`
+		RecommendedVersion int32 `json:"recommendedVersion"`
+	} `json:"java,omitempty"`
+	Resolution *struct {
+		Width  int32 `json:"width"`
+		Height int32 `json:"height"`
+	} `json:"resolution,omitempty"`
+}
+
+// LoadHyeniPackRecommendations returns the settings recommended by the HyeniPack
+// installed in gameDir, or nil if there is none
+func LoadHyeniPackRecommendations(gameDir string) *settings.PackRecommendations {
+	unified, err := loadUnifiedMetadata(filepath.Join(gameDir, "mods"))
+	if err != nil || unified.HyeniPack == nil || unified.HyeniPack.Settings == nil {
+		return nil
+	}
+
+	ps := unified.HyeniPack.Settings
+	rec := &settings.PackRecommendations{}
+	if ps.Memory != nil {
+		rec.MemoryRecommended = ps.Memory.Recommended
+		rec.MemoryMinimum = ps.Memory.Minimum
+	}
+	if ps.Java != nil {
+		rec.JavaMinimumVersion = ps.Java.MinimumVersion
+		rec.JavaRecommendedVersion = ps.Java.RecommendedVersion
+	}
+	if ps.Resolution != nil {
+		rec.ResolutionWidth = ps.Resolution.Width
+		rec.ResolutionHeight = ps.Resolution.Height
+	}
+	return rec
+}
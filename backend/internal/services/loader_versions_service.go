@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"hyenimc/backend/internal/cache"
+	"hyenimc/backend/internal/httpclient"
 )
 
 const (
@@ -28,9 +29,7 @@ type LoaderVersionsService struct {
 func NewLoaderVersionsService(cacheRepo *cache.LoaderVersionsRepository) *LoaderVersionsService {
 	return &LoaderVersionsService{
 		cacheRepo: cacheRepo,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: httpclient.New(30 * time.Second),
 	}
 }
 
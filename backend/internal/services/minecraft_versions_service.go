@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"hyenimc/backend/internal/cache"
+	"hyenimc/backend/internal/httpclient"
 )
 
 const (
@@ -45,9 +46,7 @@ type MinecraftVersionsService struct {
 func NewMinecraftVersionsService(cacheRepo *cache.APICacheRepository) *MinecraftVersionsService {
 	return &MinecraftVersionsService{
 		cacheRepo: cacheRepo,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: httpclient.New(30 * time.Second),
 	}
 }
 
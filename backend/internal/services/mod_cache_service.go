@@ -539,6 +539,7 @@ type SourceMetadata struct {
 type UnifiedMetadata struct {
 	Version   int                       `json:"version"`
 	Source    string                    `json:"source"`
+	HyeniPack *HyeniPackMetadata        `json:"hyeniPack,omitempty"`
 	Mods      map[string]SourceMetadata `json:"mods"`
 }
 
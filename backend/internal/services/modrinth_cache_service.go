@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"hyenimc/backend/internal/cache"
+	"hyenimc/backend/internal/httpclient"
 )
 
 const (
@@ -39,9 +40,7 @@ type ModrinthCacheService struct {
 func NewModrinthCacheService(cacheRepo *cache.APICacheRepository) *ModrinthCacheService {
 	return &ModrinthCacheService{
 		cacheRepo: cacheRepo,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: httpclient.New(30 * time.Second),
 	}
 }
 
@@ -275,9 +274,6 @@ func (s *ModrinthCacheService) fetchFromAPI(url string) ([]byte, error) {
 		return nil, err
 	}
 
-	// Set Modrinth API headers
-	req.Header.Set("User-Agent", "HyeniMC/1.0")
-
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch from Modrinth: %w", err)
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hyenimc/backend/internal/cache"
+	"hyenimc/backend/internal/domain"
+)
+
+// Instance content CloneProfile can copy
+const (
+	CloneMods          = "mods"
+	CloneConfig        = "config"
+	CloneSaves         = "saves"
+	CloneResourcePacks = "resourcepacks"
+	CloneShaderPacks   = "shaderpacks"
+)
+
+// CloneContents lists every selectable content directory
+var CloneContents = []string{CloneMods, CloneConfig, CloneSaves, CloneResourcePacks, CloneShaderPacks}
+
+// linkableExts are archives that are replaced rather than edited in place, so the
+// clone can share them with the source through hardlinks. Everything else
+// (configs, worlds, metadata JSON) is copied.
+var linkableExts = map[string]bool{".jar": true, ".zip": true, ".disabled": true, ".litemod": true}
+
+// CloneOptions controls CloneProfile
+type CloneOptions struct {
+	Name    string   // empty = "<source name> (copy)"
+	Include []string // content directories to copy, empty = all of CloneContents
+}
+
+// CloneResult describes a finished clone
+type CloneResult struct {
+	Profile *domain.Profile
+	Linked  int // files hardlinked to the source instance
+	Copied  int // files copied
+}
+
+// ProfileCloneService duplicates profiles together with their instance content
+type ProfileCloneService struct {
+	profiles      *ProfileService
+	mods          *cache.ModRepository
+	resourcePacks *cache.ResourcePackRepository
+	shaderPacks   *cache.ShaderPackRepository
+}
+
+// NewProfileCloneService creates a new profile clone service
+func NewProfileCloneService(profiles *ProfileService, mods *cache.ModRepository, resourcePacks *cache.ResourcePackRepository, shaderPacks *cache.ShaderPackRepository) *ProfileCloneService {
+	return &ProfileCloneService{
+		profiles:      profiles,
+		mods:          mods,
+		resourcePacks: resourcePacks,
+		shaderPacks:   shaderPacks,
+	}
+}
+
+// CloneProfile copies a profile and the selected parts of its instance directory into a
+// new profile. Files in the instance root (options.txt, servers.dat, ...) are always copied.
+// Cached mod/resource pack/shader pack rows are duplicated so the clone needs no rescan.
+func (s *ProfileCloneService) CloneProfile(ctx context.Context, sourceID string, opts CloneOptions) (*CloneResult, error) {
+	source, err := s.profiles.GetProfile(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	include := make(map[string]bool)
+	if len(opts.Include) == 0 {
+		for _, c := range CloneContents {
+			include[c] = true
+		}
+	}
+	for _, c := range opts.Include {
+		if !isCloneContent(c) {
+			return nil, fmt.Errorf("unknown clone content %q", c)
+		}
+		include[c] = true
+	}
+
+	clone := *source
+	clone.ID = uuid.New().String()
+	clone.Name = strings.TrimSpace(opts.Name)
+	if clone.Name == "" {
+		clone.Name = source.Name + " (copy)"
+	}
+	clone.GameDirectory = s.profiles.instanceDir(clone.ID)
+	clone.JvmArgs = append([]string{}, source.JvmArgs...)
+	clone.GameArgs = append([]string{}, source.GameArgs...)
+	clone.CreatedAt = time.Now()
+	clone.UpdatedAt = clone.CreatedAt
+	clone.LastPlayed = time.Time{}
+	clone.TotalPlayTime = 0
+	clone.Favorite = false
+	clone.Tags = append([]string{}, source.Tags...)
+
+	result := &CloneResult{Profile: &clone}
+	if err := createInstanceDirs(clone.GameDirectory); err != nil {
+		return nil, err
+	}
+	if err := s.copyInstance(ctx, source.GameDirectory, clone.GameDirectory, include, result); err != nil {
+		os.RemoveAll(clone.GameDirectory)
+		return nil, err
+	}
+
+	if err := s.profiles.repo.Save(&clone); err != nil {
+		os.RemoveAll(clone.GameDirectory)
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	// The copy keeps the source's tags and sits at the end of its group
+	if err := s.profiles.repo.SetTags(clone.ID, clone.Tags); err != nil {
+		log.Printf("[Profile] Clone %s: failed to copy tags: %v", clone.ID, err)
+	}
+	if source.GroupID != "" {
+		if err := s.profiles.repo.AssignGroup(clone.ID, source.GroupID); err != nil {
+			log.Printf("[Profile] Clone %s: failed to assign group: %v", clone.ID, err)
+		}
+	}
+	if fresh, err := s.profiles.repo.Get(clone.ID); err == nil {
+		result.Profile = fresh
+	}
+
+	// A missing cache row only costs a rescan, so failures here do not fail the clone
+	if err := s.cloneCacheRows(source, &clone, include); err != nil {
+		log.Printf("[Profile] Clone %s: failed to copy cached content rows: %v", clone.ID, err)
+	}
+
+	log.Printf("[Profile] Cloned %s into %s (%d linked, %d copied)", source.ID, clone.ID, result.Linked, result.Copied)
+	return result, nil
+}
+
+// copyInstance copies root files and the included content directories from src to dst
+func (s *ProfileCloneService) copyInstance(ctx context.Context, src, dst string, include map[string]bool, result *CloneResult) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // instance was never installed
+		}
+		return fmt.Errorf("failed to read instance directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.Type().IsRegular():
+			if err := copyFileKeepTime(filepath.Join(src, name), filepath.Join(dst, name)); err != nil {
+				return err
+			}
+			result.Copied++
+		case entry.IsDir() && include[name]:
+			link := name == CloneMods || name == CloneResourcePacks || name == CloneShaderPacks
+			if err := copyTree(ctx, filepath.Join(src, name), filepath.Join(dst, name), link, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyTree copies a directory tree, hardlinking archives when link is set and the
+// filesystem allows it. Directory modification times are kept as well, since
+// directory resource packs are cached by them.
+func copyTree(ctx context.Context, src, dst string, link bool, result *CloneResult) error {
+	type dirTime struct {
+		path string
+		mod  time.Time
+	}
+	var dirs []dirTime
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				dirs = append(dirs, dirTime{target, info.ModTime()})
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil // skip symlinks and special files
+		}
+		if link && linkableExts[strings.ToLower(filepath.Ext(path))] {
+			if err := os.Link(path, target); err == nil {
+				result.Linked++
+				return nil
+			}
+		}
+		if err := copyFileKeepTime(path, target); err != nil {
+			return err
+		}
+		result.Copied++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Children first, so restoring a parent is not undone by writes below it
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Chtimes(dirs[i].path, dirs[i].mod, dirs[i].mod)
+	}
+	return nil
+}
+
+// copyFileKeepTime copies a file and its modification time, which the content caches
+// use to detect changes
+func copyFileKeepTime(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// cloneCacheRows duplicates the cached content rows of the copied directories
+func (s *ProfileCloneService) cloneCacheRows(source, clone *domain.Profile, include map[string]bool) error {
+	now := time.Now()
+	rebase := func(path, dir, fileName string) string {
+		if rel, err := filepath.Rel(source.GameDirectory, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join(clone.GameDirectory, rel)
+		}
+		return filepath.Join(clone.GameDirectory, dir, fileName)
+	}
+
+	if include[CloneMods] && s.mods != nil {
+		mods, err := s.mods.ListByProfile(source.ID)
+		if err != nil {
+			return err
+		}
+		for _, m := range mods {
+			m.ID = uuid.New().String()
+			m.ProfileID = clone.ID
+			m.FilePath = rebase(m.FilePath, CloneMods, m.FileName)
+			m.CreatedAt, m.UpdatedAt = now, now
+		}
+		if err := s.mods.BatchSave(mods); err != nil {
+			return err
+		}
+	}
+
+	if include[CloneResourcePacks] && s.resourcePacks != nil {
+		packs, err := s.resourcePacks.ListByProfile(source.ID)
+		if err != nil {
+			return err
+		}
+		for _, p := range packs {
+			p.ID = uuid.New().String()
+			p.ProfileID = clone.ID
+			p.FilePath = rebase(p.FilePath, CloneResourcePacks, p.FileName)
+			p.CreatedAt, p.UpdatedAt = now, now
+			if err := s.resourcePacks.Save(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	if include[CloneShaderPacks] && s.shaderPacks != nil {
+		packs, err := s.shaderPacks.ListByProfile(source.ID)
+		if err != nil {
+			return err
+		}
+		for _, p := range packs {
+			p.ID = uuid.New().String()
+			p.ProfileID = clone.ID
+			p.FilePath = rebase(p.FilePath, CloneShaderPacks, p.FileName)
+			p.CreatedAt, p.UpdatedAt = now, now
+			if err := s.shaderPacks.Save(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isCloneContent(name string) bool {
+	for _, c := range CloneContents {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hyenimc/backend/internal/cache"
+	"hyenimc/backend/internal/domain"
+)
+
+// newCloneSource returns a clone service and a profile whose instance lives in a
+// custom game directory with a mod, a mod config, a config file and a world
+func newCloneSource(t *testing.T) (*ProfileCloneService, *domain.Profile) {
+	t.Helper()
+	profiles, conn := newTestProfileService(t)
+	clones := NewProfileCloneService(profiles, cache.NewModRepository(conn), cache.NewResourcePackRepository(conn), cache.NewShaderPackRepository(conn))
+
+	gameDir := filepath.Join(t.TempDir(), "games", "survival")
+	source, err := profiles.ImportProfile(context.Background(), &domain.Profile{
+		Name: "Survival", GameVersion: "1.21.1", LoaderType: "fabric", GameDirectory: gameDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		"options.txt":            "fov:90",
+		"mods/sodium.jar":        "jar",
+		"mods/sodium.toml":       "setting = 1",
+		"config/sodium.json":     "{}",
+		"saves/World/level.dat":  "level",
+		"resourcepacks/pack.zip": "zip",
+	} {
+		path = filepath.Join(gameDir, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return clones, source
+}
+
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+	ai, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(ai, bi)
+}
+
+func TestCloneProfile(t *testing.T) {
+	clones, source := newCloneSource(t)
+	result, err := clones.CloneProfile(context.Background(), source.ID, CloneOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := result.Profile
+
+	// The clone gets its own instance instead of sharing the custom directory
+	if want := clones.profiles.instanceDir(clone.ID); clone.GameDirectory != want {
+		t.Errorf("game directory %s, want %s", clone.GameDirectory, want)
+	}
+	if stored, _ := clones.profiles.GetProfile(context.Background(), clone.ID); stored.GameDirectory != clone.GameDirectory || stored.Name != "Survival (copy)" {
+		t.Errorf("stored profile %+v", stored)
+	}
+
+	// Archives in content directories are shared, everything else is copied
+	src, dst := source.GameDirectory, clone.GameDirectory
+	for rel, linked := range map[string]bool{
+		"mods/sodium.jar":        true,
+		"resourcepacks/pack.zip": true,
+		"mods/sodium.toml":       false,
+		"config/sodium.json":     false,
+		"saves/World/level.dat":  false,
+		"options.txt":            false,
+	} {
+		if got := sameFile(t, filepath.Join(src, rel), filepath.Join(dst, rel)); got != linked {
+			t.Errorf("%s: linked %v, want %v", rel, got, linked)
+		}
+	}
+	if result.Linked != 2 || result.Copied != 4 {
+		t.Errorf("linked %d, copied %d", result.Linked, result.Copied)
+	}
+}
+
+func TestCloneProfileInclude(t *testing.T) {
+	clones, source := newCloneSource(t)
+	ctx := context.Background()
+	if _, err := clones.CloneProfile(ctx, source.ID, CloneOptions{Include: []string{"logs"}}); err == nil {
+		t.Error("unknown content: expected an error")
+	}
+
+	result, err := clones.CloneProfile(ctx, source.ID, CloneOptions{Name: "Configs", Include: []string{CloneConfig}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := result.Profile.GameDirectory
+	for rel, want := range map[string]bool{
+		"options.txt":            true, // root files are always copied
+		"config/sodium.json":     true,
+		"mods/sodium.jar":        false,
+		"saves/World/level.dat":  false,
+		"resourcepacks/pack.zip": false,
+	} {
+		if _, err := os.Stat(filepath.Join(dst, rel)); (err == nil) != want {
+			t.Errorf("%s: present %v, want %v", rel, err == nil, want)
+		}
+	}
+	if result.Profile.Name != "Configs" {
+		t.Errorf("name %q", result.Profile.Name)
+	}
+}
+
+func TestCloneProfileCacheRows(t *testing.T) {
+	clones, source := newCloneSource(t)
+	// One row inside the source instance, one whose path is stale
+	for _, m := range []*domain.Mod{
+		{ID: "m1", ProfileID: source.ID, FileName: "sodium.jar", FilePath: filepath.Join(source.GameDirectory, "mods", "sodium.jar"), Enabled: true},
+		{ID: "m2", ProfileID: source.ID, FileName: "lithium.jar", FilePath: filepath.Join("/old", "instance", "mods", "lithium.jar")},
+	} {
+		if err := clones.mods.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := clones.resourcePacks.Save(&domain.ResourcePack{ID: "r1", ProfileID: source.ID, FileName: "pack.zip", FilePath: filepath.Join(source.GameDirectory, "resourcepacks", "pack.zip")}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := clones.CloneProfile(context.Background(), source.ID, CloneOptions{Include: []string{CloneMods}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := result.Profile
+
+	mods, err := clones.mods.ListByProfile(clone.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]string{}
+	for _, m := range mods {
+		if m.ID == "m1" || m.ID == "m2" {
+			t.Errorf("row %s reused its id", m.ID)
+		}
+		paths[m.FileName] = m.FilePath
+	}
+	for name, want := range map[string]string{
+		"sodium.jar":  filepath.Join(clone.GameDirectory, "mods", "sodium.jar"),
+		"lithium.jar": filepath.Join(clone.GameDirectory, "mods", "lithium.jar"),
+	} {
+		if paths[name] != want {
+			t.Errorf("%s: path %q, want %q", name, paths[name], want)
+		}
+	}
+	if source, _ := clones.mods.ListByProfile(source.ID); len(source) != 2 {
+		t.Errorf("source has %d mod rows", len(source))
+	}
+
+	// Resource packs were not included, so neither are their rows
+	if packs, _ := clones.resourcePacks.ListByProfile(clone.ID); len(packs) != 0 {
+		t.Errorf("%d resource pack rows", len(packs))
+	}
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/profile"
+)
+
+// Profile sort orders. Each has a natural direction; ProfileQuery.Reverse flips it.
+const (
+	ProfileSortDefault    = ""            // last played, then created, as stored
+	ProfileSortLastPlayed = "last_played" // most recent first
+	ProfileSortPlayTime   = "play_time"   // most played first
+	ProfileSortName       = "name"        // A to Z
+	ProfileSortCreated    = "created"     // newest first
+	ProfileSortGroup      = "group"       // group order, then order inside the group; ungrouped last
+)
+
+const (
+	maxProfileTags = 20
+	maxTagLength   = 32
+	maxGroupName   = 64
+)
+
+// ProfileQuery filters and orders a profile list. Zero fields match everything.
+type ProfileQuery struct {
+	Search             string // words matched against name, description and tags
+	LoaderType         string
+	GameVersion        string
+	Tags               []string // profiles must carry all of them
+	GroupID            string   // UngroupedID selects profiles without a group
+	FavoritesOnly      bool
+	InstallationStatus string
+	Sort               string
+	Reverse            bool
+}
+
+// UngroupedID selects profiles without a group in ProfileQuery.GroupID
+const UngroupedID = "ungrouped"
+
+// FilterProfiles returns the profiles matching q in the order it asks for.
+// TotalPlayTime and LastPlayed should already hold the launch statistics.
+func (s *ProfileService) FilterProfiles(profiles []*domain.Profile, q ProfileQuery) ([]*domain.Profile, error) {
+	words := strings.Fields(strings.ToLower(q.Search))
+	out := make([]*domain.Profile, 0, len(profiles))
+	for _, p := range profiles {
+		if q.LoaderType != "" && !strings.EqualFold(p.LoaderType, q.LoaderType) {
+			continue
+		}
+		if q.GameVersion != "" && p.GameVersion != q.GameVersion {
+			continue
+		}
+		if q.FavoritesOnly && !p.Favorite {
+			continue
+		}
+		if q.InstallationStatus != "" && profileStatus(p) != q.InstallationStatus {
+			continue
+		}
+		if q.GroupID == UngroupedID && p.GroupID != "" || q.GroupID != "" && q.GroupID != UngroupedID && p.GroupID != q.GroupID {
+			continue
+		}
+		if !hasAllTags(p, q.Tags) || !matchesSearch(p, words) {
+			continue
+		}
+		out = append(out, p)
+	}
+
+	var less func(a, b *domain.Profile) bool
+	switch q.Sort {
+	case ProfileSortDefault:
+	case ProfileSortLastPlayed:
+		less = func(a, b *domain.Profile) bool { return a.LastPlayed.After(b.LastPlayed) }
+	case ProfileSortPlayTime:
+		less = func(a, b *domain.Profile) bool { return a.TotalPlayTime > b.TotalPlayTime }
+	case ProfileSortName:
+		less = func(a, b *domain.Profile) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case ProfileSortCreated:
+		less = func(a, b *domain.Profile) bool { return a.CreatedAt.After(b.CreatedAt) }
+	case ProfileSortGroup:
+		groups, err := s.repo.ListGroups()
+		if err != nil {
+			return nil, err
+		}
+		rank := make(map[string]int, len(groups))
+		for i, g := range groups {
+			rank[g.ID] = i
+		}
+		groupRank := func(p *domain.Profile) int {
+			if r, ok := rank[p.GroupID]; ok {
+				return r
+			}
+			return len(groups)
+		}
+		less = func(a, b *domain.Profile) bool {
+			if ra, rb := groupRank(a), groupRank(b); ra != rb {
+				return ra < rb
+			}
+			return a.GroupOrder < b.GroupOrder
+		}
+	default:
+		return nil, fmt.Errorf("unknown sort order %q", q.Sort)
+	}
+	if less != nil {
+		sort.SliceStable(out, func(i, j int) bool {
+			if q.Reverse {
+				return less(out[j], out[i])
+			}
+			return less(out[i], out[j])
+		})
+	} else if q.Reverse {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+	return out, nil
+}
+
+func profileStatus(p *domain.Profile) string {
+	if p.InstallationStatus == "" {
+		return "complete"
+	}
+	return p.InstallationStatus
+}
+
+func hasAllTags(p *domain.Profile, tags []string) bool {
+	for _, want := range tags {
+		found := false
+		for _, tag := range p.Tags {
+			if strings.EqualFold(tag, strings.TrimSpace(want)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesSearch reports whether every word occurs in the name, description or a tag
+func matchesSearch(p *domain.Profile, words []string) bool {
+	if len(words) == 0 {
+		return true
+	}
+	text := strings.ToLower(p.Name + "\n" + p.Description + "\n" + strings.Join(p.Tags, "\n"))
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetProfileTags replaces the tags of a profile
+func (s *ProfileService) SetProfileTags(ctx context.Context, id string, tags []string) (*domain.Profile, error) {
+	if _, err := s.repo.Get(id); err != nil {
+		return nil, err
+	}
+	tags = profile.NormalizeTags(tags)
+	if len(tags) > maxProfileTags {
+		return nil, fmt.Errorf("a profile can have at most %d tags", maxProfileTags)
+	}
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+	}
+	if err := s.repo.SetTags(id, tags); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(id)
+}
+
+// ListTags returns every tag in use with its profile count
+func (s *ProfileService) ListTags(ctx context.Context) ([]profile.TagCount, error) {
+	return s.repo.ListTags()
+}
+
+// ListGroups returns all profile groups in display order
+func (s *ProfileService) ListGroups(ctx context.Context) ([]*profile.Group, error) {
+	return s.repo.ListGroups()
+}
+
+// CreateGroup adds a profile group after the existing ones
+func (s *ProfileService) CreateGroup(ctx context.Context, name string) (*profile.Group, error) {
+	name, err := validateGroupName(name)
+	if err != nil {
+		return nil, err
+	}
+	g := &profile.Group{ID: uuid.New().String(), Name: name}
+	if err := s.repo.CreateGroup(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// RenameGroup changes the name of a profile group
+func (s *ProfileService) RenameGroup(ctx context.Context, id, name string) (*profile.Group, error) {
+	name, err := validateGroupName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.RenameGroup(id, name); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGroup(id)
+}
+
+// DeleteGroup removes a profile group; its profiles become ungrouped
+func (s *ProfileService) DeleteGroup(ctx context.Context, id string) error {
+	return s.repo.DeleteGroup(id)
+}
+
+// ReorderGroups puts the listed groups first, in the given order
+func (s *ProfileService) ReorderGroups(ctx context.Context, ids []string) ([]*profile.Group, error) {
+	if err := s.repo.ReorderGroups(ids); err != nil {
+		return nil, err
+	}
+	return s.repo.ListGroups()
+}
+
+// AssignProfileGroup moves a profile to the end of a group; an empty groupID ungroups it
+func (s *ProfileService) AssignProfileGroup(ctx context.Context, profileID, groupID string) (*domain.Profile, error) {
+	if _, err := s.repo.Get(profileID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.AssignGroup(profileID, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(profileID)
+}
+
+// ReorderGroupProfiles puts the listed profiles first inside a group, in the given order
+func (s *ProfileService) ReorderGroupProfiles(ctx context.Context, groupID string, profileIDs []string) error {
+	if _, err := s.repo.GetGroup(groupID); err != nil {
+		return err
+	}
+	return s.repo.ReorderGroupProfiles(groupID, profileIDs)
+}
+
+func validateGroupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("group name is required")
+	}
+	if utf8.RuneCountInString(name) > maxGroupName {
+		return "", fmt.Errorf("group name is longer than %d characters", maxGroupName)
+	}
+	return name, nil
+}
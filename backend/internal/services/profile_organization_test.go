@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"hyenimc/backend/internal/domain"
+)
+
+func TestFilterProfiles(t *testing.T) {
+	s, _ := newTestProfileService(t)
+	ctx := context.Background()
+	modded, err := s.CreateGroup(ctx, "Modded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers, err := s.CreateGroup(ctx, "Servers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Servers is ranked above Modded although it was created later
+	if _, err := s.ReorderGroups(ctx, []string{servers.ID, modded.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Listed in the stored order, which is the default sort
+	profiles := []*domain.Profile{
+		{ID: "hub", Name: "hub", LoaderType: "vanilla", GameVersion: "1.21.1", GroupID: servers.ID, GroupOrder: 1, TotalPlayTime: 50, CreatedAt: day, LastPlayed: day.Add(3 * time.Hour), Tags: []string{"PvP"}},
+		{ID: "create", Name: "Create", LoaderType: "fabric", GameVersion: "1.20.1", GroupID: modded.ID, TotalPlayTime: 300, CreatedAt: day.Add(time.Hour), LastPlayed: day.Add(2 * time.Hour), Favorite: true, Tags: []string{"tech", "coop"}},
+		{ID: "plain", Name: "Plain", LoaderType: "vanilla", GameVersion: "1.21.1", TotalPlayTime: 10, CreatedAt: day.Add(2 * time.Hour), InstallationStatus: "installing", Description: "Survival world"},
+		{ID: "lobby", Name: "Lobby", LoaderType: "Fabric", GameVersion: "1.21.1", GroupID: servers.ID, GroupOrder: 0, TotalPlayTime: 120, CreatedAt: day.Add(3 * time.Hour)},
+	}
+
+	for _, tt := range []struct {
+		name string
+		q    ProfileQuery
+		want []string
+	}{
+		{"default", ProfileQuery{}, []string{"hub", "create", "plain", "lobby"}},
+		{"default reversed", ProfileQuery{Reverse: true}, []string{"lobby", "plain", "create", "hub"}},
+		{"ungrouped", ProfileQuery{GroupID: UngroupedID}, []string{"plain"}},
+		{"group", ProfileQuery{GroupID: servers.ID}, []string{"hub", "lobby"}},
+		{"loader ignores case", ProfileQuery{LoaderType: "FABRIC"}, []string{"create", "lobby"}},
+		{"version and favorite", ProfileQuery{GameVersion: "1.20.1", FavoritesOnly: true}, []string{"create"}},
+		{"status", ProfileQuery{InstallationStatus: "complete"}, []string{"hub", "create", "lobby"}},
+		{"all tags", ProfileQuery{Tags: []string{"Tech", " coop"}}, []string{"create"}},
+		{"search words", ProfileQuery{Search: "survival PLAIN"}, []string{"plain"}},
+		{"search tags", ProfileQuery{Search: "pvp"}, []string{"hub"}},
+		{"last played", ProfileQuery{Sort: ProfileSortLastPlayed}, []string{"hub", "create", "plain", "lobby"}},
+		{"play time", ProfileQuery{Sort: ProfileSortPlayTime}, []string{"create", "lobby", "hub", "plain"}},
+		{"play time reversed", ProfileQuery{Sort: ProfileSortPlayTime, Reverse: true}, []string{"plain", "hub", "lobby", "create"}},
+		{"name", ProfileQuery{Sort: ProfileSortName}, []string{"create", "hub", "lobby", "plain"}},
+		{"created", ProfileQuery{Sort: ProfileSortCreated}, []string{"lobby", "plain", "create", "hub"}},
+		{"group rank", ProfileQuery{Sort: ProfileSortGroup}, []string{"lobby", "hub", "create", "plain"}},
+		{"group rank reversed", ProfileQuery{Sort: ProfileSortGroup, Reverse: true}, []string{"plain", "create", "hub", "lobby"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := s.FilterProfiles(profiles, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, p := range out {
+				got = append(got, p.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.FilterProfiles(profiles, ProfileQuery{Sort: "size"}); err == nil {
+		t.Error("unknown sort: expected an error")
+	}
+	// Filtering never reorders the caller's slice
+	if profiles[0].ID != "hub" || profiles[3].ID != "lobby" {
+		t.Error("input slice was modified")
+	}
+}
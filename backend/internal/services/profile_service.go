@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +20,10 @@ import (
 type ProfileService struct {
 	repo    *profile.Repository
 	dataDir string
+
+	trashMu     sync.Mutex // serializes moves in and out of the trash
+	retentionMu sync.RWMutex
+	retention   func() time.Duration
 }
 
 // NewProfileService creates a new profile service (using SQLite)
@@ -45,12 +50,7 @@ func (s *ProfileService) CreateProfile(ctx context.Context, req *domain.CreatePr
 	// Create profile with empty memory settings
 	// Memory will inherit from global settings at launch time
 	profileID := uuid.New().String()
-	
-	// GameDirectory should be: {dataDir}/../instances/{profile-id}
-	// dataDir is typically: C:\Users\xxx\AppData\Roaming\HyeniMC\data
-	// instances is at: C:\Users\xxx\AppData\Roaming\HyeniMC\instances
-	parentDir := filepath.Dir(s.dataDir) // Remove "data" from path
-	gameDir := filepath.Join(parentDir, "instances", profileID)
+	gameDir := s.instanceDir(profileID)
 	
 	profile := &domain.Profile{
 		ID:            profileID,
@@ -72,16 +72,8 @@ func (s *ProfileService) CreateProfile(ctx context.Context, req *domain.CreatePr
 		TotalPlayTime: 0,
 	}
 
-	// Create game directory (and subdirectories) if they don't exist
-	if err := os.MkdirAll(gameDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create game directory: %w", err)
-	}
-
-	// Create essential subdirectories
-	for _, subdir := range []string{"mods", "config", "resourcepacks", "shaderpacks", "saves"} {
-		if err := os.MkdirAll(filepath.Join(gameDir, subdir), 0755); err != nil {
-			return nil, fmt.Errorf("failed to create %s directory: %w", subdir, err)
-		}
+	if err := createInstanceDirs(gameDir); err != nil {
+		return nil, err
 	}
 
 	// Save profile
@@ -92,6 +84,65 @@ func (s *ProfileService) CreateProfile(ctx context.Context, req *domain.CreatePr
 	return profile, nil
 }
 
+// ImportProfile stores a fully populated profile coming from another installation.
+// An empty GameDirectory is placed in this installation's instances directory.
+func (s *ProfileService) ImportProfile(ctx context.Context, profile *domain.Profile) (*domain.Profile, error) {
+	if profile.ID == "" {
+		profile.ID = uuid.New().String()
+	}
+	// The id names the instance directory, so it must stay inside instances/
+	if !isPathElement(profile.ID) {
+		return nil, fmt.Errorf("invalid profile id: %q", profile.ID)
+	}
+	if profile.GameDirectory == "" {
+		profile.GameDirectory = s.instanceDir(profile.ID)
+	}
+	if err := createInstanceDirs(profile.GameDirectory); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if profile.CreatedAt.IsZero() {
+		profile.CreatedAt = now
+	}
+	profile.UpdatedAt = now
+
+	if err := s.repo.Save(profile); err != nil {
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+	return profile, nil
+}
+
+// instanceDir returns the game directory of a new profile: {dataDir}/../instances/{profile-id}.
+// dataDir is typically C:\Users\xxx\AppData\Roaming\HyeniMC\data and instances
+// live next to it in C:\Users\xxx\AppData\Roaming\HyeniMC\instances.
+func (s *ProfileService) instanceDir(profileID string) string {
+	parentDir := filepath.Dir(s.dataDir) // Remove "data" from path
+	return filepath.Join(parentDir, "instances", profileID)
+}
+
+// SharedDir returns the directory holding libraries and assets shared by all instances
+func (s *ProfileService) SharedDir() string {
+	return filepath.Join(filepath.Dir(s.dataDir), "shared")
+}
+
+// isPathElement reports whether name is a single directory name
+func isPathElement(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\:`) && filepath.VolumeName(name) == ""
+}
+
+// createInstanceDirs creates a game directory and its essential subdirectories
+func createInstanceDirs(gameDir string) error {
+	if err := os.MkdirAll(gameDir, 0755); err != nil {
+		return fmt.Errorf("failed to create game directory: %w", err)
+	}
+	for _, subdir := range []string{"mods", "config", "resourcepacks", "shaderpacks", "saves"} {
+		if err := os.MkdirAll(filepath.Join(gameDir, subdir), 0755); err != nil {
+			return fmt.Errorf("failed to create %s directory: %w", subdir, err)
+		}
+	}
+	return nil
+}
+
 // GetProfile retrieves a profile by ID
 func (s *ProfileService) GetProfile(ctx context.Context, id string) (*domain.Profile, error) {
 	profile, err := s.repo.Get(id)
@@ -267,11 +318,6 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, id string, updates m
 	return profile, nil
 }
 
-// DeleteProfile deletes a profile by ID
-func (s *ProfileService) DeleteProfile(ctx context.Context, id string) error {
-	return s.repo.Delete(id)
-}
-
 // sanitizeName creates a safe directory name from a profile name
 func sanitizeName(name string) string {
 	name = strings.ToLower(name)
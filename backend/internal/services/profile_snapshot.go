@@ -0,0 +1,442 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hyenimc/backend/internal/snapshot"
+)
+
+// Snapshot reasons
+const (
+	SnapshotReasonManual          = "manual"
+	SnapshotReasonHyeniPackUpdate = "hyenipack_update"
+	SnapshotReasonModUpdate       = "mod_update"
+	SnapshotReasonRollback        = "rollback" // taken automatically before a rollback
+)
+
+// Snapshot diff change types
+const (
+	SnapshotChangeAdded    = "added"
+	SnapshotChangeRemoved  = "removed"
+	SnapshotChangeModified = "modified"
+)
+
+// snapshotRoots are the instance paths every snapshot captures; saves are optional
+var snapshotRoots = []string{"mods", "config", "options.txt"}
+
+// SnapshotOptions controls CreateSnapshot
+type SnapshotOptions struct {
+	Reason       string // empty = manual
+	Label        string
+	IncludeSaves bool
+}
+
+// SnapshotLimits bounds how many snapshots are kept per profile
+type SnapshotLimits struct {
+	MaxPerProfile int
+	MaxAge        time.Duration // the newest snapshot is kept regardless of age
+}
+
+// SnapshotChange is one differing file between two instance states
+type SnapshotChange struct {
+	Path    string
+	Change  string // added|removed|modified, from the snapshot towards the compared state
+	OldSize int64
+	NewSize int64
+}
+
+// RollbackResult describes a finished rollback
+type RollbackResult struct {
+	Backup  *snapshot.Snapshot // state before the rollback, so it can be undone
+	Written int
+	Removed int
+}
+
+// SnapshotService captures and restores instance content of profiles
+type SnapshotService struct {
+	profiles *ProfileService
+	repo     *snapshot.Repository
+	store    *snapshot.Store
+
+	mu       sync.Mutex // serializes captures, rollbacks and blob collection
+	limitsMu sync.RWMutex
+	limits   func() SnapshotLimits
+}
+
+// NewSnapshotService creates a snapshot service storing blobs in {dataDir}/snapshots
+func NewSnapshotService(profiles *ProfileService, repo *snapshot.Repository, dataDir string) *SnapshotService {
+	return &SnapshotService{
+		profiles: profiles,
+		repo:     repo,
+		store:    snapshot.NewStore(filepath.Join(dataDir, "snapshots")),
+	}
+}
+
+// SetLimits sets the retention limits; fn is called after every capture
+func (s *SnapshotService) SetLimits(fn func() SnapshotLimits) {
+	s.limitsMu.Lock()
+	defer s.limitsMu.Unlock()
+	s.limits = fn
+}
+
+func (s *SnapshotService) currentLimits() SnapshotLimits {
+	s.limitsMu.RLock()
+	fn := s.limits
+	s.limitsMu.RUnlock()
+	if fn == nil {
+		return SnapshotLimits{MaxPerProfile: 10, MaxAge: 30 * 24 * time.Hour}
+	}
+	return fn()
+}
+
+// CreateSnapshot captures mods, config and options.txt (and saves when requested)
+// of a profile, then applies the retention limits to its older snapshots
+func (s *SnapshotService) CreateSnapshot(ctx context.Context, profileID string, opts SnapshotOptions) (*snapshot.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, err := s.capture(ctx, profileID, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.prune(profileID, snap.ID)
+	return snap, nil
+}
+
+func (s *SnapshotService) capture(ctx context.Context, profileID string, opts SnapshotOptions) (*snapshot.Snapshot, error) {
+	p, err := s.profiles.GetProfile(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Reason == "" {
+		opts.Reason = SnapshotReasonManual
+	}
+
+	local, err := collectInstanceFiles(p.GameDirectory, snapshotPaths(opts.IncludeSaves))
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot.Snapshot{
+		ID:           uuid.New().String(),
+		ProfileID:    profileID,
+		Reason:       opts.Reason,
+		Label:        strings.TrimSpace(opts.Label),
+		IncludeSaves: opts.IncludeSaves,
+		CreatedAt:    time.Now(),
+	}
+	files := make([]snapshot.File, 0, len(local))
+	for _, path := range sortedKeys(local) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info := local[path]
+		hash, stored, err := s.store.Put(info.abs)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, snapshot.File{Path: path, Hash: hash, Size: info.size, ModTime: info.modTime})
+		snap.TotalBytes += info.size
+		snap.StoredBytes += stored
+	}
+	snap.FileCount = len(files)
+
+	if err := s.repo.Save(snap, files); err != nil {
+		return nil, err
+	}
+	log.Printf("[Snapshot] Created %s for profile %s (%s): %d files, %d bytes, %d new bytes stored",
+		snap.ID, profileID, snap.Reason, snap.FileCount, snap.TotalBytes, snap.StoredBytes)
+	return snap, nil
+}
+
+// ListSnapshots returns the snapshots of a profile, newest first
+func (s *SnapshotService) ListSnapshots(ctx context.Context, profileID string) ([]*snapshot.Snapshot, error) {
+	return s.repo.ListByProfile(profileID)
+}
+
+// DiffSnapshot compares a snapshot with another snapshot of the same profile,
+// or with the current instance content when againstID is empty
+func (s *SnapshotService) DiffSnapshot(ctx context.Context, snapshotID, againstID string) ([]SnapshotChange, error) {
+	snap, err := s.repo.Get(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.repo.Files(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	var against []snapshot.File
+	if againstID != "" {
+		other, err := s.repo.Get(againstID)
+		if err != nil {
+			return nil, err
+		}
+		if other.ProfileID != snap.ProfileID {
+			return nil, fmt.Errorf("snapshots %s and %s belong to different profiles", snapshotID, againstID)
+		}
+		if against, err = s.repo.Files(againstID); err != nil {
+			return nil, err
+		}
+	} else {
+		p, err := s.profiles.GetProfile(ctx, snap.ProfileID)
+		if err != nil {
+			return nil, err
+		}
+		if against, err = currentFiles(ctx, p.GameDirectory, snap.IncludeSaves, files); err != nil {
+			return nil, err
+		}
+	}
+
+	return diffFiles(files, against), nil
+}
+
+// RollbackSnapshot restores the instance content of a snapshot. The current state is
+// captured first, files missing from the snapshot are removed and changed files are
+// rewritten; saves are only touched when the snapshot includes them.
+func (s *SnapshotService) RollbackSnapshot(ctx context.Context, snapshotID string) (*RollbackResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, err := s.repo.Get(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.repo.Files(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if !s.store.Has(f.Hash) {
+			return nil, fmt.Errorf("snapshot %s is incomplete: content of %s is missing", snapshotID, f.Path)
+		}
+	}
+	p, err := s.profiles.GetProfile(ctx, snap.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.capture(ctx, snap.ProfileID, SnapshotOptions{
+		Reason:       SnapshotReasonRollback,
+		Label:        "Before rollback to " + snap.CreatedAt.Format("2006-01-02 15:04"),
+		IncludeSaves: snap.IncludeSaves,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot current state: %w", err)
+	}
+	result := &RollbackResult{Backup: backup}
+
+	local, err := collectInstanceFiles(p.GameDirectory, snapshotPaths(snap.IncludeSaves))
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]snapshot.File, len(files))
+	for _, f := range files {
+		want[f.Path] = f
+	}
+
+	for path, info := range local {
+		if _, ok := want[path]; ok {
+			continue
+		}
+		if err := os.Remove(info.abs); err != nil {
+			return result, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		result.Removed++
+	}
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		dst := filepath.Join(p.GameDirectory, filepath.FromSlash(f.Path))
+		if info, ok := local[f.Path]; ok && info.size == f.Size {
+			if hash, err := snapshot.HashFile(dst); err == nil && hash == f.Hash {
+				continue
+			}
+		}
+		if err := s.store.Extract(f.Hash, dst, f.ModTime); err != nil {
+			return result, err
+		}
+		result.Written++
+	}
+
+	s.prune(snap.ProfileID, snapshotID)
+	log.Printf("[Snapshot] Rolled back profile %s to %s (%d written, %d removed, backup %s)",
+		snap.ProfileID, snapshotID, result.Written, result.Removed, backup.ID)
+	return result, nil
+}
+
+// DeleteSnapshot removes a snapshot and the blobs no other snapshot uses
+func (s *SnapshotService) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.Delete(snapshotID); err != nil {
+		return err
+	}
+	s.collect()
+	return nil
+}
+
+// prune applies the retention limits to a profile, never deleting keepID, and drops
+// unused blobs. Failures only leave extra snapshots behind, so they are logged.
+func (s *SnapshotService) prune(profileID, keepID string) {
+	limits := s.currentLimits()
+	snaps, err := s.repo.ListByProfile(profileID)
+	if err != nil {
+		log.Printf("[Snapshot] Failed to list snapshots for pruning: %v", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-limits.MaxAge)
+	for i, snap := range snaps {
+		if snap.ID == keepID {
+			continue
+		}
+		expired := i > 0 && limits.MaxAge > 0 && snap.CreatedAt.Before(cutoff)
+		if (limits.MaxPerProfile > 0 && i >= limits.MaxPerProfile) || expired {
+			if err := s.repo.Delete(snap.ID); err != nil {
+				log.Printf("[Snapshot] Failed to delete snapshot %s: %v", snap.ID, err)
+			}
+		}
+	}
+	s.collect()
+}
+
+// collect removes blobs no snapshot references, including those of profiles
+// purged from the trash (their rows go with the trash entry)
+func (s *SnapshotService) collect() {
+	keep, err := s.repo.ReferencedHashes()
+	if err != nil {
+		log.Printf("[Snapshot] Failed to list referenced blobs: %v", err)
+		return
+	}
+	removed, freed, err := s.store.Collect(keep)
+	if err != nil {
+		log.Printf("[Snapshot] Blob collection failed: %v", err)
+	}
+	if removed > 0 {
+		log.Printf("[Snapshot] Removed %d unused blobs (%d bytes)", removed, freed)
+	}
+}
+
+func snapshotPaths(includeSaves bool) []string {
+	if includeSaves {
+		return append(append([]string{}, snapshotRoots...), "saves")
+	}
+	return snapshotRoots
+}
+
+type instanceFile struct {
+	abs     string
+	size    int64
+	modTime time.Time
+}
+
+// collectInstanceFiles lists the regular files below the given roots of gameDir,
+// keyed by slash separated relative path
+func collectInstanceFiles(gameDir string, roots []string) (map[string]instanceFile, error) {
+	files := make(map[string]instanceFile)
+	for _, root := range roots {
+		err := filepath.WalkDir(filepath.Join(gameDir, root), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(gameDir, path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = instanceFile{abs: path, size: info.Size(), modTime: info.ModTime()}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", root, err)
+		}
+	}
+	return files, nil
+}
+
+// currentFiles describes the instance content as snapshot files. Files whose size
+// differs from the snapshot are not hashed, since they changed either way.
+func currentFiles(ctx context.Context, gameDir string, includeSaves bool, base []snapshot.File) ([]snapshot.File, error) {
+	local, err := collectInstanceFiles(gameDir, snapshotPaths(includeSaves))
+	if err != nil {
+		return nil, err
+	}
+	baseByPath := make(map[string]snapshot.File, len(base))
+	for _, f := range base {
+		baseByPath[f.Path] = f
+	}
+
+	files := make([]snapshot.File, 0, len(local))
+	for path, info := range local {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		f := snapshot.File{Path: path, Size: info.size, ModTime: info.modTime}
+		if old, ok := baseByPath[path]; ok && old.Size == info.size {
+			if f.Hash, err = snapshot.HashFile(info.abs); err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// diffFiles lists the changes from a to b ordered by path
+func diffFiles(a, b []snapshot.File) []SnapshotChange {
+	byPath := make(map[string]snapshot.File, len(b))
+	for _, f := range b {
+		byPath[f.Path] = f
+	}
+
+	var changes []SnapshotChange
+	for _, old := range a {
+		cur, ok := byPath[old.Path]
+		delete(byPath, old.Path)
+		switch {
+		case !ok:
+			changes = append(changes, SnapshotChange{Path: old.Path, Change: SnapshotChangeRemoved, OldSize: old.Size})
+		case cur.Size != old.Size || cur.Hash != old.Hash:
+			changes = append(changes, SnapshotChange{Path: old.Path, Change: SnapshotChangeModified, OldSize: old.Size, NewSize: cur.Size})
+		}
+	}
+	for _, cur := range byPath {
+		changes = append(changes, SnapshotChange{Path: cur.Path, Change: SnapshotChangeAdded, NewSize: cur.Size})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func sortedKeys(m map[string]instanceFile) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hyenimc/backend/internal/snapshot"
+)
+
+func TestSnapshotsFollowProfileThroughTrash(t *testing.T) {
+	profiles, conn := newTestProfileService(t)
+	ctx := context.Background()
+	p := createTestProfile(t, profiles)
+	os.MkdirAll(filepath.Join(p.GameDirectory, "mods"), 0755)
+	os.WriteFile(filepath.Join(p.GameDirectory, "mods", "a.jar"), []byte("mod"), 0644)
+
+	snapshots := NewSnapshotService(profiles, snapshot.NewRepository(conn), profiles.dataDir)
+	snap, err := snapshots.CreateSnapshot(ctx, p.ID, SnapshotOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := profiles.TrashProfile(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots.collect()
+	if list, _ := snapshots.ListSnapshots(ctx, p.ID); len(list) != 1 {
+		t.Fatalf("%d snapshots while in trash, want 1", len(list))
+	}
+
+	if _, err := profiles.RestoreProfile(ctx, entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snapshots.DiffSnapshot(ctx, snap.ID, ""); err != nil {
+		t.Errorf("snapshot unusable after restore: %v", err)
+	}
+
+	// Purging the trash entry takes the snapshots with it
+	entry, err = profiles.TrashProfile(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profiles.PurgeTrash(ctx, entry.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := snapshots.ListSnapshots(ctx, p.ID); len(list) != 0 {
+		t.Errorf("%d snapshots after purge, want 0", len(list))
+	}
+	if keep, _ := snapshots.repo.ReferencedHashes(); len(keep) != 0 {
+		t.Errorf("%d blobs still referenced after purge", len(keep))
+	}
+}
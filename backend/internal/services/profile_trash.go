@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/profile"
+)
+
+const (
+	// DefaultTrashRetention is used until SetTrashRetention is called
+	DefaultTrashRetention = 7 * 24 * time.Hour
+	// TrashPurgeInterval is how often StartTrashPurger removes expired entries
+	TrashPurgeInterval = time.Hour
+)
+
+// TrashPurgeResult describes a finished purge
+type TrashPurgeResult struct {
+	Purged         int
+	ReclaimedBytes int64
+}
+
+// SetTrashRetention sets how long deleted profiles stay in the trash.
+// fn is called on every purge so setting changes apply without a restart.
+func (s *ProfileService) SetTrashRetention(fn func() time.Duration) {
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+	s.retention = fn
+}
+
+func (s *ProfileService) trashRetention() time.Duration {
+	s.retentionMu.RLock()
+	fn := s.retention
+	s.retentionMu.RUnlock()
+	if fn == nil {
+		return DefaultTrashRetention
+	}
+	return fn()
+}
+
+// trashDir returns {dataDir}/../trash, next to the instances directory so
+// moving an instance there is a rename on the same volume
+func (s *ProfileService) trashDir() string {
+	return filepath.Join(filepath.Dir(s.dataDir), "trash")
+}
+
+// DeleteProfile moves a profile and its instance directory to the trash.
+// The instance can be restored with RestoreProfile until the retention period ends.
+func (s *ProfileService) DeleteProfile(ctx context.Context, id string) error {
+	_, err := s.TrashProfile(ctx, id)
+	return err
+}
+
+// TrashProfile moves a profile to the trash and returns the created entry.
+// The instance directory is only moved when it lives in this installation's
+// instances directory and no other profile uses it; otherwise it is left in place.
+func (s *ProfileService) TrashProfile(ctx context.Context, id string) (*profile.TrashEntry, error) {
+	s.trashMu.Lock()
+	defer s.trashMu.Unlock()
+
+	p, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &profile.TrashEntry{
+		ID:           uuid.New().String(),
+		ProfileID:    p.ID,
+		Name:         p.Name,
+		Profile:      p,
+		OriginalPath: p.GameDirectory,
+		DeletedAt:    time.Now(),
+	}
+
+	movable, err := s.ownsInstanceDir(p)
+	if err != nil {
+		return nil, err
+	}
+	if movable {
+		entry.SizeBytes = dirSize(p.GameDirectory)
+		entry.TrashPath = filepath.Join(s.trashDir(), entry.ID)
+		if err := os.MkdirAll(s.trashDir(), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create trash directory: %w", err)
+		}
+		if err := moveDir(p.GameDirectory, entry.TrashPath); err != nil {
+			return nil, fmt.Errorf("failed to move instance to trash: %w", err)
+		}
+	}
+
+	if err := s.repo.MoveToTrash(entry); err != nil {
+		if entry.TrashPath != "" {
+			if rbErr := moveDir(entry.TrashPath, entry.OriginalPath); rbErr != nil {
+				log.Printf("[Profile] Failed to move %s back after failed delete: %v", entry.TrashPath, rbErr)
+			}
+		}
+		return nil, err
+	}
+
+	log.Printf("[Profile] Moved profile %s (%s) to trash (%d bytes)", p.ID, p.Name, entry.SizeBytes)
+	return entry, nil
+}
+
+// ownsInstanceDir reports whether the game directory of p is a directory inside the
+// instances directory that no other profile points at
+func (s *ProfileService) ownsInstanceDir(p *domain.Profile) (bool, error) {
+	if p.GameDirectory == "" {
+		return false, nil
+	}
+	instancesDir := filepath.Dir(s.instanceDir(p.ID))
+	rel, err := filepath.Rel(instancesDir, p.GameDirectory)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, nil
+	}
+	if info, err := os.Stat(p.GameDirectory); err != nil || !info.IsDir() {
+		return false, nil
+	}
+
+	profiles, err := s.repo.List()
+	if err != nil {
+		return false, err
+	}
+	for _, other := range profiles {
+		if other.ID != p.ID && filepath.Clean(other.GameDirectory) == filepath.Clean(p.GameDirectory) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ListTrash returns the profiles in the trash, most recently deleted first
+func (s *ProfileService) ListTrash(ctx context.Context) ([]*profile.TrashEntry, error) {
+	return s.repo.ListTrash()
+}
+
+// TrashExpiry returns when entry is purged automatically
+func (s *ProfileService) TrashExpiry(entry *profile.TrashEntry) time.Time {
+	return entry.DeletedAt.Add(s.trashRetention())
+}
+
+// RestoreProfile moves a trashed profile and its instance directory back.
+// Rows that cascaded away with the profile (content caches) are rebuilt by the next scan.
+func (s *ProfileService) RestoreProfile(ctx context.Context, trashID string) (*domain.Profile, error) {
+	s.trashMu.Lock()
+	defer s.trashMu.Unlock()
+
+	entry, err := s.repo.GetTrash(trashID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.Get(entry.ProfileID); err == nil {
+		return nil, fmt.Errorf("profile %s already exists", entry.ProfileID)
+	}
+
+	if entry.TrashPath != "" {
+		if _, err := os.Stat(entry.OriginalPath); err == nil {
+			return nil, fmt.Errorf("cannot restore: %s already exists", entry.OriginalPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create instances directory: %w", err)
+		}
+		if err := moveDir(entry.TrashPath, entry.OriginalPath); err != nil {
+			return nil, fmt.Errorf("failed to restore instance directory: %w", err)
+		}
+	}
+
+	entry.Profile.UpdatedAt = time.Now()
+	if err := s.repo.RestoreFromTrash(entry); err != nil {
+		if entry.TrashPath != "" {
+			if rbErr := moveDir(entry.OriginalPath, entry.TrashPath); rbErr != nil {
+				log.Printf("[Profile] Failed to move %s back to trash after failed restore: %v", entry.OriginalPath, rbErr)
+			}
+		}
+		return nil, err
+	}
+
+	log.Printf("[Profile] Restored profile %s (%s) from trash", entry.ProfileID, entry.Name)
+	return entry.Profile, nil
+}
+
+// PurgeTrash permanently deletes trash entries: the entry trashID when set, every
+// entry when all is set, otherwise only entries older than the retention period
+func (s *ProfileService) PurgeTrash(ctx context.Context, trashID string, all bool) (*TrashPurgeResult, error) {
+	s.trashMu.Lock()
+	defer s.trashMu.Unlock()
+
+	var entries []*profile.TrashEntry
+	if trashID != "" {
+		entry, err := s.repo.GetTrash(trashID)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	} else {
+		list, err := s.repo.ListTrash()
+		if err != nil {
+			return nil, err
+		}
+		cutoff := time.Now().Add(-s.trashRetention())
+		for _, entry := range list {
+			if all || !entry.DeletedAt.After(cutoff) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	result := &TrashPurgeResult{}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := s.purgeEntry(entry); err != nil {
+			log.Printf("[Profile] Failed to purge trash entry %s: %v", entry.ID, err)
+			if trashID != "" {
+				return result, err
+			}
+			continue
+		}
+		result.Purged++
+		result.ReclaimedBytes += entry.SizeBytes
+	}
+
+	if result.Purged > 0 {
+		log.Printf("[Profile] Purged %d trash entries (%d bytes reclaimed)", result.Purged, result.ReclaimedBytes)
+	}
+	return result, nil
+}
+
+// purgeEntry removes the trashed instance directory and the entry
+func (s *ProfileService) purgeEntry(entry *profile.TrashEntry) error {
+	if entry.TrashPath != "" {
+		// Never delete anything outside the trash directory, whatever the row says
+		rel, err := filepath.Rel(s.trashDir(), entry.TrashPath)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("trash path %s is outside the trash directory", entry.TrashPath)
+		}
+		if err := removeAllWithRetry(entry.TrashPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.TrashPath, err)
+		}
+	}
+	return s.repo.DeleteTrash(entry.ID)
+}
+
+// StartTrashPurger purges expired trash entries now and then every interval until ctx is done
+func (s *ProfileService) StartTrashPurger(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.PurgeTrash(ctx, "", false); err != nil && ctx.Err() == nil {
+				log.Printf("[Profile] Trash purge failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// moveDir renames src to dst. Renames on Windows fail while another process
+// (an indexer, antivirus, a just-exited game) holds a handle, so they are retried.
+// There is deliberately no copy-and-delete fallback: the trash sits on the same
+// volume as the instances, so a rename failure means files are in use, and
+// deleting around them would leave a half-removed instance behind. On failure
+// nothing has changed.
+func moveDir(src, dst string) error {
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		if err = os.Rename(src, dst); err == nil {
+			return nil
+		}
+		if _, statErr := os.Stat(src); os.IsNotExist(statErr) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
+	}
+	return err
+}
+
+// removeAllWithRetry is os.RemoveAll retried for transient Windows sharing violations
+func removeAllWithRetry(path string) error {
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		if err = os.RemoveAll(path); err == nil {
+			return nil
+		}
+		time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
+	}
+	return err
+}
+
+// dirSize returns the total size of the regular files below path
+func dirSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/profile"
+)
+
+// newTestProfileService returns a profile service on a fresh database whose
+// instances live in a temporary directory
+func newTestProfileService(t *testing.T) (*ProfileService, *sql.DB) {
+	t.Helper()
+	conn := openTestDB(t)
+	return NewProfileService(profile.NewRepository(conn), filepath.Join(t.TempDir(), "data")), conn
+}
+
+func createTestProfile(t *testing.T, s *ProfileService) *domain.Profile {
+	t.Helper()
+	p, err := s.CreateProfile(context.Background(), &domain.CreateProfileRequest{Name: "Test", GameVersion: "1.21.1", LoaderType: "vanilla"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(p.GameDirectory, "options.txt"), []byte("fov:90"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTrashAndRestoreProfile(t *testing.T) {
+	s, _ := newTestProfileService(t)
+	ctx := context.Background()
+	p := createTestProfile(t, s)
+
+	entry, err := s.TrashProfile(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p.GameDirectory); !os.IsNotExist(err) {
+		t.Errorf("instance still in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(entry.TrashPath, "options.txt")); err != nil {
+		t.Errorf("instance not in trash: %v", err)
+	}
+
+	restored, err := s.RestoreProfile(ctx, entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != p.ID {
+		t.Errorf("restored %s, want %s", restored.ID, p.ID)
+	}
+	if data, err := os.ReadFile(filepath.Join(p.GameDirectory, "options.txt")); err != nil || string(data) != "fov:90" {
+		t.Errorf("instance not restored: %v", err)
+	}
+	if list, _ := s.ListTrash(ctx); len(list) != 0 {
+		t.Errorf("%d entries left in trash", len(list))
+	}
+}
+
+func TestMoveDirFailureChangesNothing(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	os.MkdirAll(src, 0755)
+	os.WriteFile(filepath.Join(src, "world.dat"), []byte("src"), 0644)
+	// A non-empty destination makes the rename fail on every platform
+	os.MkdirAll(dst, 0755)
+	os.WriteFile(filepath.Join(dst, "other.dat"), []byte("dst"), 0644)
+
+	if err := moveDir(src, dst); err == nil {
+		t.Fatal("expected the move to fail")
+	}
+	if data, err := os.ReadFile(filepath.Join(src, "world.dat")); err != nil || string(data) != "src" {
+		t.Errorf("source damaged: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "world.dat")); !os.IsNotExist(err) {
+		t.Errorf("partial copy left in destination: %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"hyenimc/backend/internal/nbt"
+)
+
+// Resource pack prompt settings of a server entry
+const (
+	ServerTexturesPrompt   = "prompt" // tag absent
+	ServerTexturesEnabled  = "enabled"
+	ServerTexturesDisabled = "disabled"
+)
+
+const defaultServerPort = "25565"
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// ServerEntry is one entry of an instance's multiplayer server list
+type ServerEntry struct {
+	Name           string
+	Address        string
+	Icon           string // base64 PNG, as stored in servers.dat
+	AcceptTextures string // prompt|enabled|disabled
+	Hidden         bool
+	Pinned         bool // the profile's server_address
+
+	extra nbt.Compound // tags this launcher does not model, written back unchanged
+}
+
+// ServerListService reads and writes the servers.dat of profile instances
+type ServerListService struct {
+	profiles *ProfileService
+}
+
+// NewServerListService creates a new server list service
+func NewServerListService(profiles *ProfileService) *ServerListService {
+	return &ServerListService{profiles: profiles}
+}
+
+// GetServers returns the server list of a profile in display order
+func (s *ServerListService) GetServers(ctx context.Context, profileID string) ([]*ServerEntry, error) {
+	p, err := s.profiles.GetProfile(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := readServersDat(filepath.Join(p.GameDirectory, "servers.dat"))
+	if err != nil {
+		return nil, err
+	}
+	markPinned(servers, p.ServerAddress)
+	return servers, nil
+}
+
+// SetServers replaces the server list of a profile. Tags of existing entries that
+// are not modelled here are kept for entries with the same address.
+func (s *ServerListService) SetServers(ctx context.Context, profileID string, servers []*ServerEntry) ([]*ServerEntry, error) {
+	p, err := s.profiles.GetProfile(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(p.GameDirectory, "servers.dat")
+	for i, entry := range servers {
+		if err := validateServerEntry(entry); err != nil {
+			return nil, fmt.Errorf("server %d: %w", i+1, err)
+		}
+	}
+
+	existing, err := readServersDat(path)
+	if err != nil {
+		return nil, err
+	}
+	used := make([]bool, len(existing))
+	for _, entry := range servers {
+		for i, old := range existing {
+			if !used[i] && sameServerAddress(old.Address, entry.Address) {
+				entry.extra = old.extra
+				used[i] = true
+				break
+			}
+		}
+	}
+
+	if err := os.MkdirAll(p.GameDirectory, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create game directory: %w", err)
+	}
+	if err := writeServersDat(path, servers); err != nil {
+		return nil, err
+	}
+	markPinned(servers, p.ServerAddress)
+	return servers, nil
+}
+
+// PinProfileServer makes sure the profile's server_address is the first entry of its
+// server list. An existing entry for the address is moved to the top, otherwise one is
+// added; every other entry stays as it is. name and icon (base64 PNG) replace those of
+// the pinned entry when set. It reports false when the profile has no server address.
+func (s *ServerListService) PinProfileServer(ctx context.Context, profileID, name, icon string) ([]*ServerEntry, bool, error) {
+	p, err := s.profiles.GetProfile(ctx, profileID)
+	if err != nil {
+		return nil, false, err
+	}
+	path := filepath.Join(p.GameDirectory, "servers.dat")
+	servers, err := readServersDat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	address := strings.TrimSpace(p.ServerAddress)
+	if address == "" {
+		return servers, false, nil
+	}
+	if icon != "" {
+		if err := validateServerIcon(icon); err != nil {
+			return nil, false, err
+		}
+	}
+
+	pinned := &ServerEntry{Name: address, Address: address, AcceptTextures: ServerTexturesPrompt}
+	index := -1
+	for i, entry := range servers {
+		if sameServerAddress(entry.Address, address) {
+			pinned, index = entry, i
+			break
+		}
+	}
+	changed := index != 0
+	if index > 0 {
+		servers = append(servers[:index], servers[index+1:]...)
+	}
+	if name != "" && pinned.Name != name {
+		pinned.Name, changed = name, true
+	}
+	if icon != "" && pinned.Icon != icon {
+		pinned.Icon, changed = icon, true
+	}
+	if pinned.Hidden {
+		pinned.Hidden, changed = false, true
+	}
+	if index != 0 {
+		servers = append([]*ServerEntry{pinned}, servers...)
+	}
+	markPinned(servers, address)
+
+	if !changed {
+		return servers, true, nil
+	}
+	if err := os.MkdirAll(p.GameDirectory, 0755); err != nil {
+		return nil, false, fmt.Errorf("failed to create game directory: %w", err)
+	}
+	if err := writeServersDat(path, servers); err != nil {
+		return nil, false, err
+	}
+	log.Printf("[ServerList] Pinned %s for profile %s", address, profileID)
+	return servers, true, nil
+}
+
+// markPinned flags the first entry for the profile's server address
+func markPinned(servers []*ServerEntry, address string) {
+	if strings.TrimSpace(address) == "" {
+		return
+	}
+	for _, entry := range servers {
+		if sameServerAddress(entry.Address, address) {
+			entry.Pinned = true
+			return
+		}
+	}
+}
+
+// readServersDat parses servers.dat; a missing file is an empty list
+func readServersDat(path string) ([]*ServerEntry, error) {
+	_, root, err := nbt.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*ServerEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read servers.dat: %w", err)
+	}
+
+	servers := []*ServerEntry{}
+	for _, item := range root.List("servers").Items {
+		c, ok := item.(nbt.Compound)
+		if !ok {
+			continue
+		}
+		entry := &ServerEntry{
+			Name:           c.String("name"),
+			Address:        c.String("ip"),
+			Icon:           c.String("icon"),
+			AcceptTextures: ServerTexturesPrompt,
+			Hidden:         c.Bool("hidden"),
+			extra:          nbt.Compound{},
+		}
+		if _, ok := c["acceptTextures"]; ok {
+			entry.AcceptTextures = ServerTexturesDisabled
+			if c.Bool("acceptTextures") {
+				entry.AcceptTextures = ServerTexturesEnabled
+			}
+		}
+		for k, v := range c {
+			switch k {
+			case "name", "ip", "icon", "acceptTextures", "hidden":
+			default:
+				entry.extra[k] = v
+			}
+		}
+		servers = append(servers, entry)
+	}
+	return servers, nil
+}
+
+// writeServersDat writes servers.dat uncompressed, as the game does
+func writeServersDat(path string, servers []*ServerEntry) error {
+	list := nbt.List{Type: nbt.TagCompound, Items: make([]any, 0, len(servers))}
+	for _, entry := range servers {
+		c := nbt.Compound{}
+		for k, v := range entry.extra {
+			c[k] = v
+		}
+		c["name"] = entry.Name
+		c["ip"] = entry.Address
+		if entry.Icon != "" {
+			c["icon"] = entry.Icon
+		}
+		switch entry.AcceptTextures {
+		case ServerTexturesEnabled:
+			c["acceptTextures"] = int8(1)
+		case ServerTexturesDisabled:
+			c["acceptTextures"] = int8(0)
+		}
+		if entry.Hidden {
+			c["hidden"] = int8(1)
+		}
+		list.Items = append(list.Items, c)
+	}
+
+	if err := nbt.WriteFile(path, "", nbt.Compound{"servers": list}, nbt.None); err != nil {
+		return fmt.Errorf("failed to write servers.dat: %w", err)
+	}
+	return nil
+}
+
+func validateServerEntry(entry *ServerEntry) error {
+	entry.Address = strings.TrimSpace(entry.Address)
+	if entry.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+	if entry.Name == "" {
+		entry.Name = entry.Address
+	}
+	switch entry.AcceptTextures {
+	case "":
+		entry.AcceptTextures = ServerTexturesPrompt
+	case ServerTexturesPrompt, ServerTexturesEnabled, ServerTexturesDisabled:
+	default:
+		return fmt.Errorf("invalid accept_textures %q", entry.AcceptTextures)
+	}
+	if entry.Icon != "" {
+		return validateServerIcon(entry.Icon)
+	}
+	return nil
+}
+
+// validateServerIcon checks that icon is a base64 encoded PNG
+func validateServerIcon(icon string) error {
+	data, err := base64.StdEncoding.DecodeString(icon)
+	if err != nil {
+		return fmt.Errorf("icon is not valid base64: %w", err)
+	}
+	if !bytes.HasPrefix(data, pngSignature) {
+		return fmt.Errorf("icon is not a PNG image")
+	}
+	return nil
+}
+
+// sameServerAddress compares addresses case-insensitively, treating a missing port as 25565
+func sameServerAddress(a, b string) bool {
+	return normalizeServerAddress(a) == normalizeServerAddress(b)
+}
+
+func normalizeServerAddress(address string) string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		if port == defaultServerPort {
+			return host
+		}
+		return net.JoinHostPort(host, port)
+	}
+	return strings.Trim(address, "[]")
+}
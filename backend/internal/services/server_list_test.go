@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/nbt"
+)
+
+// chatReports is a tag the launcher does not model and must keep
+var chatReports = nbt.Compound{"preventsChatReports": int8(1), "custom": nbt.Compound{"note": "lobby"}}
+
+// newServerListFixture writes a servers.dat with the hub in the middle and returns a
+// server list service for a profile whose server address is serverAddress
+func newServerListFixture(t *testing.T, serverAddress string) (*ServerListService, *domain.Profile) {
+	t.Helper()
+	profiles, _ := newTestProfileService(t)
+	p, err := profiles.ImportProfile(context.Background(), &domain.Profile{
+		Name: "Hub", GameVersion: "1.21.1", LoaderType: "vanilla", ServerAddress: serverAddress,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := nbt.Compound{"name": "Hub", "ip": "play.example.com:25565", "acceptTextures": int8(1)}
+	for k, v := range chatReports {
+		hub[k] = v
+	}
+	root := nbt.Compound{"servers": nbt.List{Type: nbt.TagCompound, Items: []any{
+		nbt.Compound{"name": "Friend", "ip": "friend.example.net", "hidden": int8(1), "acceptTextures": int8(0)},
+		hub,
+		nbt.Compound{"name": "Creative", "ip": "play.example.com:25566"},
+	}}}
+	if err := nbt.WriteFile(filepath.Join(p.GameDirectory, "servers.dat"), "", root, nbt.None); err != nil {
+		t.Fatal(err)
+	}
+	return NewServerListService(profiles), p
+}
+
+// readServerTags returns the raw compounds of servers.dat in order
+func readServerTags(t *testing.T, p *domain.Profile) []nbt.Compound {
+	t.Helper()
+	_, root, err := nbt.ReadFile(filepath.Join(p.GameDirectory, "servers.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var servers []nbt.Compound
+	for _, item := range root.List("servers").Items {
+		servers = append(servers, item.(nbt.Compound))
+	}
+	return servers
+}
+
+func serverNames(servers []*ServerEntry) []string {
+	names := []string{}
+	for _, s := range servers {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestGetServers(t *testing.T) {
+	svc, p := newServerListFixture(t, "PLAY.example.com")
+	servers, err := svc.GetServers(context.Background(), p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := serverNames(servers); !reflect.DeepEqual(got, []string{"Friend", "Hub", "Creative"}) {
+		t.Fatalf("servers %v", got)
+	}
+	friend, hub, creative := servers[0], servers[1], servers[2]
+	if !friend.Hidden || friend.AcceptTextures != ServerTexturesDisabled || friend.Pinned {
+		t.Errorf("friend %+v", friend)
+	}
+	// The address without a port is the hub on the default port, not the creative server
+	if !hub.Pinned || hub.AcceptTextures != ServerTexturesEnabled || creative.Pinned {
+		t.Errorf("hub %+v, creative %+v", hub, creative)
+	}
+	if creative.AcceptTextures != ServerTexturesPrompt {
+		t.Errorf("creative %+v", creative)
+	}
+}
+
+func TestPinProfileServer(t *testing.T) {
+	svc, p := newServerListFixture(t, "play.example.com")
+	ctx := context.Background()
+	icon := base64.StdEncoding.EncodeToString(pngImage(64, 64))
+
+	servers, ok, err := svc.PinProfileServer(ctx, p.ID, "", icon)
+	if err != nil || !ok {
+		t.Fatalf("pin: %v, %v", ok, err)
+	}
+	if got := serverNames(servers); !reflect.DeepEqual(got, []string{"Hub", "Friend", "Creative"}) {
+		t.Errorf("servers %v", got)
+	}
+
+	tags := readServerTags(t, p)
+	if len(tags) != 3 {
+		t.Fatalf("%d entries written", len(tags))
+	}
+	hub := tags[0]
+	if hub.String("ip") != "play.example.com:25565" || hub.String("icon") != icon || !hub.Bool("acceptTextures") {
+		t.Errorf("hub %v", hub)
+	}
+	for k, v := range chatReports {
+		if !reflect.DeepEqual(hub[k], v) {
+			t.Errorf("%s: %v, want %v", k, hub[k], v)
+		}
+	}
+	// The other entries keep their order and flags
+	if tags[1].String("name") != "Friend" || !tags[1].Bool("hidden") || tags[2].String("ip") != "play.example.com:25566" {
+		t.Errorf("others %v", tags[1:])
+	}
+
+	// Pinning again changes nothing
+	if servers, _, err := svc.PinProfileServer(ctx, p.ID, "", ""); err != nil || len(servers) != 3 || !servers[0].Pinned {
+		t.Errorf("second pin: %v, %v", serverNames(servers), err)
+	}
+}
+
+func TestPinProfileServerAddsEntry(t *testing.T) {
+	svc, p := newServerListFixture(t, "new.example.org:25565")
+	servers, ok, err := svc.PinProfileServer(context.Background(), p.ID, "New", "")
+	if err != nil || !ok {
+		t.Fatalf("pin: %v, %v", ok, err)
+	}
+	if got := serverNames(servers); !reflect.DeepEqual(got, []string{"New", "Friend", "Hub", "Creative"}) {
+		t.Errorf("servers %v", got)
+	}
+	if tags := readServerTags(t, p); tags[0].String("ip") != "new.example.org:25565" {
+		t.Errorf("first entry %v", tags[0])
+	}
+}
+
+func TestSetServersKeepsUnmodelledTags(t *testing.T) {
+	svc, p := newServerListFixture(t, "")
+	if _, ok, err := svc.PinProfileServer(context.Background(), p.ID, "", ""); ok || err != nil {
+		t.Errorf("pin without a server address: %v, %v", ok, err)
+	}
+
+	servers := []*ServerEntry{
+		{Name: "Lobby", Address: " play.example.com "},
+		{Name: "Other", Address: "other.example.com"},
+	}
+	if _, err := svc.SetServers(context.Background(), p.ID, servers); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := readServerTags(t, p)
+	if len(tags) != 2 || tags[0].String("ip") != "play.example.com" || tags[0].String("name") != "Lobby" {
+		t.Fatalf("servers %v", tags)
+	}
+	// The tags follow the hub to its new address without the port
+	for k, v := range chatReports {
+		if !reflect.DeepEqual(tags[0][k], v) {
+			t.Errorf("%s: %v, want %v", k, tags[0][k], v)
+		}
+	}
+	// acceptTextures is modelled, so the prompt setting removes it
+	if _, ok := tags[0]["acceptTextures"]; ok || len(tags[1]) != 2 {
+		t.Errorf("servers %v", tags)
+	}
+
+	if _, err := svc.SetServers(context.Background(), p.ID, []*ServerEntry{{Name: "Broken", Address: "a.example", Icon: "not base64"}}); err == nil {
+		t.Error("invalid icon: expected an error")
+	}
+}
+
+func TestSameServerAddress(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want bool
+	}{
+		{"play.example.com", "play.example.com:25565", true},
+		{"Play.Example.com:25565", " play.example.com", true},
+		{"play.example.com", "play.example.com:25566", false},
+		{"[::1]:25565", "::1", true},
+		{"[::1]:25566", "[::1]:25565", false},
+		{"play.example.com", "example.com", false},
+	} {
+		if got := sameServerAddress(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameServerAddress(%q, %q) = %v", tt.a, tt.b, got)
+		}
+	}
+}
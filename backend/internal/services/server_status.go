@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"hyenimc/backend/internal/slp"
+)
+
+const (
+	// ServerStatusTTL is how long a successful ping is reused
+	ServerStatusTTL = 30 * time.Second
+	// ServerStatusErrorTTL is how long a failed ping is reused, so an offline
+	// server is not dialled on every refresh of the profile list
+	ServerStatusErrorTTL = 10 * time.Second
+)
+
+// ServerStatus is the result of pinging a server
+type ServerStatus struct {
+	Address  string // as requested
+	Online   bool
+	Status   *slp.Status // nil when offline
+	Error    string      // why the server is offline
+	CachedAt time.Time
+}
+
+type serverStatusEntry struct {
+	status  *ServerStatus
+	expires time.Time
+	done    chan struct{} // closed when the in-flight ping finishes
+}
+
+// ServerStatusService pings Minecraft servers with a short-lived cache.
+// Concurrent requests for the same address share one ping.
+type ServerStatusService struct {
+	profiles *ProfileService
+	pinger   *slp.Pinger
+
+	mu      sync.Mutex
+	entries map[string]*serverStatusEntry
+}
+
+// NewServerStatusService creates a new server status service
+func NewServerStatusService(profiles *ProfileService) *ServerStatusService {
+	return &ServerStatusService{
+		profiles: profiles,
+		pinger:   &slp.Pinger{},
+		entries:  make(map[string]*serverStatusEntry),
+	}
+}
+
+// GetProfileServerStatus pings the server_address of a profile
+func (s *ServerStatusService) GetProfileServerStatus(ctx context.Context, profileID string, refresh bool) (*ServerStatus, error) {
+	p, err := s.profiles.GetProfile(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.ServerAddress) == "" {
+		return nil, fmt.Errorf("profile %s has no server address", profileID)
+	}
+	return s.GetServerStatus(ctx, p.ServerAddress, refresh), nil
+}
+
+// GetServerStatus pings address, reusing a recent result unless refresh is set.
+// An unreachable server is reported as offline rather than as an error.
+func (s *ServerStatusService) GetServerStatus(ctx context.Context, address string, refresh bool) *ServerStatus {
+	key := normalizeServerAddress(address)
+
+	s.mu.Lock()
+	waited := false
+	for {
+		entry := s.entries[key]
+		if entry == nil {
+			break
+		}
+		if entry.done != nil {
+			// Another request is pinging this server; its result is as fresh as ours would be
+			done := entry.done
+			s.mu.Unlock()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				return &ServerStatus{Address: address, Error: ctx.Err().Error(), CachedAt: time.Now()}
+			}
+			waited = true
+			s.mu.Lock()
+			continue
+		}
+		if waited || (!refresh && time.Now().Before(entry.expires)) {
+			s.mu.Unlock()
+			return entry.status
+		}
+		break
+	}
+	entry := &serverStatusEntry{done: make(chan struct{})}
+	s.entries[key] = entry
+	s.pruneLocked()
+	s.mu.Unlock()
+
+	result := &ServerStatus{Address: address, CachedAt: time.Now()}
+	ttl := ServerStatusTTL
+	status, err := s.pinger.Ping(ctx, address)
+	if err != nil {
+		result.Error = err.Error()
+		ttl = ServerStatusErrorTTL
+	} else {
+		result.Online, result.Status = true, status
+	}
+
+	s.mu.Lock()
+	entry.status, entry.expires = result, time.Now().Add(ttl)
+	if ctx.Err() != nil {
+		// Cancelled by the caller, not a verdict on the server: waiters ping themselves
+		delete(s.entries, key)
+	}
+	close(entry.done)
+	entry.done = nil
+	s.mu.Unlock()
+	return result
+}
+
+// pruneLocked drops expired entries. Callers hold s.mu.
+func (s *ServerStatusService) pruneLocked() {
+	now := time.Now()
+	for key, entry := range s.entries {
+		if entry.done == nil && now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
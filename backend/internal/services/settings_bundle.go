@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hyenimc/backend/internal/domain"
+	"hyenimc/backend/internal/profile"
+	"hyenimc/backend/internal/settings"
+)
+
+const (
+	settingsBundleFormat  = "hyenimc-settings"
+	settingsBundleVersion = 1
+)
+
+// machineLocalSettings are never exported or imported: paths that only exist on this
+// machine, and the proxy configuration, whose URL can embed credentials
+var machineLocalSettings = map[string]bool{
+	settings.KeyJavaPath:         true,
+	settings.KeyNetworkCABundle:  true,
+	settings.KeyNetworkProxyMode: true,
+	settings.KeyNetworkProxyURL:  true,
+}
+
+// Profile import actions reported by the import diff
+const (
+	ProfileImportCreate    = "create"
+	ProfileImportUpdate    = "update"
+	ProfileImportSkip      = "skip"      // exists locally and overwrite was not requested
+	ProfileImportUnchanged = "unchanged" // exists locally with identical settings
+)
+
+// settingsBundle is the exported JSON document
+type settingsBundle struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt int64             `json:"exported_at"`
+	Settings   map[string]string `json:"settings"`
+	Profiles   []bundledProfile  `json:"profiles,omitempty"`
+}
+
+// bundledProfile is the portable part of a profile: its definition and overrides,
+// without the game directory, Java path, play time or installation state of this machine
+type bundledProfile struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	Description   string            `json:"description,omitempty"`
+	Icon          string            `json:"icon,omitempty"`
+	GameVersion   string            `json:"game_version"`
+	LoaderType    string            `json:"loader_type"`
+	LoaderVersion string            `json:"loader_version,omitempty"`
+	JvmArgs       []string          `json:"jvm_args"`
+	GameArgs      []string          `json:"game_args"`
+	Memory        domain.Memory     `json:"memory"`
+	Resolution    domain.Resolution `json:"resolution"`
+	Fullscreen    bool              `json:"fullscreen"`
+	ModpackID     string            `json:"modpack_id,omitempty"`
+	ModpackSource string            `json:"modpack_source,omitempty"`
+	AuthRequired  bool              `json:"auth_required,omitempty"`
+	SPAEnabled    bool              `json:"spa_enabled,omitempty"`
+	ServerAddress string            `json:"server_address,omitempty"`
+	Favorite      bool              `json:"favorite,omitempty"`
+}
+
+// SettingDiff is one global setting an import would change
+type SettingDiff struct {
+	Key string
+	Old string
+	New string
+}
+
+// ProfileDiff is what an import does with one bundled profile
+type ProfileDiff struct {
+	ID     string
+	Name   string
+	Action string
+	Fields []string // changed bundle fields for updates
+}
+
+// SettingsImportResult describes an import. With DryRun nothing is written.
+type SettingsImportResult struct {
+	Settings []SettingDiff
+	Profiles []ProfileDiff
+	Errors   []settings.FieldError // settings that were skipped or block the import
+	Applied  bool
+}
+
+// SettingsImportOptions controls ImportSettings
+type SettingsImportOptions struct {
+	DryRun            bool
+	IncludeProfiles   bool
+	OverwriteProfiles bool
+}
+
+// SettingsTransferService exports and imports launcher configuration
+type SettingsTransferService struct {
+	settings *settings.Service
+	profiles *ProfileService
+}
+
+// NewSettingsTransferService creates a new settings transfer service
+func NewSettingsTransferService(settingsSvc *settings.Service, profiles *ProfileService) *SettingsTransferService {
+	return &SettingsTransferService{settings: settingsSvc, profiles: profiles}
+}
+
+// ExportSettings writes the global settings, and the given profiles when includeProfiles,
+// to filePath. An empty profileIDs exports every profile.
+// It returns the number of exported settings and profiles.
+func (s *SettingsTransferService) ExportSettings(ctx context.Context, filePath string, includeProfiles bool, profileIDs []string) (int, int, error) {
+	values, err := s.settings.Values()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read settings: %w", err)
+	}
+	for key := range machineLocalSettings {
+		delete(values, key)
+	}
+	bundle := &settingsBundle{
+		Format:     settingsBundleFormat,
+		Version:    settingsBundleVersion,
+		ExportedAt: time.Now().Unix(),
+		Settings:   values,
+	}
+
+	if includeProfiles {
+		if s.profiles == nil {
+			return 0, 0, fmt.Errorf("profile service not available")
+		}
+		wanted := make(map[string]bool, len(profileIDs))
+		for _, id := range profileIDs {
+			wanted[id] = true
+		}
+		profiles, err := s.profiles.ListProfiles(ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list profiles: %w", err)
+		}
+		for _, p := range profiles {
+			if len(wanted) > 0 && !wanted[p.ID] {
+				continue
+			}
+			bundle.Profiles = append(bundle.Profiles, toBundledProfile(p))
+		}
+		if len(wanted) > len(bundle.Profiles) {
+			return 0, 0, fmt.Errorf("some requested profiles do not exist")
+		}
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return 0, 0, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	log.Printf("[Settings] Exported %d settings and %d profiles", len(bundle.Settings), len(bundle.Profiles))
+	return len(bundle.Settings), len(bundle.Profiles), nil
+}
+
+// ImportSettings reads a bundle written by ExportSettings and reports the difference to
+// the local configuration; unless opts.DryRun the difference is applied. Unknown or
+// invalid settings are skipped and reported; a violated cross-setting rule blocks the import.
+func (s *SettingsTransferService) ImportSettings(ctx context.Context, filePath string, opts SettingsImportOptions) (*SettingsImportResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	var bundle settingsBundle
+	if err := json.Unmarshal(data, &bundle); err != nil || bundle.Format != settingsBundleFormat {
+		return nil, fmt.Errorf("not a HyeniMC settings bundle")
+	}
+	if bundle.Version > settingsBundleVersion {
+		return nil, fmt.Errorf("settings bundle version %d is newer than supported (%d)", bundle.Version, settingsBundleVersion)
+	}
+
+	result := &SettingsImportResult{}
+	changes, err := s.diffSettings(bundle.Settings, result)
+	if err != nil {
+		return nil, err
+	}
+	blocked := false
+	if err := s.settings.ValidateValues(changes); err != nil {
+		var verr *settings.ValidationError
+		if !errors.As(err, &verr) {
+			return nil, err
+		}
+		result.Errors = append(result.Errors, verr.Fields...)
+		blocked = true
+	}
+
+	var imports []*domain.Profile
+	if opts.IncludeProfiles && len(bundle.Profiles) > 0 {
+		if s.profiles == nil {
+			return nil, fmt.Errorf("profile service not available")
+		}
+		imports, err = s.diffProfiles(ctx, bundle.Profiles, opts.OverwriteProfiles, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DryRun || blocked {
+		return result, nil
+	}
+
+	if err := s.settings.SetValues(changes, nil); err != nil {
+		return nil, fmt.Errorf("failed to apply settings: %w", err)
+	}
+	for _, p := range imports {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := s.profiles.ImportProfile(ctx, p); err != nil {
+			return nil, fmt.Errorf("failed to import profile %s: %w", p.Name, err)
+		}
+	}
+	result.Applied = true
+	log.Printf("[Settings] Imported %d settings and %d profiles", len(changes), len(imports))
+	return result, nil
+}
+
+// diffSettings returns the normalized bundle values that differ from the local ones
+func (s *SettingsTransferService) diffSettings(values map[string]string, result *SettingsImportResult) (map[string]string, error) {
+	current, err := s.settings.Values()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := make(map[string]string)
+	for _, key := range keys {
+		def, ok := settings.Lookup(key)
+		if !ok {
+			result.Errors = append(result.Errors, settings.FieldError{Key: key, Message: "unknown setting, skipped"})
+			continue
+		}
+		if machineLocalSettings[key] {
+			result.Errors = append(result.Errors, settings.FieldError{Key: key, Message: "machine-local setting, skipped"})
+			continue
+		}
+		value, err := def.Normalize(values[key])
+		if err != nil {
+			result.Errors = append(result.Errors, settings.FieldError{Key: key, Message: err.Error() + ", skipped"})
+			continue
+		}
+		if value == current[key] {
+			continue
+		}
+		changes[key] = value
+		result.Settings = append(result.Settings, SettingDiff{Key: key, Old: current[key], New: value})
+	}
+	return changes, nil
+}
+
+// diffProfiles fills result.Profiles and returns the profiles to store
+func (s *SettingsTransferService) diffProfiles(ctx context.Context, bundled []bundledProfile, overwrite bool, result *SettingsImportResult) ([]*domain.Profile, error) {
+	var imports []*domain.Profile
+	for _, b := range bundled {
+		if b.ID == "" || b.Name == "" || b.GameVersion == "" || b.LoaderType == "" {
+			return nil, fmt.Errorf("bundle contains an incomplete profile %q", b.Name)
+		}
+		// Profile ids become instance directory names; the bundle is untrusted input
+		if _, err := uuid.Parse(b.ID); err != nil {
+			return nil, fmt.Errorf("bundle contains a profile with an invalid id %q", b.ID)
+		}
+		diff := ProfileDiff{ID: b.ID, Name: b.Name}
+
+		existing, err := s.profiles.GetProfile(ctx, b.ID)
+		if errors.Is(err, profile.ErrNotFound) {
+			diff.Action = ProfileImportCreate
+			p := &domain.Profile{}
+			b.applyTo(p)
+			imports = append(imports, p)
+			result.Profiles = append(result.Profiles, diff)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read profile %s: %w", b.ID, err)
+		}
+
+		diff.Fields = profileFieldDiff(toBundledProfile(existing), b)
+		switch {
+		case len(diff.Fields) == 0:
+			diff.Action = ProfileImportUnchanged
+		case !overwrite:
+			diff.Action = ProfileImportSkip
+		default:
+			diff.Action = ProfileImportUpdate
+			b.applyTo(existing)
+			imports = append(imports, existing)
+		}
+		result.Profiles = append(result.Profiles, diff)
+	}
+	return imports, nil
+}
+
+func toBundledProfile(p *domain.Profile) bundledProfile {
+	return bundledProfile{
+		ID:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Icon:          p.Icon,
+		GameVersion:   p.GameVersion,
+		LoaderType:    p.LoaderType,
+		LoaderVersion: p.LoaderVersion,
+		JvmArgs:       nonNil(p.JvmArgs),
+		GameArgs:      nonNil(p.GameArgs),
+		Memory:        p.Memory,
+		Resolution:    p.Resolution,
+		Fullscreen:    p.Fullscreen,
+		ModpackID:     p.ModpackID,
+		ModpackSource: p.ModpackSource,
+		AuthRequired:  p.AuthRequired,
+		SPAEnabled:    p.SPAEnabled,
+		ServerAddress: p.ServerAddress,
+		Favorite:      p.Favorite,
+	}
+}
+
+// applyTo copies the bundled fields onto p, leaving machine-local state untouched
+func (b bundledProfile) applyTo(p *domain.Profile) {
+	p.ID = b.ID
+	p.Name = b.Name
+	p.Description = b.Description
+	p.Icon = b.Icon
+	p.GameVersion = b.GameVersion
+	p.LoaderType = b.LoaderType
+	p.LoaderVersion = b.LoaderVersion
+	p.JvmArgs = nonNil(b.JvmArgs)
+	p.GameArgs = nonNil(b.GameArgs)
+	p.Memory = b.Memory
+	p.Resolution = b.Resolution
+	p.Fullscreen = b.Fullscreen
+	p.ModpackID = b.ModpackID
+	p.ModpackSource = b.ModpackSource
+	p.AuthRequired = b.AuthRequired
+	p.SPAEnabled = b.SPAEnabled
+	p.ServerAddress = b.ServerAddress
+	p.Favorite = b.Favorite
+}
+
+// profileFieldDiff returns the JSON names of the fields that differ between a and b
+func profileFieldDiff(a, b bundledProfile) []string {
+	var am, bm map[string]json.RawMessage
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	_ = json.Unmarshal(aj, &am)
+	_ = json.Unmarshal(bj, &bm)
+
+	var fields []string
+	for key, av := range am {
+		if bv, ok := bm[key]; !ok || string(av) != string(bv) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range bm {
+		if _, ok := am[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
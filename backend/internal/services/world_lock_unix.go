@@ -0,0 +1,24 @@
+//go:build !windows
+
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// sessionLocked reports whether a running game holds the world's session.lock.
+// Java locks it with fcntl, so the lock is visible through F_GETLK without taking it.
+func sessionLocked(dir string) bool {
+	f, err := os.Open(filepath.Join(dir, "session.lock"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	lk := syscall.Flock_t{Type: syscall.F_WRLCK}
+	if err := syscall.FcntlFlock(f.Fd(), syscall.F_GETLK, &lk); err != nil {
+		return false
+	}
+	return lk.Type != syscall.F_UNLCK
+}
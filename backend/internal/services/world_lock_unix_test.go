@@ -0,0 +1,83 @@
+//go:build !windows
+
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestHoldSessionLock is not a test: it is the stand-in game process for
+// TestWorldInUse, locking session.lock the way Java does until stdin closes
+func TestHoldSessionLock(t *testing.T) {
+	path := os.Getenv("HYENIMC_TEST_SESSION_LOCK")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lk := syscall.Flock_t{Type: syscall.F_WRLCK}
+	if err := syscall.FcntlFlock(f.Fd(), syscall.F_SETLKW, &lk); err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout.WriteString("locked\n")
+	io.Copy(io.Discard, os.Stdin)
+}
+
+func TestWorldInUse(t *testing.T) {
+	s, profileID, saves := newTestWorldService(t)
+	ctx := context.Background()
+	dir := newTestWorld(t, saves, "World", "v1")
+	archive, _, err := s.BackupWorld(ctx, profileID, "World", filepath.Join(t.TempDir(), "world.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An unlocked session.lock left by a closed game does not count
+	os.WriteFile(filepath.Join(dir, "session.lock"), nil, 0644)
+	if inUse, err := s.WorldInUse(ctx, profileID, "World"); err != nil || inUse {
+		t.Fatalf("idle world reported in use: %v", err)
+	}
+
+	game := exec.Command(os.Args[0], "-test.run=^TestHoldSessionLock$")
+	game.Env = append(os.Environ(), "HYENIMC_TEST_SESSION_LOCK="+filepath.Join(dir, "session.lock"))
+	stdin, _ := game.StdinPipe()
+	stdout, _ := game.StdoutPipe()
+	if err := game.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "locked\n" {
+		t.Fatalf("helper: %q %v", line, err)
+	}
+
+	if inUse, _ := s.WorldInUse(ctx, profileID, "World"); !inUse {
+		t.Error("locked world not reported in use")
+	}
+	if err := s.DeleteWorld(ctx, profileID, "World"); !errors.Is(err, ErrWorldInUse) {
+		t.Errorf("delete: got %v", err)
+	}
+	if _, err := s.RestoreWorld(ctx, profileID, archive, "World", true); !errors.Is(err, ErrWorldInUse) {
+		t.Errorf("overwrite: got %v", err)
+	}
+	// Restoring next to it is fine
+	if w, err := s.RestoreWorld(ctx, profileID, archive, "World", false); err != nil || w.FolderName != "World (2)" {
+		t.Errorf("restore copy: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "level.dat")); err != nil {
+		t.Errorf("world damaged: %v", err)
+	}
+
+	stdin.Close()
+	game.Wait()
+	if err := s.DeleteWorld(ctx, profileID, "World"); err != nil {
+		t.Errorf("delete after the game exited: %v", err)
+	}
+}
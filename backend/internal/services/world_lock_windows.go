@@ -0,0 +1,27 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// sessionLocked reports whether a running game holds the world's session.lock.
+// Java locks it with LockFileEx, so a non-blocking lock attempt fails while the game runs.
+func sessionLocked(dir string) bool {
+	f, err := os.OpenFile(filepath.Join(dir, "session.lock"), os.O_RDWR, 0)
+	if err != nil {
+		// A sharing violation means the game has the file open
+		return errors.Is(err, windows.ERROR_SHARING_VIOLATION)
+	}
+	defer f.Close()
+	h := windows.Handle(f.Fd())
+	var ol windows.Overlapped
+	if err := windows.LockFileEx(h, windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol); err != nil {
+		return true
+	}
+	windows.UnlockFileEx(h, 0, 1, 0, &ol)
+	return false
+}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hyenimc/backend/internal/nbt"
+)
+
+// restoreStagingPrefix names the directories RestoreWorld stages archives in. They sit
+// in the saves directory so the final rename stays on one volume.
+const restoreStagingPrefix = ".restore-"
+
+// ErrWorldInUse is returned when a running game holds the world's session.lock
+var ErrWorldInUse = errors.New("world is open in a running game")
+
+// Game modes as stored in level.dat
+var gameModes = map[int64]string{0: "survival", 1: "creative", 2: "adventure", 3: "spectator"}
+
+// World describes a world in an instance's saves directory
+type World struct {
+	FolderName  string
+	Name        string // LevelName, falls back to the folder name
+	GameMode    string // survival|creative|adventure|spectator
+	Hardcore    bool
+	LastPlayed  time.Time
+	Version     string // game version that last saved the world, empty before 1.9
+	DataVersion int32
+	SizeBytes   int64
+	HasIcon     bool
+	Error       string // set when level.dat could not be read
+}
+
+// WorldService manages the worlds of profile instances
+type WorldService struct {
+	profiles *ProfileService
+
+	restoreMu sync.Mutex // held while a restore has staging directories in flight
+}
+
+// NewWorldService creates a new world service
+func NewWorldService(profiles *ProfileService) *WorldService {
+	return &WorldService{profiles: profiles}
+}
+
+// savesDir returns the saves directory of a profile
+func (s *WorldService) savesDir(ctx context.Context, profileID string) (string, error) {
+	p, err := s.profiles.GetProfile(ctx, profileID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.GameDirectory, "saves"), nil
+}
+
+// worldDir resolves an existing world folder of a profile
+func (s *WorldService) worldDir(ctx context.Context, profileID, folder string) (string, error) {
+	if err := validateWorldFolder(folder); err != nil {
+		return "", err
+	}
+	saves, err := s.savesDir(ctx, profileID)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(saves, folder)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("world not found: %s", folder)
+	}
+	return dir, nil
+}
+
+// ListWorlds returns the worlds of a profile, most recently played first
+func (s *WorldService) ListWorlds(ctx context.Context, profileID string) ([]*World, error) {
+	saves, err := s.savesDir(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	// Staging directories are only stale when no restore is running
+	if s.restoreMu.TryLock() {
+		cleanStaleRestores(saves)
+		s.restoreMu.Unlock()
+	}
+	entries, err := os.ReadDir(saves)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*World{}, nil
+		}
+		return nil, fmt.Errorf("failed to read saves directory: %w", err)
+	}
+
+	worlds := []*World{}
+	for _, entry := range entries {
+		// Hidden directories are restore staging areas or not worlds at all
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(saves, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, "level.dat")); err != nil {
+			continue // not a world
+		}
+		worlds = append(worlds, readWorld(dir))
+	}
+
+	sort.Slice(worlds, func(i, j int) bool { return worlds[i].LastPlayed.After(worlds[j].LastPlayed) })
+	return worlds, nil
+}
+
+// GetWorld returns a single world of a profile
+func (s *WorldService) GetWorld(ctx context.Context, profileID, folder string) (*World, error) {
+	dir, err := s.worldDir(ctx, profileID, folder)
+	if err != nil {
+		return nil, err
+	}
+	return readWorld(dir), nil
+}
+
+// readWorld describes the world in dir from its level.dat
+func readWorld(dir string) *World {
+	w := &World{FolderName: filepath.Base(dir), Name: filepath.Base(dir)}
+	w.SizeBytes = dirSize(dir)
+	if _, err := os.Stat(filepath.Join(dir, "icon.png")); err == nil {
+		w.HasIcon = true
+	}
+
+	_, root, err := nbt.ReadFile(filepath.Join(dir, "level.dat"))
+	if err != nil {
+		w.Error = err.Error()
+		if info, statErr := os.Stat(filepath.Join(dir, "level.dat")); statErr == nil {
+			w.LastPlayed = info.ModTime()
+		}
+		return w
+	}
+	data := root.Compound("Data")
+	if name := data.String("LevelName"); name != "" {
+		w.Name = name
+	}
+	w.GameMode = gameModes[data.Int("GameType")]
+	w.Hardcore = data.Bool("hardcore")
+	if ms := data.Int("LastPlayed"); ms > 0 {
+		w.LastPlayed = time.UnixMilli(ms)
+	}
+	w.Version = data.Compound("Version").String("Name")
+	w.DataVersion = int32(data.Int("DataVersion"))
+	return w
+}
+
+// BackupWorld zips a world to dest, or to {userData}/backups/worlds/{profile-id}
+// when dest is empty, and returns the archive path and size
+func (s *WorldService) BackupWorld(ctx context.Context, profileID, folder, dest string) (string, int64, error) {
+	dir, err := s.worldDir(ctx, profileID, folder)
+	if err != nil {
+		return "", 0, err
+	}
+	if dest == "" {
+		name := fmt.Sprintf("%s_%s.zip", folder, time.Now().Format("20060102-150405"))
+		dest = filepath.Join(filepath.Dir(s.profiles.dataDir), "backups", "worlds", profileID, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".world-backup-*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create backup: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := zipWorld(ctx, tmp, dir, folder); err != nil {
+		tmp.Close()
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", 0, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	log.Printf("[World] Backed up %s/%s to %s (%d bytes)", profileID, folder, dest, info.Size())
+	return dest, info.Size(), nil
+}
+
+// zipWorld writes the world in dir to w with every entry under folder/
+func zipWorld(ctx context.Context, w io.Writer, dir, folder string) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// session.lock is held by a running game and meaningless in a backup
+		if !d.Type().IsRegular() || d.Name() == "session.lock" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(folder, filepath.ToSlash(rel))
+		header.Method = zip.Deflate
+
+		out, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(out, in)
+		return err
+	})
+	if err != nil {
+		zw.Close()
+		return fmt.Errorf("failed to archive world: %w", err)
+	}
+	return zw.Close()
+}
+
+// RestoreWorld extracts a world archive into a profile's saves directory and returns
+// the restored world. The archive may hold level.dat at its root or inside one folder.
+// name defaults to that folder (or the archive name); an existing world with the same
+// name is replaced when overwrite is set, otherwise a free name is chosen.
+func (s *WorldService) RestoreWorld(ctx context.Context, profileID, archive, name string, overwrite bool) (*World, error) {
+	saves, err := s.savesDir(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zr.Close()
+
+	prefix, ok := worldArchiveRoot(zr.File)
+	if !ok {
+		return nil, fmt.Errorf("archive does not contain a world (no level.dat)")
+	}
+	if name == "" {
+		name = strings.TrimSuffix(prefix, "/")
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(archive), filepath.Ext(archive))
+		}
+	}
+	if err := validateWorldFolder(name); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(saves, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create saves directory: %w", err)
+	}
+	s.restoreMu.Lock()
+	defer s.restoreMu.Unlock()
+	cleanStaleRestores(saves)
+
+	target := filepath.Join(saves, name)
+	if _, err := os.Stat(target); err == nil {
+		if !overwrite {
+			target = uniqueWorldDir(saves, name)
+		} else if sessionLocked(target) {
+			return nil, fmt.Errorf("%w: %s", ErrWorldInUse, name)
+		}
+	}
+
+	staging, err := os.MkdirTemp(saves, restoreStagingPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(f.Name, prefix) || f.FileInfo().IsDir() {
+			continue
+		}
+		rel := strings.TrimPrefix(f.Name, prefix)
+		dst, err := safeJoin(staging, rel)
+		if err != nil {
+			return nil, err
+		}
+		if err := extractZipFile(f, dst); err != nil {
+			return nil, err
+		}
+	}
+
+	// Swap the staged world in; an overwritten world is only removed once that worked.
+	// Its temporary name records the world, so a crash in between can be recovered.
+	var old string
+	if _, err := os.Stat(target); err == nil {
+		old = staging + ".old-" + filepath.Base(target)
+		if err := moveDir(target, old); err != nil {
+			return nil, fmt.Errorf("failed to replace existing world: %w", err)
+		}
+	}
+	if err := moveDir(staging, target); err != nil {
+		if old != "" {
+			moveDir(old, target)
+		}
+		return nil, fmt.Errorf("failed to restore world: %w", err)
+	}
+	if old != "" {
+		os.RemoveAll(old)
+	}
+
+	log.Printf("[World] Restored %s into %s", archive, target)
+	return readWorld(target), nil
+}
+
+// cleanStaleRestores removes staging directories left in saves by an interrupted
+// restore. A world moved aside for an overwrite is put back if its folder is empty.
+// The caller must hold restoreMu.
+func cleanStaleRestores(saves string) {
+	entries, err := os.ReadDir(saves)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, restoreStagingPrefix) {
+			continue
+		}
+		dir := filepath.Join(saves, name)
+		if i := strings.Index(name, ".old-"); i >= 0 {
+			world := name[i+len(".old-"):]
+			target := filepath.Join(saves, world)
+			if _, err := os.Stat(target); os.IsNotExist(err) && validateWorldFolder(world) == nil {
+				if err := moveDir(dir, target); err != nil {
+					log.Printf("[World] Failed to recover %s: %v", world, err)
+				} else {
+					log.Printf("[World] Recovered %s from an interrupted restore", world)
+				}
+				continue
+			}
+		}
+		if err := removeAllWithRetry(dir); err != nil {
+			log.Printf("[World] Failed to remove stale restore directory %s: %v", dir, err)
+		}
+	}
+}
+
+// worldArchiveRoot returns the directory prefix of the shallowest level.dat in an archive
+func worldArchiveRoot(files []*zip.File) (string, bool) {
+	best, found := "", false
+	for _, f := range files {
+		if path.Base(f.Name) != "level.dat" {
+			continue
+		}
+		prefix := strings.TrimSuffix(f.Name, "level.dat")
+		if !found || strings.Count(prefix, "/") < strings.Count(best, "/") {
+			best, found = prefix, true
+		}
+	}
+	return best, found
+}
+
+// safeJoin joins an archive entry name to dir, rejecting entries that escape it
+func safeJoin(dir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) || filepath.VolumeName(clean) != "" {
+		return "", fmt.Errorf("archive entry escapes the world directory: %s", name)
+	}
+	return filepath.Join(dir, clean), nil
+}
+
+func extractZipFile(f *zip.File, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, f.Modified, f.Modified)
+}
+
+// DeleteWorld removes a world from a profile
+func (s *WorldService) DeleteWorld(ctx context.Context, profileID, folder string) error {
+	dir, err := s.worldDir(ctx, profileID, folder)
+	if err != nil {
+		return err
+	}
+	if sessionLocked(dir) {
+		return fmt.Errorf("%w: %s", ErrWorldInUse, folder)
+	}
+	if err := removeAllWithRetry(dir); err != nil {
+		return fmt.Errorf("failed to delete world: %w", err)
+	}
+	log.Printf("[World] Deleted %s/%s", profileID, folder)
+	return nil
+}
+
+// WorldInUse reports whether a running game has the world open
+func (s *WorldService) WorldInUse(ctx context.Context, profileID, folder string) (bool, error) {
+	dir, err := s.worldDir(ctx, profileID, folder)
+	if err != nil {
+		return false, err
+	}
+	return sessionLocked(dir), nil
+}
+
+// CopyWorld copies a world into another (or the same) profile. name defaults to the
+// source folder; a free name is chosen when it is taken.
+func (s *WorldService) CopyWorld(ctx context.Context, srcProfileID, folder, dstProfileID, name string) (*World, error) {
+	src, err := s.worldDir(ctx, srcProfileID, folder)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = folder
+	}
+	if err := validateWorldFolder(name); err != nil {
+		return nil, err
+	}
+	saves, err := s.savesDir(ctx, dstProfileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(saves, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create saves directory: %w", err)
+	}
+
+	dst := uniqueWorldDir(saves, name)
+	if err := copyTree(ctx, src, dst, false, &CloneResult{}); err != nil {
+		os.RemoveAll(dst)
+		return nil, fmt.Errorf("failed to copy world: %w", err)
+	}
+	os.Remove(filepath.Join(dst, "session.lock"))
+
+	log.Printf("[World] Copied %s/%s to %s", srcProfileID, folder, dst)
+	return readWorld(dst), nil
+}
+
+// uniqueWorldDir returns saves/name, or saves/name (2), (3), ... when taken
+func uniqueWorldDir(saves, name string) string {
+	dir := filepath.Join(saves, name)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return dir
+		}
+		dir = filepath.Join(saves, fmt.Sprintf("%s (%d)", name, i))
+	}
+}
+
+// validateWorldFolder rejects names that are not a single directory name
+func validateWorldFolder(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\:`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid world folder name: %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestWorld creates saves/<folder> with a placeholder level.dat and returns its path
+func newTestWorld(t *testing.T, saves, folder, content string) string {
+	t.Helper()
+	dir := filepath.Join(saves, folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "level.dat"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func newTestWorldService(t *testing.T) (*WorldService, string, string) {
+	t.Helper()
+	profiles, _ := newTestProfileService(t)
+	p := createTestProfile(t, profiles)
+	return NewWorldService(profiles), p.ID, filepath.Join(p.GameDirectory, "saves")
+}
+
+func worldFolders(t *testing.T, s *WorldService, profileID string) map[string]bool {
+	t.Helper()
+	worlds, err := s.ListWorlds(context.Background(), profileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]bool)
+	for _, w := range worlds {
+		out[w.FolderName] = true
+	}
+	return out
+}
+
+func TestListWorldsCleansStaleRestores(t *testing.T) {
+	s, profileID, saves := newTestWorldService(t)
+	newTestWorld(t, saves, "Kept", "kept")
+	newTestWorld(t, saves, ".restore-1", "half extracted")
+	// Interrupted overwrites: one world was never swapped back in, one was
+	newTestWorld(t, saves, ".restore-2.old-Lost", "lost")
+	newTestWorld(t, saves, ".restore-3.old-Kept", "replaced")
+
+	folders := worldFolders(t, s, profileID)
+	if len(folders) != 2 || !folders["Kept"] || !folders["Lost"] {
+		t.Errorf("worlds %v", folders)
+	}
+	entries, _ := os.ReadDir(saves)
+	if len(entries) != 2 {
+		t.Errorf("%d entries left in saves", len(entries))
+	}
+	if data, _ := os.ReadFile(filepath.Join(saves, "Lost", "level.dat")); string(data) != "lost" {
+		t.Errorf("recovered world %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(saves, "Kept", "level.dat")); string(data) != "kept" {
+		t.Errorf("existing world replaced by %q", data)
+	}
+}
+
+func TestRestoreWorldOverwrite(t *testing.T) {
+	s, profileID, saves := newTestWorldService(t)
+	ctx := context.Background()
+	dir := newTestWorld(t, saves, "World", "v1")
+	archive, _, err := s.BackupWorld(ctx, profileID, "World", filepath.Join(t.TempDir(), "world.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "level.dat"), []byte("v2"), 0644)
+
+	if _, err := s.RestoreWorld(ctx, profileID, archive, "", true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "level.dat")); string(data) != "v1" {
+		t.Errorf("level.dat %q after restore", data)
+	}
+	if folders := worldFolders(t, s, profileID); len(folders) != 1 {
+		t.Errorf("worlds %v", folders)
+	}
+	if entries, _ := os.ReadDir(saves); len(entries) != 1 {
+		t.Errorf("%d entries left in saves", len(entries))
+	}
+}
@@ -23,6 +23,17 @@ const (
 	DefaultCacheEnabled   = true
 	DefaultCacheMaxSizeGB = 10
 	DefaultCacheTTLDays   = 30
+
+	// Update settings
+	DefaultUpdateCheckIntervalHours = 2
+	DefaultUpdateAutoDownload       = false
+
+	// Trash settings
+	DefaultTrashRetentionDays = 7
+
+	// Snapshot settings
+	DefaultSnapshotMaxPerProfile = 10
+	DefaultSnapshotMaxAgeDays    = 30
 )
 
 // Setting keys
@@ -42,27 +53,75 @@ const (
 	KeyCacheEnabled   = "cache.enabled"
 	KeyCacheMaxSizeGB = "cache.max_size_gb"
 	KeyCacheTTLDays   = "cache.ttl_days"
+
+	KeyUpdateCheckIntervalHours = "update.check_interval_hours"
+	KeyUpdateAutoDownload       = "update.auto_download"
+
+	KeyTrashRetentionDays = "trash.retention_days"
+
+	KeySnapshotMaxPerProfile = "snapshot.max_per_profile"
+	KeySnapshotMaxAgeDays    = "snapshot.max_age_days"
 )
 
+func init() {
+	MustRegister(
+		Definition{Key: KeyJavaPath, Type: TypeString, Default: DefaultJavaPath,
+			Description: "Java executable used when a profile does not set one (empty = auto-detect)"},
+		Definition{Key: KeyMemoryMin, Type: TypeInt, Default: itoa(DefaultMemoryMin), Min: 256, Max: 1 << 20,
+			Description: "Initial Java heap size in MB (-Xms)"},
+		Definition{Key: KeyMemoryMax, Type: TypeInt, Default: itoa(DefaultMemoryMax), Min: 256, Max: 1 << 20,
+			Description: "Maximum Java heap size in MB (-Xmx)"},
+
+		Definition{Key: KeyResolutionWidth, Type: TypeInt, Default: itoa(DefaultResolutionWidth), Min: 1, Max: 16384,
+			Description: "Game window width"},
+		Definition{Key: KeyResolutionHeight, Type: TypeInt, Default: itoa(DefaultResolutionHeight), Min: 1, Max: 16384,
+			Description: "Game window height"},
+		Definition{Key: KeyFullscreen, Type: TypeBool, Default: btoa(DefaultFullscreen),
+			Description: "Start the game in fullscreen"},
+
+		Definition{Key: KeyDownloadTimeoutMs, Type: TypeInt, Default: itoa(DefaultDownloadTimeoutMs), Min: 100, Max: 600000,
+			Description: "Per-request download timeout in milliseconds"},
+		Definition{Key: KeyDownloadMaxRetries, Type: TypeInt, Default: itoa(DefaultDownloadMaxRetries), Min: 0, Max: 20,
+			Description: "Retries per file before a download fails"},
+		Definition{Key: KeyDownloadMaxParallel, Type: TypeInt, Default: itoa(DefaultDownloadMaxParallel), Min: 1, Max: 64,
+			Description: "Maximum concurrent downloads"},
+
+		Definition{Key: KeyCacheEnabled, Type: TypeBool, Default: btoa(DefaultCacheEnabled),
+			Description: "Cache API responses and downloaded files"},
+		Definition{Key: KeyCacheMaxSizeGB, Type: TypeInt, Default: itoa(DefaultCacheMaxSizeGB), Min: 1, Max: 1024,
+			Description: "Maximum cache size in GB"},
+		Definition{Key: KeyCacheTTLDays, Type: TypeInt, Default: itoa(DefaultCacheTTLDays), Min: 1, Max: 3650,
+			Description: "Days before cached entries expire"},
+
+		Definition{Key: KeyUpdateCheckIntervalHours, Type: TypeInt, Default: itoa(DefaultUpdateCheckIntervalHours), Min: 1, Max: 168,
+			Description: "Hours between launcher update checks"},
+		Definition{Key: KeyUpdateAutoDownload, Type: TypeBool, Default: btoa(DefaultUpdateAutoDownload),
+			Description: "Download launcher updates automatically"},
+
+		Definition{Key: KeyTrashRetentionDays, Type: TypeInt, Default: itoa(DefaultTrashRetentionDays), Min: 1, Max: 365,
+			Description: "Days a deleted profile stays in the trash before it is purged"},
+
+		Definition{Key: KeySnapshotMaxPerProfile, Type: TypeInt, Default: itoa(DefaultSnapshotMaxPerProfile), Min: 1, Max: 100,
+			Description: "Snapshots kept per profile; the oldest are removed first"},
+		Definition{Key: KeySnapshotMaxAgeDays, Type: TypeInt, Default: itoa(DefaultSnapshotMaxAgeDays), Min: 1, Max: 3650,
+			Description: "Days before a snapshot is removed (the newest snapshot of a profile is always kept)"},
+	)
+
+	RegisterRule(func(values map[string]string) *FieldError {
+		if parseInt32(values[KeyMemoryMin], DefaultMemoryMin) > parseInt32(values[KeyMemoryMax], DefaultMemoryMax) {
+			return &FieldError{Key: KeyMemoryMin, Message: "must not exceed " + KeyMemoryMax}
+		}
+		return nil
+	})
+}
+
 // GetDefaultSettings returns a map of default settings
 func GetDefaultSettings() map[string]string {
-	return map[string]string{
-		KeyJavaPath:    DefaultJavaPath,
-		KeyMemoryMin:   itoa(DefaultMemoryMin),
-		KeyMemoryMax:   itoa(DefaultMemoryMax),
-		
-		KeyResolutionWidth:  itoa(DefaultResolutionWidth),
-		KeyResolutionHeight: itoa(DefaultResolutionHeight),
-		KeyFullscreen:       btoa(DefaultFullscreen),
-		
-		KeyDownloadTimeoutMs:  itoa(DefaultDownloadTimeoutMs),
-		KeyDownloadMaxRetries: itoa(DefaultDownloadMaxRetries),
-		KeyDownloadMaxParallel: itoa(DefaultDownloadMaxParallel),
-		
-		KeyCacheEnabled:   btoa(DefaultCacheEnabled),
-		KeyCacheMaxSizeGB: itoa(DefaultCacheMaxSizeGB),
-		KeyCacheTTLDays:   itoa(DefaultCacheTTLDays),
+	defaults := make(map[string]string)
+	for _, def := range Definitions() {
+		defaults[def.Key] = def.Default
 	}
+	return defaults
 }
 
 func itoa(i int) string {
@@ -0,0 +1,126 @@
+package settings
+
+import "sort"
+
+// Setting origins, from lowest to highest precedence
+const (
+	OriginDefault   = "default"   // settings/defaults.go
+	OriginGlobal    = "global"    // global_settings table
+	OriginHyeniPack = "hyenipack" // recommendations of the installed HyeniPack
+	OriginProfile   = "profile"   // per-profile override
+)
+
+// Effective-only keys carrying HyeniPack Java recommendations (no global equivalent)
+const (
+	KeyJavaMinVersion         = "java.min_version"
+	KeyJavaRecommendedVersion = "java.recommended_version"
+)
+
+// EffectiveSetting is the resolved value of one setting and where it came from
+type EffectiveSetting struct {
+	Key    string
+	Value  string
+	Origin string
+}
+
+// ProfileOverrides holds the per-profile settings. Empty/zero values inherit.
+type ProfileOverrides struct {
+	JavaPath         string
+	MemoryMin        int32
+	MemoryMax        int32
+	ResolutionWidth  int32
+	ResolutionHeight int32
+	Fullscreen       bool
+}
+
+// PackRecommendations holds the HyeniPack settings block (memory/java/resolution)
+type PackRecommendations struct {
+	MemoryRecommended      int32
+	MemoryMinimum          int32
+	JavaMinimumVersion     int32
+	JavaRecommendedVersion int32
+	ResolutionWidth        int32
+	ResolutionHeight       int32
+}
+
+// Effective resolves every setting for a profile: defaults, then stored globals,
+// then HyeniPack recommendations, then profile overrides. profile and pack may be nil.
+func (s *Service) Effective(profile *ProfileOverrides, pack *PackRecommendations) ([]EffectiveSetting, error) {
+	stored, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return Resolve(stored, profile, pack), nil
+}
+
+// Resolve merges the settings layers. A stored global equal to its default is
+// reported as a default, since defaults are written to the table on first run.
+func Resolve(stored map[string]string, profile *ProfileOverrides, pack *PackRecommendations) []EffectiveSetting {
+	values := make(map[string]*EffectiveSetting)
+	for key, value := range GetDefaultSettings() {
+		values[key] = &EffectiveSetting{Key: key, Value: value, Origin: OriginDefault}
+	}
+	values[KeyJavaMinVersion] = &EffectiveSetting{Key: KeyJavaMinVersion, Value: "0", Origin: OriginDefault}
+	values[KeyJavaRecommendedVersion] = &EffectiveSetting{Key: KeyJavaRecommendedVersion, Value: "0", Origin: OriginDefault}
+
+	for key, value := range stored {
+		if v, ok := values[key]; ok && v.Value == value {
+			continue
+		}
+		values[key] = &EffectiveSetting{Key: key, Value: value, Origin: OriginGlobal}
+	}
+
+	set := func(key, value, origin string) {
+		values[key] = &EffectiveSetting{Key: key, Value: value, Origin: origin}
+	}
+
+	if pack != nil {
+		if pack.MemoryRecommended > 0 {
+			set(KeyMemoryMax, itoa(int(pack.MemoryRecommended)), OriginHyeniPack)
+		}
+		if pack.MemoryMinimum > 0 && parseInt32(values[KeyMemoryMax].Value, DefaultMemoryMax) < pack.MemoryMinimum {
+			set(KeyMemoryMax, itoa(int(pack.MemoryMinimum)), OriginHyeniPack)
+		}
+		if pack.JavaMinimumVersion > 0 {
+			set(KeyJavaMinVersion, itoa(int(pack.JavaMinimumVersion)), OriginHyeniPack)
+		}
+		if pack.JavaRecommendedVersion > 0 {
+			set(KeyJavaRecommendedVersion, itoa(int(pack.JavaRecommendedVersion)), OriginHyeniPack)
+		}
+		if pack.ResolutionWidth > 0 && pack.ResolutionHeight > 0 {
+			set(KeyResolutionWidth, itoa(int(pack.ResolutionWidth)), OriginHyeniPack)
+			set(KeyResolutionHeight, itoa(int(pack.ResolutionHeight)), OriginHyeniPack)
+		}
+	}
+
+	// Empty/0 inherits. The profile sets its own resolution when width or height is
+	// non-zero; a 0 dimension still inherits, and fullscreen follows the profile only
+	// in that case. The launcher resolves launches the same way (src/main/ipc/profile.ts).
+	if profile != nil {
+		if profile.JavaPath != "" {
+			set(KeyJavaPath, profile.JavaPath, OriginProfile)
+		}
+		if profile.MemoryMin > 0 {
+			set(KeyMemoryMin, itoa(int(profile.MemoryMin)), OriginProfile)
+		}
+		if profile.MemoryMax > 0 {
+			set(KeyMemoryMax, itoa(int(profile.MemoryMax)), OriginProfile)
+		}
+		if profile.ResolutionWidth > 0 || profile.ResolutionHeight > 0 {
+			if profile.ResolutionWidth > 0 {
+				set(KeyResolutionWidth, itoa(int(profile.ResolutionWidth)), OriginProfile)
+			}
+			if profile.ResolutionHeight > 0 {
+				set(KeyResolutionHeight, itoa(int(profile.ResolutionHeight)), OriginProfile)
+			}
+			set(KeyFullscreen, btoa(profile.Fullscreen), OriginProfile)
+		}
+	}
+
+	result := make([]EffectiveSetting, 0, len(values))
+	for _, v := range values {
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
@@ -0,0 +1,60 @@
+package settings
+
+import "testing"
+
+func effectiveValues(stored map[string]string, profile *ProfileOverrides, pack *PackRecommendations) map[string]EffectiveSetting {
+	out := make(map[string]EffectiveSetting)
+	for _, s := range Resolve(stored, profile, pack) {
+		out[s.Key] = s
+	}
+	return out
+}
+
+func TestResolveResolution(t *testing.T) {
+	stored := map[string]string{KeyResolutionWidth: "1920", KeyResolutionHeight: "1080", KeyFullscreen: "true"}
+	for _, tc := range []struct {
+		name                      string
+		profile                   *ProfileOverrides
+		width, height, fullscreen string
+		widthOrigin, fsOrigin     string
+	}{
+		{"no profile", nil, "1920", "1080", "true", OriginGlobal, OriginGlobal},
+		{"inherit", &ProfileOverrides{Fullscreen: false}, "1920", "1080", "true", OriginGlobal, OriginGlobal},
+		{"own resolution", &ProfileOverrides{ResolutionWidth: 1280, ResolutionHeight: 720}, "1280", "720", "false", OriginProfile, OriginProfile},
+		{"width only", &ProfileOverrides{ResolutionWidth: 1280, Fullscreen: true}, "1280", "1080", "true", OriginProfile, OriginProfile},
+		{"height only", &ProfileOverrides{ResolutionHeight: 720}, "1920", "720", "false", OriginGlobal, OriginProfile},
+	} {
+		v := effectiveValues(stored, tc.profile, nil)
+		if v[KeyResolutionWidth].Value != tc.width || v[KeyResolutionHeight].Value != tc.height || v[KeyFullscreen].Value != tc.fullscreen {
+			t.Errorf("%s: %sx%s fullscreen=%s", tc.name, v[KeyResolutionWidth].Value, v[KeyResolutionHeight].Value, v[KeyFullscreen].Value)
+		}
+		if v[KeyResolutionWidth].Origin != tc.widthOrigin || v[KeyFullscreen].Origin != tc.fsOrigin {
+			t.Errorf("%s: origins %s/%s", tc.name, v[KeyResolutionWidth].Origin, v[KeyFullscreen].Origin)
+		}
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	stored := map[string]string{KeyMemoryMax: itoa(DefaultMemoryMax), KeyMemoryMin: "2048"}
+	pack := &PackRecommendations{MemoryRecommended: 6144, JavaMinimumVersion: 21, ResolutionWidth: 1600, ResolutionHeight: 900}
+
+	v := effectiveValues(stored, nil, pack)
+	if v[KeyMemoryMax].Value != "6144" || v[KeyMemoryMax].Origin != OriginHyeniPack {
+		t.Errorf("memory max %+v", v[KeyMemoryMax])
+	}
+	if v[KeyMemoryMin].Origin != OriginGlobal || v[KeyJavaMinVersion].Value != "21" {
+		t.Errorf("memory min %+v, java %+v", v[KeyMemoryMin], v[KeyJavaMinVersion])
+	}
+	if v[KeyResolutionHeight].Value != "900" {
+		t.Errorf("resolution %+v", v[KeyResolutionHeight])
+	}
+
+	v = effectiveValues(stored, &ProfileOverrides{MemoryMax: 8192, ResolutionWidth: 1280}, pack)
+	if v[KeyMemoryMax].Value != "8192" || v[KeyMemoryMax].Origin != OriginProfile {
+		t.Errorf("profile memory max %+v", v[KeyMemoryMax])
+	}
+	// The pack's height is the inherited value for a profile that only sets a width
+	if v[KeyResolutionWidth].Value != "1280" || v[KeyResolutionHeight].Value != "900" || v[KeyResolutionHeight].Origin != OriginHyeniPack {
+		t.Errorf("resolution %+v %+v", v[KeyResolutionWidth], v[KeyResolutionHeight])
+	}
+}
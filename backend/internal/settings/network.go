@@ -0,0 +1,90 @@
+package settings
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"hyenimc/backend/internal/httpclient"
+)
+
+// Network setting keys (outbound HTTP of every backend service)
+const (
+	KeyNetworkProxyMode         = "network.proxy_mode"
+	KeyNetworkProxyURL          = "network.proxy_url"
+	KeyNetworkNoProxy           = "network.no_proxy"
+	KeyNetworkCABundle          = "network.ca_bundle"
+	KeyNetworkUserAgent         = "network.user_agent"
+	KeyNetworkConnectTimeoutMs  = "network.connect_timeout_ms"
+	KeyNetworkResponseTimeoutMs = "network.response_timeout_ms"
+)
+
+func init() {
+	MustRegister(
+		Definition{Key: KeyNetworkProxyMode, Type: TypeString, Default: httpclient.ProxyModeSystem,
+			Options:     []string{httpclient.ProxyModeSystem, httpclient.ProxyModeManual, httpclient.ProxyModeNone},
+			Description: "system uses the HTTP(S)_PROXY environment, manual uses network.proxy_url, none connects directly"},
+		Definition{Key: KeyNetworkProxyURL, Type: TypeString,
+			Description: "Proxy for manual mode, e.g. http://proxy:3128 or socks5://127.0.0.1:1080"},
+		Definition{Key: KeyNetworkNoProxy, Type: TypeStringList,
+			Description: "Hosts, .domain suffixes, IPs or CIDRs that bypass the proxy"},
+		Definition{Key: KeyNetworkCABundle, Type: TypeString,
+			Description: "PEM file with extra trusted certificate authorities"},
+		Definition{Key: KeyNetworkUserAgent, Type: TypeString, Default: httpclient.DefaultUserAgent,
+			Description: "User-Agent sent with outbound requests"},
+		Definition{Key: KeyNetworkConnectTimeoutMs, Type: TypeInt, Default: "10000", Min: 1000, Max: 120000,
+			Description: "Connect and TLS handshake timeout in milliseconds"},
+		Definition{Key: KeyNetworkResponseTimeoutMs, Type: TypeInt, Default: "30000", Min: 1000, Max: 600000,
+			Description: "Timeout waiting for response headers in milliseconds"},
+	)
+
+	RegisterRule(func(values map[string]string) *FieldError {
+		if values[KeyNetworkProxyMode] != httpclient.ProxyModeManual {
+			return nil
+		}
+		if err := httpclient.ValidateProxyURL(values[KeyNetworkProxyURL]); err != nil {
+			return &FieldError{Key: KeyNetworkProxyURL, Message: err.Error()}
+		}
+		return nil
+	})
+	RegisterRule(func(values map[string]string) *FieldError {
+		path := values[KeyNetworkCABundle]
+		if path == "" {
+			return nil
+		}
+		if _, err := httpclient.LoadCABundle(path); err != nil {
+			return &FieldError{Key: KeyNetworkCABundle, Message: err.Error()}
+		}
+		return nil
+	})
+}
+
+// IsNetworkKey reports whether key belongs to the network settings
+func IsNetworkKey(key string) bool {
+	return strings.HasPrefix(key, "network.")
+}
+
+// NetworkConfig returns the outbound HTTP configuration from the stored settings
+func (s *Service) NetworkConfig() (httpclient.Config, error) {
+	values, err := s.Values()
+	if err != nil {
+		return httpclient.Config{}, err
+	}
+	return NetworkConfigFrom(values), nil
+}
+
+// NetworkConfigFrom builds the outbound HTTP configuration from setting values
+func NetworkConfigFrom(values map[string]string) httpclient.Config {
+	cfg := httpclient.Config{
+		ProxyMode:       values[KeyNetworkProxyMode],
+		ProxyURL:        values[KeyNetworkProxyURL],
+		CABundle:        values[KeyNetworkCABundle],
+		UserAgent:       values[KeyNetworkUserAgent],
+		ConnectTimeout:  time.Duration(parseInt32(values[KeyNetworkConnectTimeoutMs], 10000)) * time.Millisecond,
+		ResponseTimeout: time.Duration(parseInt32(values[KeyNetworkResponseTimeoutMs], 30000)) * time.Millisecond,
+	}
+	if raw := values[KeyNetworkNoProxy]; raw != "" {
+		_ = json.Unmarshal([]byte(raw), &cfg.NoProxy)
+	}
+	return cfg
+}
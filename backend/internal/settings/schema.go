@@ -0,0 +1,214 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Setting value types
+const (
+	TypeString     = "string"
+	TypeInt        = "int"
+	TypeBool       = "bool"
+	TypeStringList = "string_list" // stored as a JSON array of strings
+)
+
+// Definition describes one global setting
+type Definition struct {
+	Key         string
+	Type        string
+	Default     string
+	Min         int64    // TypeInt lower bound, applied when Min < Max
+	Max         int64    // TypeInt upper bound, applied when Min < Max
+	Options     []string // allowed values for TypeString, empty means any
+	Description string
+}
+
+// Rule checks a constraint across several settings. values contains every
+// registered key; return nil when the constraint holds.
+type Rule func(values map[string]string) *FieldError
+
+// FieldError is a validation failure of one setting
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// ValidationError collects every invalid setting of a write
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i := range e.Fields {
+		msgs[i] = e.Fields[i].Error()
+	}
+	return "invalid settings: " + strings.Join(msgs, "; ")
+}
+
+var registry = struct {
+	mu    sync.RWMutex
+	defs  map[string]Definition
+	rules []Rule
+}{defs: make(map[string]Definition)}
+
+// Register adds a setting to the schema. The default must satisfy the definition.
+func Register(def Definition) error {
+	if def.Key == "" {
+		return fmt.Errorf("setting key is required")
+	}
+	switch def.Type {
+	case TypeString, TypeInt, TypeBool, TypeStringList:
+	default:
+		return fmt.Errorf("setting %s: unknown type %q", def.Key, def.Type)
+	}
+	normalized, err := def.Normalize(def.Default)
+	if err != nil {
+		return fmt.Errorf("setting %s: invalid default: %w", def.Key, err)
+	}
+	def.Default = normalized
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, exists := registry.defs[def.Key]; exists {
+		return fmt.Errorf("setting %s is already registered", def.Key)
+	}
+	registry.defs[def.Key] = def
+	return nil
+}
+
+// MustRegister registers settings and panics on an invalid definition
+func MustRegister(defs ...Definition) {
+	for _, def := range defs {
+		if err := Register(def); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterRule adds a cross-setting constraint
+func RegisterRule(rule Rule) {
+	registry.mu.Lock()
+	registry.rules = append(registry.rules, rule)
+	registry.mu.Unlock()
+}
+
+// Lookup returns the definition of key
+func Lookup(key string) (Definition, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	def, ok := registry.defs[key]
+	return def, ok
+}
+
+// Definitions returns every registered setting sorted by key
+func Definitions() []Definition {
+	registry.mu.RLock()
+	defs := make([]Definition, 0, len(registry.defs))
+	for _, def := range registry.defs {
+		defs = append(defs, def)
+	}
+	registry.mu.RUnlock()
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Key < defs[j].Key })
+	return defs
+}
+
+// Normalize validates value against the definition and returns its canonical form
+func (d Definition) Normalize(value string) (string, error) {
+	switch d.Type {
+	case TypeInt:
+		i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("must be an integer")
+		}
+		if d.Min < d.Max && (i < d.Min || i > d.Max) {
+			return "", fmt.Errorf("must be between %d and %d", d.Min, d.Max)
+		}
+		return strconv.FormatInt(i, 10), nil
+	case TypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("must be true or false")
+		}
+		return btoa(b), nil
+	case TypeStringList:
+		list := []string{}
+		if strings.TrimSpace(value) != "" {
+			if err := json.Unmarshal([]byte(value), &list); err != nil {
+				return "", fmt.Errorf("must be a JSON array of strings")
+			}
+		}
+		data, _ := json.Marshal(list)
+		return string(data), nil
+	default:
+		if len(d.Options) > 0 {
+			for _, opt := range d.Options {
+				if value == opt {
+					return value, nil
+				}
+			}
+			return "", fmt.Errorf("must be one of %s", strings.Join(d.Options, ", "))
+		}
+		return value, nil
+	}
+}
+
+// validate normalizes updates against the schema and checks the rules on the
+// merged result. current must hold every registered key.
+func validate(current, updates map[string]string) (map[string]string, error) {
+	var fields []FieldError
+	normalized := make(map[string]string, len(updates))
+	for key, value := range updates {
+		def, ok := Lookup(key)
+		if !ok {
+			fields = append(fields, FieldError{Key: key, Message: "unknown setting"})
+			continue
+		}
+		v, err := def.Normalize(value)
+		if err != nil {
+			fields = append(fields, FieldError{Key: key, Message: err.Error()})
+			continue
+		}
+		normalized[key] = v
+	}
+
+	if len(fields) == 0 {
+		merged := make(map[string]string, len(current))
+		for k, v := range current {
+			merged[k] = v
+		}
+		for k, v := range normalized {
+			merged[k] = v
+		}
+		registry.mu.RLock()
+		rules := registry.rules
+		registry.mu.RUnlock()
+		for _, rule := range rules {
+			fe := rule(merged)
+			if fe == nil {
+				continue
+			}
+			// A rule the stored values already break (a CA bundle deleted since it was
+			// set) only blocks updates that touch the reported key
+			if _, updated := normalized[fe.Key]; !updated && rule(current) != nil {
+				continue
+			}
+			fields = append(fields, *fe)
+		}
+	}
+
+	if len(fields) > 0 {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+		return nil, &ValidationError{Fields: fields}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,26 @@
+package settings
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateRulesOnStoredValues(t *testing.T) {
+	current := GetDefaultSettings()
+	current[KeyNetworkCABundle] = filepath.Join(t.TempDir(), "deleted.pem")
+
+	// A CA bundle deleted after it was set does not block unrelated updates
+	if _, err := validate(current, map[string]string{KeyMemoryMax: "6144"}); err != nil {
+		t.Errorf("unrelated update: %v", err)
+	}
+	var verr *ValidationError
+	if _, err := validate(current, map[string]string{KeyNetworkCABundle: filepath.Join(t.TempDir(), "other.pem")}); !errors.As(err, &verr) {
+		t.Errorf("missing CA bundle: got %v", err)
+	}
+
+	// An update that breaks a rule through another key is still refused
+	if _, err := validate(GetDefaultSettings(), map[string]string{KeyNetworkProxyMode: "manual"}); !errors.As(err, &verr) || verr.Fields[0].Key != KeyNetworkProxyURL {
+		t.Errorf("manual proxy without URL: got %v", err)
+	}
+}
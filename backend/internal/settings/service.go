@@ -3,11 +3,16 @@ package settings
 import (
 	"fmt"
 	"strconv"
+	"sync"
+	"time"
 )
 
 // Service handles settings business logic
 type Service struct {
 	repo *Repository
+
+	writeMu sync.Mutex // serializes writes so change notifications see consistent snapshots
+	watch   watchers
 }
 
 // NewService creates a new settings service
@@ -32,24 +37,18 @@ type GlobalSettings struct {
 	CacheEnabled   bool
 	CacheMaxSizeGB int32
 	CacheTTLDays   int32
+
+	UpdateCheckIntervalHours int32
+	UpdateAutoDownload       bool
 }
 
 // Get retrieves all global settings
 func (s *Service) Get() (*GlobalSettings, error) {
-	all, err := s.repo.GetAll()
+	all, err := s.Values()
 	if err != nil {
 		return nil, err
 	}
 
-	// If no settings exist, initialize with defaults
-	if len(all) == 0 {
-		defaults := GetDefaultSettings()
-		if err := s.repo.SetBatch(defaults); err != nil {
-			return nil, fmt.Errorf("failed to initialize default settings: %w", err)
-		}
-		all = defaults
-	}
-
 	settings := &GlobalSettings{
 		JavaPath:    all[KeyJavaPath],
 		MemoryMin:   parseInt32(all[KeyMemoryMin], DefaultMemoryMin),
@@ -66,6 +65,9 @@ func (s *Service) Get() (*GlobalSettings, error) {
 		CacheEnabled:   parseBool(all[KeyCacheEnabled], DefaultCacheEnabled),
 		CacheMaxSizeGB: parseInt32(all[KeyCacheMaxSizeGB], DefaultCacheMaxSizeGB),
 		CacheTTLDays:   parseInt32(all[KeyCacheTTLDays], DefaultCacheTTLDays),
+
+		UpdateCheckIntervalHours: parseInt32(all[KeyUpdateCheckIntervalHours], DefaultUpdateCheckIntervalHours),
+		UpdateAutoDownload:       parseBool(all[KeyUpdateAutoDownload], DefaultUpdateAutoDownload),
 	}
 	
 	// Auto-fix invalid memory settings from old data
@@ -78,14 +80,8 @@ func (s *Service) Get() (*GlobalSettings, error) {
 	return settings, nil
 }
 
-// Update updates global settings
+// Update replaces every global setting. Invalid values are rejected with a *ValidationError.
 func (s *Service) Update(settings *GlobalSettings) error {
-	// Validate memory settings: ensure min <= max
-	if settings.MemoryMin > settings.MemoryMax {
-		// Auto-adjust: set max to min
-		settings.MemoryMax = settings.MemoryMin
-	}
-	
 	values := map[string]string{
 		KeyJavaPath:    settings.JavaPath,
 		KeyMemoryMin:   fmt.Sprintf("%d", settings.MemoryMin),
@@ -102,17 +98,128 @@ func (s *Service) Update(settings *GlobalSettings) error {
 		KeyCacheEnabled:   fmt.Sprintf("%t", settings.CacheEnabled),
 		KeyCacheMaxSizeGB: fmt.Sprintf("%d", settings.CacheMaxSizeGB),
 		KeyCacheTTLDays:   fmt.Sprintf("%d", settings.CacheTTLDays),
+
+		KeyUpdateCheckIntervalHours: fmt.Sprintf("%d", settings.UpdateCheckIntervalHours),
+		KeyUpdateAutoDownload:       fmt.Sprintf("%t", settings.UpdateAutoDownload),
+	}
+
+	return s.SetValues(values, nil)
+}
+
+// Values returns every registered setting, falling back to schema defaults for
+// keys that are not stored yet
+func (s *Service) Values() (map[string]string, error) {
+	stored, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// If no settings exist, initialize with defaults
+	if len(stored) == 0 {
+		defaults := GetDefaultSettings()
+		if err := s.repo.SetBatch(defaults); err != nil {
+			return nil, fmt.Errorf("failed to initialize default settings: %w", err)
+		}
+		return defaults, nil
+	}
+
+	values := GetDefaultSettings()
+	for key, value := range stored {
+		if _, ok := values[key]; ok {
+			values[key] = value
+		}
 	}
+	return values, nil
+}
+
+// TrashRetention returns how long deleted profiles are kept in the trash
+func (s *Service) TrashRetention() time.Duration {
+	days := int32(DefaultTrashRetentionDays)
+	if values, err := s.Values(); err == nil {
+		days = parseInt32(values[KeyTrashRetentionDays], days)
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
 
-	return s.repo.SetBatch(values)
+// SnapshotLimits returns how many snapshots each profile keeps and for how long
+func (s *Service) SnapshotLimits() (int, time.Duration) {
+	count, days := int32(DefaultSnapshotMaxPerProfile), int32(DefaultSnapshotMaxAgeDays)
+	if values, err := s.Values(); err == nil {
+		count = parseInt32(values[KeySnapshotMaxPerProfile], count)
+		days = parseInt32(values[KeySnapshotMaxAgeDays], days)
+	}
+	return int(count), time.Duration(days) * 24 * time.Hour
+}
+
+// ValidateValues checks a partial update against the schema and the stored settings
+// without writing anything
+func (s *Service) ValidateValues(updates map[string]string) error {
+	current, err := s.Values()
+	if err != nil {
+		return err
+	}
+	_, err = validate(current, updates)
+	return err
+}
+
+// SetValues applies a partial update: keys in updates are validated against the
+// schema and stored, keys in reset return to their defaults. Nothing is written
+// unless the whole update is valid.
+func (s *Service) SetValues(updates map[string]string, reset []string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	current, err := s.Values()
+	if err != nil {
+		return err
+	}
+	changes := make(map[string]string, len(updates)+len(reset))
+	var unknown []FieldError
+	for _, key := range reset {
+		def, ok := Lookup(key)
+		if !ok {
+			unknown = append(unknown, FieldError{Key: key, Message: "unknown setting"})
+			continue
+		}
+		changes[key] = def.Default
+	}
+	if len(unknown) > 0 {
+		return &ValidationError{Fields: unknown}
+	}
+	for key, value := range updates {
+		changes[key] = value
+	}
+
+	normalized, err := validate(current, changes)
+	if err != nil {
+		return err
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+
+	before := s.snapshot()
+	if err := s.repo.SetBatch(normalized); err != nil {
+		return err
+	}
+	s.notify(before)
+	return nil
 }
 
 // Reset resets all settings to defaults
 func (s *Service) Reset() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	before := s.snapshot()
+
 	if err := s.repo.Clear(); err != nil {
 		return err
 	}
-	return s.repo.SetBatch(GetDefaultSettings())
+	if err := s.repo.SetBatch(GetDefaultSettings()); err != nil {
+		return err
+	}
+	s.notify(before)
+	return nil
 }
 
 // Helper functions
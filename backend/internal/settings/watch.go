@@ -0,0 +1,115 @@
+package settings
+
+import (
+	"log"
+	"sort"
+	"sync"
+)
+
+// Change describes a settings write that modified at least one key
+type Change struct {
+	Keys     []string        // changed keys, sorted
+	Settings *GlobalSettings // settings after the change
+}
+
+// Has reports whether key is among the changed keys
+func (c *Change) Has(key string) bool {
+	for _, k := range c.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// watchers holds the subscribers of a Service
+type watchers struct {
+	mu   sync.Mutex
+	subs map[chan *Change]struct{}
+}
+
+// Subscribe registers for settings changes. The returned function unsubscribes
+// and closes the channel. Slow subscribers drop changes instead of blocking writers,
+// so consumers should re-read the settings they care about from Change.Settings.
+func (s *Service) Subscribe(buffer int) (<-chan *Change, func()) {
+	if buffer <= 0 {
+		buffer = 8
+	}
+	ch := make(chan *Change, buffer)
+
+	s.watch.mu.Lock()
+	if s.watch.subs == nil {
+		s.watch.subs = make(map[chan *Change]struct{})
+	}
+	s.watch.subs[ch] = struct{}{}
+	s.watch.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.watch.mu.Lock()
+			delete(s.watch.subs, ch)
+			s.watch.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// snapshot returns the stored values used to detect changes
+func (s *Service) snapshot() map[string]string {
+	all, err := s.repo.GetAll()
+	if err != nil {
+		log.Printf("[Settings] Failed to snapshot settings: %v", err)
+		return nil
+	}
+	return all
+}
+
+// notify publishes the keys that differ between before and the current state
+func (s *Service) notify(before map[string]string) {
+	s.watch.mu.Lock()
+	n := len(s.watch.subs)
+	s.watch.mu.Unlock()
+	if n == 0 {
+		return
+	}
+
+	after := s.snapshot()
+	keys := diffKeys(before, after)
+	if len(keys) == 0 {
+		return
+	}
+	current, err := s.Get()
+	if err != nil {
+		log.Printf("[Settings] Failed to load settings for change notification: %v", err)
+		return
+	}
+
+	change := &Change{Keys: keys, Settings: current}
+	s.watch.mu.Lock()
+	defer s.watch.mu.Unlock()
+	for ch := range s.watch.subs {
+		select {
+		case ch <- change:
+		default:
+			log.Printf("[Settings] Subscriber is not keeping up, dropped change %v", keys)
+		}
+	}
+}
+
+// diffKeys returns the sorted keys whose values differ between a and b
+func diffKeys(a, b map[string]string) []string {
+	var keys []string
+	for k, v := range b {
+		if old, ok := a[k]; !ok || old != v {
+			keys = append(keys, k)
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
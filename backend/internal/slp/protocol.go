@@ -0,0 +1,330 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	// Handshake protocol version -1 asks the server to report its own version
+	handshakeProtocol = -1
+	// Legacy ping advertises 1.6.4
+	legacyProtocol = 78
+
+	maxPacketLen  = 1 << 21 // vanilla's limit for a single packet
+	faviconPrefix = "data:image/png;base64,"
+)
+
+// pingModern performs the 1.7+ handshake, status request and ping
+func pingModern(conn net.Conn, host string, port int) (*Status, error) {
+	status, err := statusRequest(conn, host, port)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	return status, nil
+}
+
+func statusRequest(conn net.Conn, host string, port int) (*Status, error) {
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, handshakeProtocol)
+	writeString(&handshake, host)
+	binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1) // next state: status
+
+	var out bytes.Buffer
+	writePacket(&out, 0x00, handshake.Bytes())
+	writePacket(&out, 0x00, nil) // status request
+	start := time.Now()
+	if _, err := conn.Write(out.Bytes()); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	id, payload, err := readPacket(r)
+	if err != nil {
+		return nil, fmt.Errorf("status response: %w", err)
+	}
+	if id != 0x00 {
+		return nil, fmt.Errorf("unexpected packet 0x%02x", id)
+	}
+	latency := time.Since(start)
+	body, err := readString(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("status response: %w", err)
+	}
+	status, err := parseStatus([]byte(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Ping/pong for a latency figure without the cost of building the status;
+	// some servers close instead, in which case the status round trip stands
+	status.Latency = latency
+	var ping bytes.Buffer
+	token := time.Now().UnixNano()
+	binary.Write(&ping, binary.BigEndian, token)
+	out.Reset()
+	writePacket(&out, 0x01, ping.Bytes())
+	start = time.Now()
+	if _, err := conn.Write(out.Bytes()); err == nil {
+		if id, payload, err := readPacket(r); err == nil && id == 0x01 && bytes.Equal(payload, ping.Bytes()) {
+			status.Latency = time.Since(start)
+		}
+	}
+	return status, nil
+}
+
+// statusJSON is the status response; description is a chat component
+type statusJSON struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+			ID   string `json:"id"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description json.RawMessage `json:"description"`
+	Favicon     string          `json:"favicon"`
+}
+
+func parseStatus(data []byte) (*Status, error) {
+	var raw statusJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid status JSON: %w", err)
+	}
+	status := &Status{
+		Version:     raw.Version.Name,
+		Protocol:    raw.Version.Protocol,
+		Online:      raw.Players.Online,
+		Max:         raw.Players.Max,
+		Description: raw.Description,
+		Favicon:     strings.TrimPrefix(raw.Favicon, faviconPrefix),
+	}
+	for _, p := range raw.Players.Sample {
+		status.Sample = append(status.Sample, Player{Name: p.Name, ID: p.ID})
+	}
+	if len(raw.Description) > 0 {
+		var component any
+		if err := json.Unmarshal(raw.Description, &component); err == nil {
+			status.MOTD = StripFormatting(flattenText(component))
+		}
+	}
+	return status, nil
+}
+
+// flattenText concatenates the text of a chat component and its children.
+// Translated components fall back to their key.
+func flattenText(component any) string {
+	switch c := component.(type) {
+	case string:
+		return c
+	case []any:
+		var sb strings.Builder
+		for _, part := range c {
+			sb.WriteString(flattenText(part))
+		}
+		return sb.String()
+	case map[string]any:
+		var sb strings.Builder
+		if text, ok := c["text"]; ok {
+			sb.WriteString(flattenText(text))
+		} else if key, ok := c["translate"].(string); ok {
+			sb.WriteString(key)
+		}
+		if extra, ok := c["extra"]; ok {
+			sb.WriteString(flattenText(extra))
+		}
+		return sb.String()
+	case float64:
+		return strconv.FormatFloat(c, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(c)
+	}
+	return ""
+}
+
+// StripFormatting removes § colour and style codes
+func StripFormatting(s string) string {
+	if !strings.ContainsRune(s, '§') {
+		return s
+	}
+	var sb strings.Builder
+	skip := false
+	for _, r := range s {
+		switch {
+		case skip:
+			skip = false
+		case r == '§':
+			skip = true
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// pingLegacy sends the 1.6 ping (0xFE 0x01 plus MC|PingHost), which 1.4 to 1.6
+// servers answer and older servers answer in the 1.3 format
+func pingLegacy(conn net.Conn, host string, port int) (*Status, error) {
+	hostChars := utf16.Encode([]rune(host))
+	var out bytes.Buffer
+	out.Write([]byte{0xfe, 0x01, 0xfa})
+	writeUTF16(&out, "MC|PingHost")
+	binary.Write(&out, binary.BigEndian, uint16(7+2*len(hostChars)))
+	out.WriteByte(legacyProtocol)
+	writeUTF16(&out, host)
+	binary.Write(&out, binary.BigEndian, int32(port))
+
+	start := time.Now()
+	if _, err := conn.Write(out.Bytes()); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	id, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if id != 0xff {
+		return nil, fmt.Errorf("%w: unexpected legacy packet 0x%02x", ErrProtocol, id)
+	}
+	body, err := readUTF16(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	status, err := parseLegacy(body)
+	if err != nil {
+		return nil, err
+	}
+	status.Latency = time.Since(start)
+	return status, nil
+}
+
+// parseLegacy reads "§1\0protocol\0version\0motd\0online\0max" (1.4+) or
+// "motd§online§max" (beta 1.8 to 1.3)
+func parseLegacy(body string) (*Status, error) {
+	status := &Status{Legacy: true}
+	if strings.HasPrefix(body, "§1\x00") {
+		fields := strings.Split(body, "\x00")
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("%w: legacy response has %d fields", ErrProtocol, len(fields))
+		}
+		status.Protocol, _ = strconv.Atoi(fields[1])
+		status.Version = fields[2]
+		status.MOTD = StripFormatting(fields[3])
+		status.Online, _ = strconv.Atoi(fields[4])
+		status.Max, _ = strconv.Atoi(fields[5])
+		return status, nil
+	}
+
+	fields := strings.Split(body, "§")
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("%w: malformed legacy response", ErrProtocol)
+	}
+	n := len(fields)
+	status.MOTD = strings.Join(fields[:n-2], "§")
+	status.Online, _ = strconv.Atoi(fields[n-2])
+	status.Max, _ = strconv.Atoi(fields[n-1])
+	return status, nil
+}
+
+func writeVarInt(w *bytes.Buffer, v int32) {
+	u := uint32(v)
+	for u >= 0x80 {
+		w.WriteByte(byte(u) | 0x80)
+		u >>= 7
+	}
+	w.WriteByte(byte(u))
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(v), nil
+		}
+	}
+	return 0, fmt.Errorf("varint too long")
+}
+
+func writeString(w *bytes.Buffer, s string) {
+	writeVarInt(w, int32(len(s)))
+	w.WriteString(s)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || int(n) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", n)
+	}
+	b := make([]byte, n)
+	io.ReadFull(r, b)
+	return string(b), nil
+}
+
+func writePacket(w *bytes.Buffer, id int32, payload []byte) {
+	var body bytes.Buffer
+	writeVarInt(&body, id)
+	body.Write(payload)
+	writeVarInt(w, int32(body.Len()))
+	w.Write(body.Bytes())
+}
+
+func readPacket(r *bufio.Reader) (int32, []byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if n <= 0 || n > maxPacketLen {
+		return 0, nil, fmt.Errorf("invalid packet length %d", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	br := bytes.NewReader(data)
+	id, err := readVarInt(br)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, data[len(data)-br.Len():], nil
+}
+
+func writeUTF16(w *bytes.Buffer, s string) {
+	chars := utf16.Encode([]rune(s))
+	binary.Write(w, binary.BigEndian, uint16(len(chars)))
+	binary.Write(w, binary.BigEndian, chars)
+}
+
+func readUTF16(r io.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return "", err
+	}
+	chars := make([]uint16, n)
+	if err := binary.Read(r, binary.BigEndian, chars); err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(chars)), nil
+}
@@ -0,0 +1,162 @@
+// Package slp queries the status of Minecraft Java Edition servers with the
+// Server List Ping protocol, the same request the multiplayer screen sends.
+package slp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPort is used when an address has no port and no SRV record
+const DefaultPort = 25565
+
+// DefaultTimeout applies when the context passed to Ping has no deadline
+const DefaultTimeout = 5 * time.Second
+
+// Status is the state a server reports
+type Status struct {
+	Host        string // host contacted, after SRV resolution
+	Port        int
+	Version     string
+	Protocol    int
+	Online      int
+	Max         int
+	Sample      []Player
+	MOTD        string          // plain text, formatting removed
+	Description json.RawMessage // raw chat component; empty for legacy pings
+	Favicon     string          // base64 PNG without the data: prefix, as servers.dat stores icons
+	Latency     time.Duration
+	Legacy      bool // answered the pre-1.7 ping
+}
+
+// Player is an entry of the player sample
+type Player struct {
+	Name string
+	ID   string
+}
+
+// ErrProtocol wraps responses that do not follow the protocol
+var ErrProtocol = errors.New("slp: protocol error")
+
+// Resolver looks up SRV records. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// Pinger sends status requests
+type Pinger struct {
+	Resolver Resolver // nil = net.DefaultResolver
+	Dialer   net.Dialer
+}
+
+// Ping queries address with the default Pinger
+func Ping(ctx context.Context, address string) (*Status, error) {
+	return (&Pinger{}).Ping(ctx, address)
+}
+
+// Ping resolves address and sends a modern status request, falling back to the
+// 1.6 legacy ping when the server does not speak the modern protocol
+func (p *Pinger) Ping(ctx context.Context, address string) (*Status, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	host, port, err := p.Resolve(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	// The handshake carries the address as typed, so virtual hosts on proxies still match
+	handshakeHost, _, _ := splitAddress(address)
+
+	status, err := p.ping(ctx, host, port, func(conn net.Conn) (*Status, error) {
+		return pingModern(conn, handshakeHost, port)
+	})
+	if err == nil || !errors.Is(err, ErrProtocol) || ctx.Err() != nil {
+		return status, err
+	}
+
+	status, legacyErr := p.ping(ctx, host, port, func(conn net.Conn) (*Status, error) {
+		return pingLegacy(conn, handshakeHost, port)
+	})
+	if legacyErr != nil {
+		return nil, fmt.Errorf("%w (legacy ping: %v)", err, legacyErr)
+	}
+	return status, nil
+}
+
+func (p *Pinger) ping(ctx context.Context, host string, port int, fn func(net.Conn) (*Status, error)) (*Status, error) {
+	conn, err := p.Dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Unblock reads when ctx is cancelled before the deadline
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	status, err := fn(conn)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	status.Host, status.Port = host, port
+	return status, nil
+}
+
+// Resolve returns the host and port to connect to. Addresses without a port are
+// looked up as _minecraft._tcp SRV records, as the game does.
+func (p *Pinger) Resolve(ctx context.Context, address string) (string, int, error) {
+	host, port, err := splitAddress(address)
+	if err != nil {
+		return "", 0, err
+	}
+	if port != 0 {
+		return host, port, nil
+	}
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return host, DefaultPort, nil
+	}
+
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if _, records, err := resolver.LookupSRV(ctx, "minecraft", "tcp", host); err == nil && len(records) > 0 {
+		return strings.TrimSuffix(records[0].Target, "."), int(records[0].Port), nil
+	}
+	return host, DefaultPort, nil
+}
+
+// splitAddress parses host[:port]; port is 0 when absent
+func splitAddress(address string) (string, int, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", 0, fmt.Errorf("slp: empty address")
+	}
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		// No port; strip brackets of a bare IPv6 literal
+		return strings.Trim(address, "[]"), 0, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("slp: invalid port in %q", address)
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("slp: missing host in %q", address)
+	}
+	return host, port, nil
+}
@@ -0,0 +1,228 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const statusBody = `{
+	"version": {"name": "1.21.1", "protocol": 767},
+	"players": {"max": 100, "online": 2, "sample": [{"name": "hyeni", "id": "4566e69f-c907-48ee-8d71-d7ba5aa00d20"}]},
+	"description": {"text": "§aHyeni", "extra": [{"text": "World"}, " ", {"translate": "multiplayer.status"}]},
+	"favicon": "data:image/png;base64,iVBORw0KGgo="
+}`
+
+// fakeServer answers connections with handle until the test ends
+func fakeServer(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// modernHandler plays a 1.7+ server; legacy pings are dropped
+func modernHandler(pong bool) func(net.Conn) {
+	return func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		id, payload, err := readPacket(r)
+		if err != nil || id != 0x00 {
+			return
+		}
+		br := bytes.NewReader(payload)
+		if proto, _ := readVarInt(br); proto != handshakeProtocol {
+			return
+		}
+		if host, _ := readString(br); host != "127.0.0.1" {
+			return
+		}
+		if id, _, err := readPacket(r); err != nil || id != 0x00 {
+			return
+		}
+		var resp, out bytes.Buffer
+		writeString(&resp, statusBody)
+		writePacket(&out, 0x00, resp.Bytes())
+		conn.Write(out.Bytes())
+
+		id, payload, err = readPacket(r)
+		if err != nil || id != 0x01 || !pong {
+			return
+		}
+		out.Reset()
+		writePacket(&out, 0x01, payload)
+		conn.Write(out.Bytes())
+	}
+}
+
+// legacyHandler plays a 1.6 server, which drops modern handshakes
+func legacyHandler(conn net.Conn) {
+	head := make([]byte, 3)
+	if _, err := io.ReadFull(conn, head); err != nil || !bytes.Equal(head, []byte{0xfe, 0x01, 0xfa}) {
+		return
+	}
+	var out bytes.Buffer
+	out.WriteByte(0xff)
+	writeUTF16(&out, "§1\x0078\x001.6.4\x00§cOld §lserver\x003\x0020")
+	conn.Write(out.Bytes())
+}
+
+func TestPingModern(t *testing.T) {
+	for _, pong := range []bool{true, false} {
+		addr := fakeServer(t, modernHandler(pong))
+		status, err := Ping(context.Background(), addr)
+		if err != nil {
+			t.Fatalf("pong=%v: %v", pong, err)
+		}
+		if status.Legacy || status.Version != "1.21.1" || status.Protocol != 767 {
+			t.Errorf("version: %+v", status)
+		}
+		if status.Online != 2 || status.Max != 100 || len(status.Sample) != 1 || status.Sample[0].Name != "hyeni" {
+			t.Errorf("players: %+v", status)
+		}
+		if status.MOTD != "HyeniWorld multiplayer.status" {
+			t.Errorf("motd %q", status.MOTD)
+		}
+		if status.Favicon != "iVBORw0KGgo=" {
+			t.Errorf("favicon %q", status.Favicon)
+		}
+		if status.Latency <= 0 || status.Host != "127.0.0.1" {
+			t.Errorf("latency %v host %s", status.Latency, status.Host)
+		}
+	}
+}
+
+func TestPingLegacyFallback(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) {
+		b := bufio.NewReader(conn)
+		if first, err := b.Peek(1); err != nil || first[0] != 0xfe {
+			return // modern handshake: close like an old server
+		}
+		legacyHandler(&peekedConn{Conn: conn, r: b})
+	})
+	status, err := Ping(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Legacy || status.Version != "1.6.4" || status.MOTD != "Old server" || status.Online != 3 || status.Max != 20 {
+		t.Errorf("%+v", status)
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if _, err := Ping(context.Background(), addr); err == nil || errors.Is(err, ErrProtocol) {
+		t.Fatalf("expected a dial error, got %v", err)
+	}
+}
+
+func TestPingTimeout(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) { io.Copy(io.Discard, conn) })
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := Ping(ctx, addr); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("ping did not stop at the deadline")
+	}
+}
+
+type fakeResolver struct{ target string }
+
+func (f fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if service != "minecraft" || proto != "tcp" || name != "play.example.com" {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	host, port, _ := net.SplitHostPort(f.target)
+	p, _ := strconv.Atoi(port)
+	return "", []*net.SRV{{Target: host + ".", Port: uint16(p)}}, nil
+}
+
+func TestResolve(t *testing.T) {
+	p := &Pinger{Resolver: fakeResolver{target: "mc.example.net:25570"}}
+	cases := map[string]string{
+		"play.example.com":       "mc.example.net:25570",
+		"play.example.com:25565": "play.example.com:25565",
+		"other.example.com":      "other.example.com:25565",
+		"10.0.0.1":               "10.0.0.1:25565",
+		"[::1]":                  "[::1]:25565",
+	}
+	for in, want := range cases {
+		host, port, err := p.Resolve(context.Background(), in)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if got := net.JoinHostPort(host, strconv.Itoa(port)); got != want {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "host:0", "host:abc", ":25565"} {
+		if _, _, err := p.Resolve(context.Background(), in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestParseLegacy(t *testing.T) {
+	status, err := parseLegacy("A beta server§5§10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.MOTD != "A beta server" || status.Online != 5 || status.Max != 10 {
+		t.Errorf("%+v", status)
+	}
+	if _, err := parseLegacy("nothing"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestVarInt(t *testing.T) {
+	for _, v := range []int32{0, 1, 127, 128, 25565, -1, 1<<31 - 1, -1 << 31} {
+		var buf bytes.Buffer
+		writeVarInt(&buf, v)
+		got, err := readVarInt(&buf)
+		if err != nil || got != v {
+			t.Errorf("%d: got %d, %v", v, got, err)
+		}
+	}
+	if _, err := readVarInt(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01})); err == nil {
+		t.Error("expected an error for a six byte varint")
+	}
+}
+
+// peekedConn reads through the buffered reader used to peek
+type peekedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) { return c.r.Read(p) }
@@ -0,0 +1,174 @@
+package snapshot
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Snapshot is a recorded copy of a profile's instance content
+type Snapshot struct {
+	ID           string
+	ProfileID    string
+	Reason       string // manual|hyenipack_update|mod_update|rollback
+	Label        string
+	IncludeSaves bool
+	FileCount    int
+	TotalBytes   int64 // size of the captured files
+	StoredBytes  int64 // bytes this snapshot added to the blob store
+	CreatedAt    time.Time
+}
+
+// File is one file of a snapshot
+type File struct {
+	Path    string // slash separated, relative to the game directory
+	Hash    string // SHA-256 of the content, the blob name
+	Size    int64
+	ModTime time.Time
+}
+
+// Repository handles snapshot persistence
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new snapshot repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Save stores a snapshot and its files in one transaction
+func (r *Repository) Save(snap *Snapshot, files []File) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO profile_snapshots (id, profile_id, reason, label, include_saves, file_count, total_bytes, stored_bytes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, snap.ID, snap.ProfileID, snap.Reason, snap.Label, snap.IncludeSaves, snap.FileCount,
+		snap.TotalBytes, snap.StoredBytes, snap.CreatedAt.Unix()); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO profile_snapshot_files (snapshot_id, path, hash, size, mod_time)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, f := range files {
+		if _, err := stmt.Exec(snap.ID, f.Path, f.Hash, f.Size, f.ModTime.UnixNano()); err != nil {
+			return fmt.Errorf("failed to save snapshot file %s: %w", f.Path, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Get retrieves a snapshot by ID
+func (r *Repository) Get(id string) (*Snapshot, error) {
+	row := r.db.QueryRow(`
+		SELECT id, profile_id, reason, label, include_saves, file_count, total_bytes, stored_bytes, created_at
+		FROM profile_snapshots WHERE id = ?
+	`, id)
+
+	var snap Snapshot
+	var createdAt int64
+	err := row.Scan(&snap.ID, &snap.ProfileID, &snap.Reason, &snap.Label, &snap.IncludeSaves,
+		&snap.FileCount, &snap.TotalBytes, &snap.StoredBytes, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("snapshot not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	snap.CreatedAt = time.Unix(createdAt, 0)
+	return &snap, nil
+}
+
+// ListByProfile returns the snapshots of a profile, newest first
+func (r *Repository) ListByProfile(profileID string) ([]*Snapshot, error) {
+	rows, err := r.db.Query(`
+		SELECT id, profile_id, reason, label, include_saves, file_count, total_bytes, stored_bytes, created_at
+		FROM profile_snapshots WHERE profile_id = ?
+		ORDER BY created_at DESC, rowid DESC
+	`, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snaps []*Snapshot
+	for rows.Next() {
+		var snap Snapshot
+		var createdAt int64
+		if err := rows.Scan(&snap.ID, &snap.ProfileID, &snap.Reason, &snap.Label, &snap.IncludeSaves,
+			&snap.FileCount, &snap.TotalBytes, &snap.StoredBytes, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snap.CreatedAt = time.Unix(createdAt, 0)
+		snaps = append(snaps, &snap)
+	}
+	return snaps, rows.Err()
+}
+
+// Files returns the files of a snapshot ordered by path
+func (r *Repository) Files(snapshotID string) ([]File, error) {
+	rows, err := r.db.Query(`
+		SELECT path, hash, size, mod_time FROM profile_snapshot_files
+		WHERE snapshot_id = ? ORDER BY path
+	`, snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []File
+	for rows.Next() {
+		var f File
+		var modTime int64
+		if err := rows.Scan(&f.Path, &f.Hash, &f.Size, &modTime); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot file: %w", err)
+		}
+		f.ModTime = time.Unix(0, modTime)
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// Delete removes a snapshot and its file rows
+func (r *Repository) Delete(id string) error {
+	result, err := r.db.Exec("DELETE FROM profile_snapshots WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("snapshot not found: %s", id)
+	}
+	return nil
+}
+
+// ReferencedHashes returns every blob hash still used by a snapshot
+func (r *Repository) ReferencedHashes() (map[string]bool, error) {
+	rows, err := r.db.Query("SELECT DISTINCT hash FROM profile_snapshot_files")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot hashes: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes[hash] = true
+	}
+	return hashes, rows.Err()
+}
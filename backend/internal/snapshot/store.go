@@ -0,0 +1,173 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store keeps gzip-compressed file contents named by their SHA-256, so a file
+// shared by many snapshots (or many profiles) is stored once
+type Store struct {
+	dir string
+}
+
+// NewStore creates a blob store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) blobPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// HashFile returns the SHA-256 of a file
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Put stores the file at path unless a blob with the same content exists.
+// It returns the content hash and the number of bytes added to the store.
+func (s *Store) Put(path string) (string, int64, error) {
+	hash, err := HashFile(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	dst := s.blobPath(hash)
+	if _, err := os.Stat(dst); err == nil {
+		return hash, 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), hash+".*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if _, err := io.Copy(gz, in); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("failed to store %s: %w", path, err)
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", 0, fmt.Errorf("failed to store blob %s: %w", hash, err)
+	}
+	return hash, info.Size(), nil
+}
+
+// Has reports whether a blob exists
+func (s *Store) Has(hash string) bool {
+	_, err := os.Stat(s.blobPath(hash))
+	return err == nil
+}
+
+// Extract writes the blob hash to dst with the given modification time.
+// dst is replaced atomically, so an interrupted extract never leaves a partial file.
+func (s *Store) Extract(hash, dst string, modTime time.Time) error {
+	f, err := os.Open(s.blobPath(hash))
+	if err != nil {
+		return fmt.Errorf("missing snapshot blob %s: %w", hash, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("corrupt snapshot blob %s: %w", hash, err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), gz); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to extract %s: %w", dst, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != hash {
+		return fmt.Errorf("snapshot blob %s is corrupt (content hash %s)", hash, got)
+	}
+	if err := os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+	return nil
+}
+
+// Collect removes every blob not in keep and returns how many were removed
+// and the bytes freed
+func (s *Store) Collect(keep map[string]bool) (int, int64, error) {
+	var removed int
+	var freed int64
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || keep[d.Name()] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		// Leave temp files of a Put in progress alone
+		if filepath.Ext(path) == ".tmp" && time.Since(info.ModTime()) < time.Hour {
+			return nil
+		}
+		if err := os.Remove(path); err == nil {
+			removed++
+			freed += info.Size()
+		}
+		return nil
+	})
+	return removed, freed, err
+}
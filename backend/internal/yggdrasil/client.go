@@ -0,0 +1,234 @@
+package yggdrasil
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hyenimc/backend/internal/download"
+	"hyenimc/backend/internal/httpclient"
+)
+
+// apiLocationHeader is the authlib-injector API location indication (ALI) header
+const apiLocationHeader = "X-Authlib-Injector-API-Location"
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("access token is invalid, sign in again")
+	ErrNoProfile          = errors.New("account has no game profile on this server")
+)
+
+// Error is an error response from a Yggdrasil server
+type Error struct {
+	Code    string `json:"error"`
+	Message string `json:"errorMessage"`
+	Cause   string `json:"cause"`
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("yggdrasil error %s: %s", e.Code, e.Message)
+	}
+	return "yggdrasil error " + e.Code
+}
+
+// Profile is a game profile on the server
+type Profile struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Session is the result of authenticate/refresh
+type Session struct {
+	AccessToken       string    `json:"accessToken"`
+	ClientToken       string    `json:"clientToken"`
+	AvailableProfiles []Profile `json:"availableProfiles"`
+	SelectedProfile   *Profile  `json:"selectedProfile"`
+}
+
+// Client talks to Yggdrasil compatible authentication servers
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a new Yggdrasil client
+func NewClient() *Client {
+	return &Client{httpClient: httpclient.New(30 * time.Second)}
+}
+
+// ResolveAPIRoot follows the authlib-injector API location indication: the server
+// may point a user-facing URL at its actual API root through the ALI header.
+func (c *Client) ResolveAPIRoot(ctx context.Context, serverURL string) (string, error) {
+	serverURL = strings.TrimSpace(serverURL)
+	if !strings.Contains(serverURL, "://") {
+		serverURL = "https://" + serverURL
+	}
+	base, err := url.Parse(serverURL)
+	if err != nil || base.Host == "" {
+		return "", fmt.Errorf("invalid server URL: %q", serverURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", download.Classify(err)
+	}
+	resp.Body.Close()
+
+	if loc := resp.Header.Get(apiLocationHeader); loc != "" {
+		if ref, err := url.Parse(loc); err == nil {
+			base = resp.Request.URL.ResolveReference(ref)
+		}
+	}
+	return strings.TrimSuffix(base.String(), "/"), nil
+}
+
+// Metadata returns the raw API metadata document served at the API root.
+// authlib-injector accepts it base64 encoded as prefetched data.
+func (c *Client) Metadata(ctx context.Context, apiRoot string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiRoot+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, download.Classify(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, download.NewHTTPStatusError(resp)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, download.Classify(err)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("server metadata is not valid JSON")
+	}
+	return body, nil
+}
+
+// PrefetchedMetadata returns the metadata document base64 encoded
+func (c *Client) PrefetchedMetadata(ctx context.Context, apiRoot string) (string, error) {
+	body, err := c.Metadata(ctx, apiRoot)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(body), nil
+}
+
+// Authenticate signs in with username (or email) and password
+func (c *Client) Authenticate(ctx context.Context, apiRoot, username, password, clientToken string) (*Session, error) {
+	body := map[string]any{
+		"agent":       map[string]any{"name": "Minecraft", "version": 1},
+		"username":    username,
+		"password":    password,
+		"clientToken": clientToken,
+		"requestUser": true,
+	}
+	var s Session
+	if err := c.post(ctx, apiRoot+"/authserver/authenticate", body, &s); err != nil {
+		var ye *Error
+		if errors.As(err, &ye) && ye.Code == "ForbiddenOperationException" {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("authenticate: %w", err)
+	}
+	return &s, nil
+}
+
+// Refresh renews an access token, optionally selecting a profile
+func (c *Client) Refresh(ctx context.Context, apiRoot, accessToken, clientToken string, selected *Profile) (*Session, error) {
+	body := map[string]any{
+		"accessToken": accessToken,
+		"clientToken": clientToken,
+		"requestUser": true,
+	}
+	if selected != nil {
+		body["selectedProfile"] = selected
+	}
+	var s Session
+	if err := c.post(ctx, apiRoot+"/authserver/refresh", body, &s); err != nil {
+		var ye *Error
+		if errors.As(err, &ye) && ye.Code == "ForbiddenOperationException" {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("refresh: %w", err)
+	}
+	return &s, nil
+}
+
+// Validate reports whether an access token is still accepted by the server
+func (c *Client) Validate(ctx context.Context, apiRoot, accessToken, clientToken string) (bool, error) {
+	body := map[string]any{"accessToken": accessToken, "clientToken": clientToken}
+	err := c.post(ctx, apiRoot+"/authserver/validate", body, nil)
+	if err == nil {
+		return true, nil
+	}
+	var ye *Error
+	if errors.As(err, &ye) {
+		return false, nil
+	}
+	return false, fmt.Errorf("validate: %w", err)
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return download.Classify(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return download.Classify(err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if out == nil || len(data) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		return nil
+	}
+
+	var ye Error
+	if json.Unmarshal(data, &ye) == nil && ye.Code != "" {
+		return &ye
+	}
+	return download.NewHTTPStatusError(resp)
+}
+
+// JVMArgs returns the JVM arguments that load authlib-injector for apiRoot.
+// prefetched (base64 metadata) is optional and saves a request at game start.
+func JVMArgs(agentJar, apiRoot, prefetched string) []string {
+	args := []string{fmt.Sprintf("-javaagent:%s=%s", agentJar, apiRoot)}
+	if prefetched != "" {
+		args = append(args, "-Dauthlibinjector.yggdrasil.prefetched="+prefetched)
+	}
+	return args
+}
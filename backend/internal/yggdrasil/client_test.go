@@ -0,0 +1,199 @@
+package yggdrasil
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"hyenimc/backend/internal/download"
+)
+
+const metadata = `{"meta": {"serverName": "Test"}, "skinDomains": ["example.com"]}`
+
+// fakeServer is an authlib-injector server whose API root is /api/yggdrasil,
+// announced on / through the API location header
+type fakeServer struct {
+	srv      *httptest.Server
+	password string
+	profiles []Profile
+
+	mu     sync.Mutex
+	tokens map[string]*Profile // issued access tokens and their bound profile
+	issued int
+}
+
+func newFakeServer(t *testing.T, profiles ...Profile) *fakeServer {
+	t.Helper()
+	f := &fakeServer{password: "secret", profiles: profiles, tokens: map[string]*Profile{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(apiLocationHeader, "/api/yggdrasil")
+		w.Write([]byte("<html>login page</html>"))
+	})
+	mux.HandleFunc("GET /api/yggdrasil/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(metadata))
+	})
+	mux.HandleFunc("POST /api/yggdrasil/authserver/authenticate", f.authenticate)
+	mux.HandleFunc("POST /api/yggdrasil/authserver/refresh", f.refresh)
+	mux.HandleFunc("POST /api/yggdrasil/authserver/validate", f.validate)
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeServer) apiRoot() string { return f.srv.URL + "/api/yggdrasil" }
+
+// forbidden writes the error Yggdrasil servers return for bad credentials and tokens
+func forbidden(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(Error{Code: "ForbiddenOperationException", Message: message})
+}
+
+// issue returns a new access token bound to p (nil until a profile is selected)
+func (f *fakeServer) issue(p *Profile) string {
+	f.issued++
+	token := fmt.Sprintf("token-%d", f.issued)
+	f.tokens[token] = p
+	return token
+}
+
+func (f *fakeServer) authenticate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username, Password, ClientToken string
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.Password != f.password {
+		forbidden(w, "Invalid credentials. Invalid username or password.")
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := Session{ClientToken: req.ClientToken, AvailableProfiles: f.profiles}
+	if len(f.profiles) == 1 {
+		s.SelectedProfile = &f.profiles[0]
+	}
+	s.AccessToken = f.issue(s.SelectedProfile)
+	json.NewEncoder(w).Encode(s)
+}
+
+func (f *fakeServer) refresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AccessToken, ClientToken string
+		SelectedProfile          *Profile
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bound, ok := f.tokens[req.AccessToken]
+	if !ok {
+		forbidden(w, "Invalid token.")
+		return
+	}
+	delete(f.tokens, req.AccessToken)
+	if req.SelectedProfile != nil {
+		bound = req.SelectedProfile
+	}
+	json.NewEncoder(w).Encode(Session{AccessToken: f.issue(bound), ClientToken: req.ClientToken, SelectedProfile: bound})
+}
+
+func (f *fakeServer) validate(w http.ResponseWriter, r *http.Request) {
+	var req struct{ AccessToken string }
+	json.NewDecoder(r.Body).Decode(&req)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.tokens[req.AccessToken]; !ok {
+		forbidden(w, "Invalid token.")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestResolveAPIRoot(t *testing.T) {
+	f := newFakeServer(t)
+	c := NewClient()
+	root, err := c.ResolveAPIRoot(context.Background(), f.srv.URL)
+	if err != nil || root != f.apiRoot() {
+		t.Errorf("got %q, %v", root, err)
+	}
+	// The API root itself carries no header and stays as it is
+	if root, err := c.ResolveAPIRoot(context.Background(), f.apiRoot()+"/"); err != nil || root != f.apiRoot() {
+		t.Errorf("API root: %q, %v", root, err)
+	}
+	if _, err := c.ResolveAPIRoot(context.Background(), "https://"); err == nil {
+		t.Error("expected an error for a URL without host")
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	f := newFakeServer(t)
+	prefetched, err := NewClient().PrefetchedMetadata(context.Background(), f.apiRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := base64.StdEncoding.DecodeString(prefetched); string(data) != metadata {
+		t.Errorf("prefetched %q", data)
+	}
+	// The login page is not a metadata document
+	if _, err := NewClient().Metadata(context.Background(), f.srv.URL); err == nil {
+		t.Error("expected an error for HTML")
+	}
+}
+
+func TestAuthenticateAndRefresh(t *testing.T) {
+	steve, alex := Profile{ID: "0123456789abcdef0123456789abcdef", Name: "Steve"}, Profile{ID: "fedcba9876543210fedcba9876543210", Name: "Alex"}
+	f := newFakeServer(t, steve, alex)
+	c := NewClient()
+	ctx := context.Background()
+
+	s, err := c.Authenticate(ctx, f.apiRoot(), "player@example.com", "secret", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ClientToken != "client" || len(s.AvailableProfiles) != 2 || s.SelectedProfile != nil {
+		t.Errorf("session %+v", s)
+	}
+
+	bound, err := c.Refresh(ctx, f.apiRoot(), s.AccessToken, s.ClientToken, &alex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bound.AccessToken == s.AccessToken || bound.SelectedProfile == nil || bound.SelectedProfile.Name != "Alex" {
+		t.Errorf("refreshed session %+v", bound)
+	}
+
+	if ok, err := c.Validate(ctx, f.apiRoot(), bound.AccessToken, "client"); !ok || err != nil {
+		t.Errorf("validate new token: %v, %v", ok, err)
+	}
+	if ok, err := c.Validate(ctx, f.apiRoot(), s.AccessToken, "client"); ok || err != nil {
+		t.Errorf("validate replaced token: %v, %v", ok, err)
+	}
+}
+
+func TestAuthErrors(t *testing.T) {
+	f := newFakeServer(t)
+	c := NewClient()
+	ctx := context.Background()
+
+	if _, err := c.Authenticate(ctx, f.apiRoot(), "player", "wrong", "client"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: %v", err)
+	}
+	if _, err := c.Refresh(ctx, f.apiRoot(), "revoked", "client", nil); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("invalid token: %v", err)
+	}
+
+	// A server that is not Yggdrasil fails with its HTTP status
+	var se *download.HTTPStatusError
+	if _, err := c.Authenticate(ctx, f.srv.URL, "player", "secret", "client"); !errors.As(err, &se) || se.StatusCode != http.StatusNotFound {
+		t.Errorf("not found: %v", err)
+	}
+	// Only a Yggdrasil error means the token is invalid
+	if ok, err := c.Validate(ctx, f.srv.URL, "token", "client"); ok || !errors.As(err, &se) {
+		t.Errorf("validate against a non-Yggdrasil server: %v, %v", ok, err)
+	}
+}
@@ -3,9 +3,13 @@ import path from 'path';
 import { app } from 'electron';
 import fs from 'fs/promises';
 import fsSync from 'fs';
+import crypto from 'crypto';
+import { AUTH_CONFIG } from '../services/auth-config';
 
 let backendProcess: ChildProcess | null = null;
 let backendAddress: string | null = null;
+let backendToken: string | null = null;
+let backendHttpToken: string | null = null;
 
 /**
  * Start the Go gRPC backend server
@@ -78,14 +82,28 @@ export async function startBackend(): Promise<string> {
       // File doesn't exist, which is fine
     }
     
+    // Per-launch secret required on every gRPC call. It goes over stdin as the
+    // first line so it never touches disk.
+    const token = crypto.randomBytes(32).toString('hex');
+    // The second line is the REST gateway token; the backend only reads it when
+    // HYENIMC_HTTP_ADDR is set and ignores it otherwise
+    const httpToken = crypto.randomBytes(32).toString('hex');
+
     // Spawn backend process
     backendProcess = spawn(binaryPath, [], {
       env: {
         ...process.env,
         HYENIMC_DATA_DIR: dataDir,
+        HYENIMC_AUTH: 'stdin',
+        // Used by the backend for Microsoft login and token refresh
+        AZURE_CLIENT_ID: AUTH_CONFIG.AZURE_CLIENT_ID,
       },
-      stdio: ['ignore', 'pipe', 'pipe'],
+      stdio: ['pipe', 'pipe', 'pipe'],
+    });
+    backendProcess.stdin?.on('error', (err) => {
+      console.error('[Backend] Failed to send auth token:', err);
     });
+    backendProcess.stdin?.end(`${token}\n${httpToken}\n`);
 
     let resolved = false;
 
@@ -110,6 +128,8 @@ export async function startBackend(): Promise<string> {
       console.log(`[Backend] Process exited with code ${code}, signal ${signal}`);
       backendProcess = null;
       backendAddress = null;
+      backendToken = null;
+      backendHttpToken = null;
     });
 
     // Poll for the port file (more reliable than stdout parsing)
@@ -122,11 +142,12 @@ export async function startBackend(): Promise<string> {
         const content = await fs.readFile(portFile, 'utf-8');
         const trimmedContent = content.trim();
         
-        // Parse format: address|pid
-        const [address, pidStr] = trimmedContent.split('|');
+        // Parse format: address|pid[|token]
+        const [address, pidStr, publishedToken] = trimmedContent.split('|');
         
         // Validate address format (security: prevent injection)
-        if (address && /^127\.0\.0\.1:\d+$/.test(address) && !resolved) {
+        // Either loopback TCP or a Unix socket (HYENIMC_ADDR=unix:<path>)
+        if (address && /^(127\.0\.0\.1:\d+|unix:[^|]+)$/.test(address) && !resolved) {
           // CRITICAL: Validate PID matches our spawned process
           const pid = parseInt(pidStr, 10);
           if (backendProcess && backendProcess.pid !== pid) {
@@ -142,6 +163,9 @@ export async function startBackend(): Promise<string> {
           }
           
           backendAddress = address;
+          // A published token means the backend ignored our handshake (started without HYENIMC_AUTH=stdin)
+          backendToken = publishedToken || token;
+          backendHttpToken = publishedToken ? null : httpToken;
           resolved = true;
           console.log('[Backend] Server listening on:', backendAddress, `(PID: ${pid})`);
           resolve(backendAddress);
@@ -175,6 +199,8 @@ export async function stopBackend(): Promise<void> {
       backendProcess!.once('exit', async () => {
         backendProcess = null;
         backendAddress = null;
+        backendToken = null;
+        backendHttpToken = null;
         
         // Clean up port file
         try {
@@ -208,6 +234,21 @@ export function getBackendAddress(): string | null {
   return backendAddress;
 }
 
+/**
+ * Get the per-launch secret gRPC calls must send as "authorization: Bearer <token>"
+ */
+export function getBackendToken(): string | null {
+  return backendToken;
+}
+
+/**
+ * Get the bearer token of the opt-in REST gateway (HYENIMC_HTTP_ADDR); null when
+ * the backend published its own tokens instead
+ */
+export function getBackendHttpToken(): string | null {
+  return backendHttpToken;
+}
+
 /**
  * Check if backend is running
  */
@@ -1,14 +1,15 @@
-import { credentials } from '@grpc/grpc-js';
-import { getBackendAddress } from '../backend/manager';
+import { credentials, InterceptingCall, type ClientOptions, type Interceptor } from '@grpc/grpc-js';
+import { getBackendAddress, getBackendToken } from '../backend/manager';
 import {
   ProfileServiceClient,
   type CreateProfileRequest,
   type Profile,
-  type ListProfilesRequest,
+  ListProfilesRequest,
   type ListProfilesResponse,
   type GetProfileRequest,
   type UpdateProfileRequest,
   type DeleteProfileRequest,
+  type DeleteProfileResponse,
 } from '../gen/launcher/profile';
 import {
   DownloadServiceClient,
@@ -123,6 +124,19 @@ import {
   type RemoveAccountRequest,
   type RemoveAccountResponse,
 } from '../gen/launcher/account';
+import {
+  SnapshotServiceClient,
+  type Snapshot,
+  type CreateSnapshotRequest,
+  type ListSnapshotsRequest,
+  type ListSnapshotsResponse,
+  type DiffSnapshotRequest,
+  type DiffSnapshotResponse,
+  type RollbackSnapshotRequest,
+  type RollbackSnapshotResponse,
+  type DeleteSnapshotRequest,
+  type DeleteSnapshotResponse,
+} from '../gen/launcher/snapshot';
 
 let profileClient: ProfileServiceClient | null = null;
 let downloadClient: DownloadServiceClient | null = null;
@@ -135,6 +149,7 @@ let settingsClient: SettingsServiceClient | null = null;
 let modClient: ModServiceClient | null = null;
 let cacheClient: CacheServiceClient | null = null;
 let accountClient: AccountServiceClient | null = null;
+let snapshotClient: SnapshotServiceClient | null = null;
 
 function ensureAddr(): string {
   const addr = getBackendAddress();
@@ -142,10 +157,23 @@ function ensureAddr(): string {
   return addr;
 }
 
+// Attaches the backend's per-launch secret to every call, unary and streaming.
+// Insecure channels cannot carry call credentials, hence an interceptor.
+const authInterceptor: Interceptor = (options, nextCall) =>
+  new InterceptingCall(nextCall(options), {
+    start(metadata, listener, next) {
+      const token = getBackendToken();
+      if (token) metadata.set('authorization', `Bearer ${token}`);
+      next(metadata, listener);
+    },
+  });
+
+const clientOptions: ClientOptions = { interceptors: [authInterceptor] };
+
 function ensureSettingsClient(): SettingsServiceClient {
   const addr = ensureAddr();
   if (!settingsClient || lastAddr !== addr) {
-    settingsClient = new SettingsServiceClient(addr, credentials.createInsecure());
+    settingsClient = new SettingsServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return settingsClient;
@@ -154,7 +182,7 @@ function ensureSettingsClient(): SettingsServiceClient {
 function ensureLoaderClient(): LoaderServiceClient {
   const addr = ensureAddr();
   if (!loaderClient || lastAddr !== addr) {
-    loaderClient = new LoaderServiceClient(addr, credentials.createInsecure());
+    loaderClient = new LoaderServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return loaderClient;
@@ -163,7 +191,7 @@ function ensureLoaderClient(): LoaderServiceClient {
 function ensureHealthClient(): HealthServiceClient {
   const addr = ensureAddr();
   if (!healthClient || lastAddr !== addr) {
-    healthClient = new HealthServiceClient(addr, credentials.createInsecure());
+    healthClient = new HealthServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return healthClient;
@@ -172,7 +200,7 @@ function ensureHealthClient(): HealthServiceClient {
 function ensureVersionClient(): VersionServiceClient {
   const addr = ensureAddr();
   if (!versionClient || lastAddr !== addr) {
-    versionClient = new VersionServiceClient(addr, credentials.createInsecure());
+    versionClient = new VersionServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return versionClient;
@@ -290,7 +318,7 @@ export function streamLogs(
 function ensureProfileClient(): ProfileServiceClient {
   const addr = ensureAddr();
   if (!profileClient || lastAddr !== addr) {
-    profileClient = new ProfileServiceClient(addr, credentials.createInsecure());
+    profileClient = new ProfileServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return profileClient;
@@ -299,7 +327,7 @@ function ensureProfileClient(): ProfileServiceClient {
 function ensureDownloadClient(): DownloadServiceClient {
   const addr = ensureAddr();
   if (!downloadClient || lastAddr !== addr) {
-    downloadClient = new DownloadServiceClient(addr, credentials.createInsecure());
+    downloadClient = new DownloadServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return downloadClient;
@@ -308,7 +336,7 @@ function ensureDownloadClient(): DownloadServiceClient {
 function ensureInstanceClient(): InstanceServiceClient {
   const addr = ensureAddr();
   if (!instanceClient || lastAddr !== addr) {
-    instanceClient = new InstanceServiceClient(addr, credentials.createInsecure());
+    instanceClient = new InstanceServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return instanceClient;
@@ -329,20 +357,21 @@ function promisify<Req, Res>(
 export const profileRpc = {
   createProfile: (req: CreateProfileRequest) =>
     promisify<CreateProfileRequest, Profile>(ensureProfileClient().createProfile.bind(ensureProfileClient()))(req),
-  listProfiles: (req: ListProfilesRequest) =>
-    promisify<ListProfilesRequest, ListProfilesResponse>(ensureProfileClient().listProfiles.bind(ensureProfileClient()))(req),
+  // Filters and sorting are optional; unset fields list every profile
+  listProfiles: (req: Partial<ListProfilesRequest> = {}) =>
+    promisify<ListProfilesRequest, ListProfilesResponse>(ensureProfileClient().listProfiles.bind(ensureProfileClient()))(ListProfilesRequest.fromPartial(req)),
   getProfile: (req: GetProfileRequest) =>
     promisify<GetProfileRequest, Profile>(ensureProfileClient().getProfile.bind(ensureProfileClient()))(req),
   updateProfile: (req: UpdateProfileRequest) =>
     promisify<UpdateProfileRequest, Profile>(ensureProfileClient().updateProfile.bind(ensureProfileClient()))(req),
   deleteProfile: (req: DeleteProfileRequest) =>
-    promisify<DeleteProfileRequest, { success: boolean }>(ensureProfileClient().deleteProfile.bind(ensureProfileClient()))(req),
+    promisify<DeleteProfileRequest, DeleteProfileResponse>(ensureProfileClient().deleteProfile.bind(ensureProfileClient()))(req),
 };
 
 function ensureModClient(): ModServiceClient {
   const addr = ensureAddr();
   if (!modClient || lastAddr !== addr) {
-    modClient = new ModServiceClient(addr, credentials.createInsecure());
+    modClient = new ModServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return modClient;
@@ -357,6 +386,28 @@ export const modRpc = {
     promisify<RefreshModCacheRequest, RefreshModCacheResponse>(ensureModClient().refreshModCache.bind(ensureModClient()))(req),
 };
 
+function ensureSnapshotClient(): SnapshotServiceClient {
+  const addr = ensureAddr();
+  if (!snapshotClient || lastAddr !== addr) {
+    snapshotClient = new SnapshotServiceClient(addr, credentials.createInsecure(), clientOptions);
+    lastAddr = addr;
+  }
+  return snapshotClient;
+}
+
+export const snapshotRpc = {
+  createSnapshot: (req: CreateSnapshotRequest) =>
+    promisify<CreateSnapshotRequest, Snapshot>(ensureSnapshotClient().createSnapshot.bind(ensureSnapshotClient()))(req),
+  listSnapshots: (req: ListSnapshotsRequest) =>
+    promisify<ListSnapshotsRequest, ListSnapshotsResponse>(ensureSnapshotClient().listSnapshots.bind(ensureSnapshotClient()))(req),
+  diffSnapshot: (req: DiffSnapshotRequest) =>
+    promisify<DiffSnapshotRequest, DiffSnapshotResponse>(ensureSnapshotClient().diffSnapshot.bind(ensureSnapshotClient()))(req),
+  rollbackSnapshot: (req: RollbackSnapshotRequest) =>
+    promisify<RollbackSnapshotRequest, RollbackSnapshotResponse>(ensureSnapshotClient().rollbackSnapshot.bind(ensureSnapshotClient()))(req),
+  deleteSnapshot: (req: DeleteSnapshotRequest) =>
+    promisify<DeleteSnapshotRequest, DeleteSnapshotResponse>(ensureSnapshotClient().deleteSnapshot.bind(ensureSnapshotClient()))(req),
+};
+
 export function streamDownloadProgress(
   req: ProgressRequest,
   onData: (ev: ProgressEvent) => void,
@@ -376,7 +427,7 @@ export function streamDownloadProgress(
 function ensureCacheClient(): CacheServiceClient {
   const addr = ensureAddr();
   if (!cacheClient || lastAddr !== addr) {
-    cacheClient = new CacheServiceClient(addr, credentials.createInsecure());
+    cacheClient = new CacheServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return cacheClient;
@@ -487,7 +538,7 @@ export const cacheRpc = {
 function ensureAccountClient(): AccountServiceClient {
   const addr = ensureAddr();
   if (!accountClient || lastAddr !== addr) {
-    accountClient = new AccountServiceClient(addr, credentials.createInsecure());
+    accountClient = new AccountServiceClient(addr, credentials.createInsecure(), clientOptions);
     lastAddr = addr;
   }
   return accountClient;
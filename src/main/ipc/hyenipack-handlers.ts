@@ -97,6 +97,23 @@ export function registerHyeniPackHandlers() {
       onProgress?: (progress: HyeniPackImportProgress) => void
     ) => {
       try {
+        // Updating an existing instance: snapshot first so the update can be rolled back
+        const existingMods = await fs.readdir(path.join(instanceDir, 'mods')).catch(() => []);
+        if (existingMods.length > 0) {
+          try {
+            const { snapshotRpc } = await import('../grpc/clients');
+            const snap = await snapshotRpc.createSnapshot({
+              profileId,
+              reason: 'hyenipack_update',
+              label: path.basename(packFilePath),
+              includeSaves: false,
+            });
+            console.log(`[IPC HyeniPack] Created snapshot ${snap.id} before import`);
+          } catch (err) {
+            console.warn('[IPC HyeniPack] Failed to create snapshot before import:', err);
+          }
+        }
+        
         const result = await hyeniPackImporter.importHyeniPack(
           packFilePath,
           profileId,
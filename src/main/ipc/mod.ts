@@ -610,6 +610,20 @@ export function registerModHandlers(): void {
       console.log(`[IPC Mod] Updating ${updates.length} mods`);
       const gameDir = getProfileInstanceDir(profileId);
       
+      // Snapshot first so the update can be rolled back
+      try {
+        const { snapshotRpc } = await import('../grpc/clients');
+        const snap = await snapshotRpc.createSnapshot({
+          profileId,
+          reason: 'mod_update',
+          label: `${updates.length} mods`,
+          includeSaves: false,
+        });
+        console.log(`[IPC Mod] Created snapshot ${snap.id} before update`);
+      } catch (err) {
+        console.warn('[IPC Mod] Failed to create snapshot before update:', err);
+      }
+      
       const result = await getModUpdater().updateMods(
         gameDir,
         updates,
@@ -242,7 +242,7 @@ export function registerModpackHandlers(): void {
           if (failure.shouldDeleteProfile) {
             // 치명적 실패 또는 취소 - 프로필 삭제
             console.log('[IPC Modpack] [Import] Deleting profile due to fatal failure:', profileId);
-            await profileRpc.deleteProfile({ id: profileId });
+            await profileRpc.deleteProfile({ id: profileId, permanent: true });
           } else {
             // 복구 가능한 실패 - failed 상태로 유지
             console.log('[IPC Modpack] [Import] Marking profile as failed:', profileId);
@@ -266,7 +266,7 @@ export function registerModpackHandlers(): void {
         if (modpackManager.isCancelled(profileId)) {
           try {
             const { profileRpc } = await import('../grpc/clients');
-            await profileRpc.deleteProfile({ id: profileId });
+            await profileRpc.deleteProfile({ id: profileId, permanent: true });
             console.log('[IPC Modpack] [Import] Deleted cancelled profile:', profileId);
           } catch (e) {
             console.error('[IPC Modpack] [Import] Failed to delete cancelled profile:', e);
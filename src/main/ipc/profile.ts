@@ -133,23 +133,9 @@ export function registerProfileHandlers(): void {
         console.warn(`[IPC Profile] Failed to stop file watcher:`, err);
       }
       
-      // 2. Delete from backend DB
-      await profileRpc.deleteProfile({ id });
-      
-      // 3. Delete profile instance directory (game files, saves, etc.)
-      const { getProfileInstanceDir } = await import('../utils/paths');
-      const instanceDir = getProfileInstanceDir(id);
-      
-      const fs = await import('fs/promises');
-      try {
-        // Wait a bit for file handles to be released
-        await new Promise(resolve => setTimeout(resolve, 100));
-        await fs.rm(instanceDir, { recursive: true, force: true });
-        console.log(`[IPC Profile] ✅ Deleted instance directory: ${instanceDir}`);
-      } catch (err) {
-        console.error(`[IPC Profile] ❌ Failed to delete instance directory:`, err);
-        // Don't throw - profile is already deleted from backend
-      }
+      // 2. Move the profile and its instance directory to the trash
+      const res = await profileRpc.deleteProfile({ id, permanent: false });
+      console.log(`[IPC Profile] Moved to trash: ${res.trashId} (${res.trashedBytes} bytes)`);
       
       console.log('[IPC Profile] Profile deleted successfully');
       return { success: true };
@@ -575,17 +561,20 @@ export function registerProfileHandlers(): void {
         ? profile.javaPath
         : (globalSettings?.java?.javaPath || java.path);
       
-      // Resolve resolution: profile overrides global settings
-      // If resolution is 0,0, use global settings (including fullscreen)
-      const useGlobalResolution = profile.resolution?.width === 0 && profile.resolution?.height === 0;
+      // Resolve resolution: the profile sets its own resolution when width or height is non-zero,
+      // and a 0 dimension inherits the global value. Fullscreen follows the profile only when it
+      // sets its own resolution (same rule as the backend's effective settings).
+      const profileWidth = profile.resolution?.width || 0;
+      const profileHeight = profile.resolution?.height || 0;
+      const useGlobalResolution = profileWidth <= 0 && profileHeight <= 0;
       
-      const resolutionWidth = useGlobalResolution
-        ? (globalSettings?.resolution?.width || 854)
-        : (profile.resolution?.width || 854);
+      const resolutionWidth = profileWidth > 0
+        ? profileWidth
+        : (globalSettings?.resolution?.width || 854);
       
-      const resolutionHeight = useGlobalResolution
-        ? (globalSettings?.resolution?.height || 480)
-        : (profile.resolution?.height || 480);
+      const resolutionHeight = profileHeight > 0
+        ? profileHeight
+        : (globalSettings?.resolution?.height || 480);
       
       // Fullscreen: use global if resolution is global
       const fullscreenToUse = useGlobalResolution
@@ -541,6 +541,8 @@ export class HyeniPackImporter {
         name: manifest.name,
         version: manifest.version,
         author: manifest.author,
+        // 권장 설정 (백엔드 GetEffectiveSettings에서 사용)
+        ...(manifest.settings ? { settings: manifest.settings } : {}),
       },
       mods: {}
     };
@@ -33,6 +33,9 @@ export interface HyeniPackManifest {
   // 모드 목록
   mods: HyeniPackModEntry[];
   
+  // 권장 설정 (메모리/Java/해상도)
+  settings?: ModpackSettings;
+  
   // 생성 정보
   createdAt: string;
   exportedFrom?: {
@@ -111,6 +114,7 @@ export interface HyeniPackManifestV2 {
   };
   mods: HyeniPackModEntry[];
   overrides: OverridePolicy[];
+  settings?: ModpackSettings;
   createdAt: string;
   exportedFrom?: {
     launcher: 'HyeniMC';